@@ -0,0 +1,67 @@
+package gokvstores
+
+import "context"
+
+// BitOperation is a bitwise operation between bitmaps.
+type BitOperation int
+
+// Bitwise operations of BitOp.
+const (
+	BitAnd BitOperation = iota + 1
+	BitOr
+	BitXor
+)
+
+// String returns the name of the bitwise operation.
+func (o BitOperation) String() string {
+	switch o {
+	case BitAnd:
+		return "AND"
+	case BitOr:
+		return "OR"
+	case BitXor:
+		return "XOR"
+	default:
+		return "unknown"
+	}
+}
+
+// BitmapStore is implemented by stores supporting bitmaps, e.g. for compact per-user flags
+// indexed by user ID. Bits are numbered from the most significant bit of the first byte
+// and bitmaps grow as needed, missing bits being 0. Byte ranges include start and end, and
+// negative offsets are offsets from the last byte, -1 being the last byte.
+type BitmapStore interface {
+	// SetBit sets the bit at offset in the bitmap stored at key, creating it if needed,
+	// and returns its previous value.
+	SetBit(ctx context.Context, key string, offset int64, value bool) (bool, error)
+
+	// GetBit returns the bit at offset in the bitmap stored at key.
+	GetBit(ctx context.Context, key string, offset int64) (bool, error)
+
+	// BitCount returns the number of bits set in the bytes between start and end
+	// of the bitmap stored at key. BitCount(ctx, key, 0, -1) counts all bits.
+	BitCount(ctx context.Context, key string, start int64, end int64) (int64, error)
+
+	// BitOp stores at dest the result of op between the bitmaps stored at keys,
+	// missing bitmaps and the end of shorter bitmaps being zeros, and returns its size
+	// in bytes, which is the size of the longest bitmap. dest is deleted if all bitmaps
+	// are missing.
+	BitOp(ctx context.Context, op BitOperation, dest string, keys ...string) (int64, error)
+
+	// BitPos returns the position of the first bit with the given value in the bytes between
+	// start and end of the bitmap stored at key, or -1 if there is none. Missing bitmaps
+	// are considered to be zeros.
+	BitPos(ctx context.Context, key string, value bool, start int64, end int64) (int64, error)
+}
+
+// Names of the BitmapStore operations.
+const (
+	OpSetBit   = "SetBit"
+	OpGetBit   = "GetBit"
+	OpBitCount = "BitCount"
+	OpBitOp    = "BitOp"
+	OpBitPos   = "BitPos"
+)
+
+// maxBitOffset is the highest bit offset of a bitmap, a Redis string holding at most 512 MB.
+const maxBitOffset = 1<<32 - 1
@@ -0,0 +1,443 @@
+package gokvstores
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrChaos is the default error returned by failures injected by ChaosStore.
+var ErrChaos = errors.New("gokvstores: injected failure")
+
+// ChaosOptions are ChaosStore options.
+type ChaosOptions struct {
+	// Seed seeds the random number generator, so that a run can be reproduced.
+	Seed int64
+
+	// Latency is added to every call.
+	Latency time.Duration
+
+	// LatencyJitter is the maximum random duration added to Latency.
+	LatencyJitter time.Duration
+
+	// ErrorRate is the probability, between 0 and 1, for a call to fail.
+	ErrorRate float64
+
+	// ErrorRates overrides ErrorRate for the given operations (see the Op constants).
+	ErrorRates map[string]float64
+
+	// TimeoutRate is the probability, between 0 and 1, for a call to hang
+	// until its context is done or Timeout elapses.
+	TimeoutRate float64
+
+	// Timeout is the maximum duration of a hanging call, after which it fails
+	// with context.DeadlineExceeded. Zero means calls hang until their context is done.
+	Timeout time.Duration
+
+	// PartialFailureRate is the probability, between 0 and 1, for each key of a batch
+	// operation (MGet, GetMaps, SetMaps) to fail. The operation is then only applied
+	// to the other keys and returns Err along with partial results.
+	PartialFailureRate float64
+
+	// Err is the error returned by injected failures. Defaults to ErrChaos.
+	Err error
+}
+
+// ChaosStore is a KVStore wrapper injecting latency and failures, to test how
+// applications behave when their store is slow or failing.
+//
+// Failures are either random, driven by ChaosOptions, or scripted with FailNext.
+type ChaosStore struct {
+	store   KVStore
+	options ChaosOptions
+
+	mu       sync.Mutex
+	rand     *rand.Rand
+	scripted []*scriptedFailure
+}
+
+// scriptedFailure is a failure injected by FailNext.
+type scriptedFailure struct {
+	op    string
+	count int
+	err   error
+}
+
+// NewChaosStore returns a ChaosStore wrapping the given store.
+func NewChaosStore(store KVStore, options ChaosOptions) *ChaosStore {
+	if options.Err == nil {
+		options.Err = ErrChaos
+	}
+
+	return &ChaosStore{
+		store:   store,
+		options: options,
+		rand:    rand.New(rand.NewSource(options.Seed)),
+	}
+}
+
+// Unwrap returns the wrapped store.
+func (c *ChaosStore) Unwrap() KVStore {
+	return c.store
+}
+
+// FailNext makes the next count calls to the given operation fail with err,
+// or with the configured error if err is nil. An empty operation matches every operation.
+// It does nothing if count is not positive.
+//
+//	store.FailNext(gokvstores.OpGet, 3, nil)
+func (c *ChaosStore) FailNext(op string, count int, err error) {
+	if count <= 0 {
+		return
+	}
+
+	if err == nil {
+		err = c.options.Err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.scripted = append(c.scripted, &scriptedFailure{op: op, count: count, err: err})
+}
+
+// Reset removes all scripted failures.
+func (c *ChaosStore) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.scripted = nil
+}
+
+// Get returns value for the given key.
+func (c *ChaosStore) Get(ctx context.Context, key string) (interface{}, error) {
+	if err := c.inject(ctx, OpGet); err != nil {
+		return nil, err
+	}
+	return c.store.Get(ctx, key)
+}
+
+// MGet returns map of key, value for a list of keys.
+func (c *ChaosStore) MGet(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	if err := c.inject(ctx, OpMGet); err != nil {
+		return nil, err
+	}
+
+	kept, failed := c.partition(keys)
+
+	values, err := c.store.MGet(ctx, kept)
+	if err == nil && failed {
+		err = c.options.Err
+	}
+
+	return values, err
+}
+
+// Set sets value for the given key.
+func (c *ChaosStore) Set(ctx context.Context, key string, value interface{}) error {
+	if err := c.inject(ctx, OpSet); err != nil {
+		return err
+	}
+	return c.store.Set(ctx, key, value)
+}
+
+// SetWithExpiration sets the value for the given key for a specified duration.
+func (c *ChaosStore) SetWithExpiration(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	if err := c.inject(ctx, OpSetWithExpiration); err != nil {
+		return err
+	}
+	return c.store.SetWithExpiration(ctx, key, value, expiration)
+}
+
+// GetMap returns map for the given key.
+func (c *ChaosStore) GetMap(ctx context.Context, key string) (map[string]interface{}, error) {
+	if err := c.inject(ctx, OpGetMap); err != nil {
+		return nil, err
+	}
+	return c.store.GetMap(ctx, key)
+}
+
+// GetMaps returns maps for the given keys.
+func (c *ChaosStore) GetMaps(ctx context.Context, keys []string) (map[string]map[string]interface{}, error) {
+	if err := c.inject(ctx, OpGetMaps); err != nil {
+		return nil, err
+	}
+
+	kept, failed := c.partition(keys)
+
+	values, err := c.store.GetMaps(ctx, kept)
+	if err == nil && failed {
+		err = c.options.Err
+	}
+
+	return values, err
+}
+
+// SetMap sets map for the given key.
+func (c *ChaosStore) SetMap(ctx context.Context, key string, value map[string]interface{}) error {
+	if err := c.inject(ctx, OpSetMap); err != nil {
+		return err
+	}
+	return c.store.SetMap(ctx, key, value)
+}
+
+// SetMaps sets the given maps.
+func (c *ChaosStore) SetMaps(ctx context.Context, maps map[string]map[string]interface{}) error {
+	if err := c.inject(ctx, OpSetMaps); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(maps))
+	for key := range maps {
+		keys = append(keys, key)
+	}
+
+	// Keys are sorted so that failures only depend on the seed.
+	sort.Strings(keys)
+
+	kept, failed := c.partition(keys)
+
+	partial := make(map[string]map[string]interface{}, len(kept))
+	for _, key := range kept {
+		partial[key] = maps[key]
+	}
+
+	if err := c.store.SetMaps(ctx, partial); err != nil {
+		return err
+	}
+
+	if failed {
+		return c.options.Err
+	}
+
+	return nil
+}
+
+// DeleteMap removes the specified fields from the map stored at key.
+func (c *ChaosStore) DeleteMap(ctx context.Context, key string, fields ...string) error {
+	if err := c.inject(ctx, OpDeleteMap); err != nil {
+		return err
+	}
+	return c.store.DeleteMap(ctx, key, fields...)
+}
+
+// GetSlice returns slice for the given key.
+func (c *ChaosStore) GetSlice(ctx context.Context, key string) ([]interface{}, error) {
+	if err := c.inject(ctx, OpGetSlice); err != nil {
+		return nil, err
+	}
+	return c.store.GetSlice(ctx, key)
+}
+
+// SetSlice sets slice for the given key.
+func (c *ChaosStore) SetSlice(ctx context.Context, key string, value []interface{}) error {
+	if err := c.inject(ctx, OpSetSlice); err != nil {
+		return err
+	}
+	return c.store.SetSlice(ctx, key, value)
+}
+
+// AppendSlice appends values to an existing slice.
+// If key does not exist, creates slice.
+func (c *ChaosStore) AppendSlice(ctx context.Context, key string, values ...interface{}) error {
+	if err := c.inject(ctx, OpAppendSlice); err != nil {
+		return err
+	}
+	return c.store.AppendSlice(ctx, key, values...)
+}
+
+// Exists checks if all the given keys exist.
+func (c *ChaosStore) Exists(ctx context.Context, keys ...string) (bool, error) {
+	if err := c.inject(ctx, OpExists); err != nil {
+		return false, err
+	}
+	return c.store.Exists(ctx, keys...)
+}
+
+// ExistsAll checks if all the given keys exist.
+func (c *ChaosStore) ExistsAll(ctx context.Context, keys ...string) (bool, error) {
+	if err := c.inject(ctx, OpExistsAll); err != nil {
+		return false, err
+	}
+	return c.store.ExistsAll(ctx, keys...)
+}
+
+// ExistsAny checks if at least one of the given keys exists.
+func (c *ChaosStore) ExistsAny(ctx context.Context, keys ...string) (bool, error) {
+	if err := c.inject(ctx, OpExistsAny); err != nil {
+		return false, err
+	}
+	return c.store.ExistsAny(ctx, keys...)
+}
+
+// CountExisting returns the number of given keys that exist.
+func (c *ChaosStore) CountExisting(ctx context.Context, keys ...string) (int, error) {
+	if err := c.inject(ctx, OpCountExisting); err != nil {
+		return 0, err
+	}
+	return c.store.CountExisting(ctx, keys...)
+}
+
+// Delete deletes the given key.
+func (c *ChaosStore) Delete(ctx context.Context, key string) error {
+	if err := c.inject(ctx, OpDelete); err != nil {
+		return err
+	}
+	return c.store.Delete(ctx, key)
+}
+
+// Flush flushes the store.
+func (c *ChaosStore) Flush(ctx context.Context) error {
+	if err := c.inject(ctx, OpFlush); err != nil {
+		return err
+	}
+	return c.store.Flush(ctx)
+}
+
+// Keys returns all keys matching pattern.
+func (c *ChaosStore) Keys(ctx context.Context, pattern string) ([]interface{}, error) {
+	if err := c.inject(ctx, OpKeys); err != nil {
+		return nil, err
+	}
+	return c.store.Keys(ctx, pattern)
+}
+
+// Close closes the wrapped store.
+// Only scripted failures apply to Close, since it does not take a context.
+func (c *ChaosStore) Close() error {
+	c.mu.Lock()
+	err := c.nextScripted(OpClose)
+	c.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return c.store.Close()
+}
+
+// inject applies the configured latency and failures to a call to the given operation.
+func (c *ChaosStore) inject(ctx context.Context, op string) error {
+	c.mu.Lock()
+
+	if err := c.nextScripted(op); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+
+	latency := c.options.Latency
+	if c.options.LatencyJitter > 0 {
+		latency += time.Duration(c.rand.Int63n(int64(c.options.LatencyJitter)))
+	}
+
+	timeout := c.options.TimeoutRate > 0 && c.rand.Float64() < c.options.TimeoutRate
+
+	rate, ok := c.options.ErrorRates[op]
+	if !ok {
+		rate = c.options.ErrorRate
+	}
+	failed := rate > 0 && c.rand.Float64() < rate
+
+	c.mu.Unlock()
+
+	if err := sleep(ctx, latency); err != nil {
+		return err
+	}
+
+	if timeout {
+		if c.options.Timeout <= 0 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		if err := sleep(ctx, c.options.Timeout); err != nil {
+			return err
+		}
+
+		return context.DeadlineExceeded
+	}
+
+	if failed {
+		return c.options.Err
+	}
+
+	return nil
+}
+
+// nextScripted returns the error of the next scripted failure matching op, if any.
+// It must be called with c.mu held.
+func (c *ChaosStore) nextScripted(op string) error {
+	for i, f := range c.scripted {
+		if f.op != "" && f.op != op {
+			continue
+		}
+
+		f.count--
+		if f.count <= 0 {
+			c.scripted = append(c.scripted[:i], c.scripted[i+1:]...)
+		}
+
+		return f.err
+	}
+
+	return nil
+}
+
+// partition returns the keys of a batch operation which do not fail,
+// and whether some keys failed.
+func (c *ChaosStore) partition(keys []string) ([]string, bool) {
+	if c.options.PartialFailureRate <= 0 {
+		return keys, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kept := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if c.rand.Float64() >= c.options.PartialFailureRate {
+			kept = append(kept, key)
+		}
+	}
+
+	return kept, len(kept) < len(keys)
+}
+
+// sleep waits for the given duration or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunScript runs script on the wrapped store, which must implement ScriptStore.
+func (c *ChaosStore) RunScript(ctx context.Context, script *Script, keys []string, args ...interface{}) *ScriptResult {
+	if err := c.inject(ctx, OpRunScript); err != nil {
+		return &ScriptResult{err: err}
+	}
+	return RunScript(ctx, c.store, script, keys, args...)
+}
+
+// Health checks the wrapped store, which should implement HealthChecker.
+func (c *ChaosStore) Health(ctx context.Context) HealthStatus {
+	return Health(ctx, c.store)
+}
+
+var (
+	_ KVStore       = &ChaosStore{}
+	_ ScriptStore   = &ChaosStore{}
+	_ HealthChecker = &ChaosStore{}
+)
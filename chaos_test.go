@@ -0,0 +1,149 @@
+package gokvstores_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+	"github.com/ulule/gokvstores/kvstoretest"
+)
+
+func newChaosStore(t *testing.T, options gokvstores.ChaosOptions) *gokvstores.ChaosStore {
+	store, err := gokvstores.NewMemoryStore(time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	return gokvstores.NewChaosStore(store, options)
+}
+
+func TestChaosStore(t *testing.T) {
+	kvstoretest.RunConformance(t, func(t *testing.T) gokvstores.KVStore {
+		return newChaosStore(t, gokvstores.ChaosOptions{})
+	})
+}
+
+func TestChaosStore_FailNext(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store := newChaosStore(t, gokvstores.ChaosOptions{})
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	errCustom := errors.New("custom")
+
+	store.FailNext(gokvstores.OpGet, 3, nil)
+	store.FailNext("", 1, errCustom)
+
+	for i := 0; i < 3; i++ {
+		_, err := store.Get(ctx, "key")
+		is.Equal(gokvstores.ErrChaos, err)
+	}
+
+	is.Equal(errCustom, store.Set(ctx, "key", "other"))
+
+	v, err := store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("value", v)
+
+	store.FailNext(gokvstores.OpGet, 1, nil)
+	store.Reset()
+
+	_, err = store.Get(ctx, "key")
+	is.NoError(err)
+
+	// Counts which are not positive script no failure.
+	store.FailNext(gokvstores.OpGet, 0, nil)
+	store.FailNext("", -1, nil)
+
+	_, err = store.Get(ctx, "key")
+	is.NoError(err)
+	is.NoError(store.Set(ctx, "key", "value"))
+}
+
+func TestChaosStore_ErrorRates(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	options := gokvstores.ChaosOptions{
+		Seed:       42,
+		ErrorRate:  0.5,
+		ErrorRates: map[string]float64{gokvstores.OpSet: 0},
+	}
+
+	run := func() []bool {
+		store := newChaosStore(t, options)
+
+		failures := make([]bool, 50)
+		for i := range failures {
+			is.NoError(store.Set(ctx, "key", "value"))
+
+			_, err := store.Get(ctx, "key")
+			failures[i] = err != nil
+		}
+
+		return failures
+	}
+
+	failures := run()
+	is.Equal(failures, run())
+	is.Contains(failures, true)
+	is.Contains(failures, false)
+}
+
+func TestChaosStore_Latency(t *testing.T) {
+	is := assert.New(t)
+
+	store := newChaosStore(t, gokvstores.ChaosOptions{Latency: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := store.Get(ctx, "key")
+	is.Equal(context.DeadlineExceeded, err)
+	is.True(time.Since(start) < time.Second)
+}
+
+func TestChaosStore_Timeouts(t *testing.T) {
+	is := assert.New(t)
+
+	store := newChaosStore(t, gokvstores.ChaosOptions{TimeoutRate: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := store.Get(ctx, "key")
+	is.Equal(context.DeadlineExceeded, err)
+
+	store = newChaosStore(t, gokvstores.ChaosOptions{TimeoutRate: 1, Timeout: 10 * time.Millisecond})
+
+	_, err = store.Get(context.Background(), "key")
+	is.Equal(context.DeadlineExceeded, err)
+}
+
+func TestChaosStore_PartialFailures(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store := newChaosStore(t, gokvstores.ChaosOptions{Seed: 1, PartialFailureRate: 0.5})
+
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	maps := map[string]map[string]interface{}{}
+	for _, key := range keys {
+		maps[key] = map[string]interface{}{"key": key}
+	}
+
+	is.Equal(gokvstores.ErrChaos, store.SetMaps(ctx, maps))
+
+	count, err := store.CountExisting(ctx, keys...)
+	is.NoError(err)
+	is.True(count > 0 && count < len(keys))
+
+	values, err := store.GetMaps(ctx, keys)
+	is.Equal(gokvstores.ErrChaos, err)
+	is.True(len(values) < len(keys))
+}
@@ -0,0 +1,450 @@
+package gokvstores
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by CircuitBreakerStore calls rejected while its circuit
+// is open, when it has no fallback store.
+var ErrCircuitOpen = errors.New("gokvstores: circuit open")
+
+// CircuitState is the state of a CircuitBreakerStore circuit.
+type CircuitState int
+
+const (
+	// CircuitClosed is the normal state: calls are sent to the store.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen is the tripped state: calls are sent to the fallback store.
+	CircuitOpen
+
+	// CircuitHalfOpen is the probing state: a limited number of calls are sent to the store
+	// to check whether it recovered, the others are sent to the fallback store.
+	CircuitHalfOpen
+)
+
+// String implements fmt.Stringer.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerOptions are CircuitBreakerStore options.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failures which trips the circuit.
+	// Defaults to 5.
+	FailureThreshold int
+
+	// ErrorRate is the failure rate, between 0 and 1, over Window which trips the circuit.
+	// Zero disables it.
+	ErrorRate float64
+
+	// MinRequests is the minimum number of calls in Window for ErrorRate to apply.
+	// Defaults to 10.
+	MinRequests int
+
+	// Window is the duration over which ErrorRate is computed. Defaults to 10 seconds.
+	Window time.Duration
+
+	// OpenTimeout is how long the circuit stays open before probing the store.
+	// Defaults to 5 seconds.
+	OpenTimeout time.Duration
+
+	// HalfOpenMaxCalls is the number of concurrent probe calls in the half-open state.
+	// Defaults to 1.
+	HalfOpenMaxCalls int
+
+	// Fallback is the store receiving calls while the circuit is open.
+	// If nil, these calls fail with ErrCircuitOpen.
+	Fallback KVStore
+
+	// IsFailure reports whether an error counts as a failure of the store.
+	// Defaults to IsRetryable, so that errors such as ErrWrongType do not trip the circuit.
+	IsFailure func(err error) bool
+
+	// OnStateChange is called on every state change, outside of the breaker lock.
+	OnStateChange func(from CircuitState, to CircuitState)
+}
+
+// CircuitBreakerStore is a KVStore wrapper which stops sending calls to a failing store,
+// so that callers do not wait for its timeouts, and sends them to a fallback store instead
+// until the store recovers.
+type CircuitBreakerStore struct {
+	store   KVStore
+	options CircuitBreakerOptions
+
+	mu          sync.Mutex
+	state       CircuitState
+	consecutive int
+	windowStart time.Time
+	calls       int
+	failures    int
+	openedAt    time.Time
+	probes      int
+}
+
+// NewCircuitBreakerStore returns a CircuitBreakerStore wrapping the given store.
+func NewCircuitBreakerStore(store KVStore, options CircuitBreakerOptions) *CircuitBreakerStore {
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = 5
+	}
+
+	if options.MinRequests <= 0 {
+		options.MinRequests = 10
+	}
+
+	if options.Window <= 0 {
+		options.Window = 10 * time.Second
+	}
+
+	if options.OpenTimeout <= 0 {
+		options.OpenTimeout = 5 * time.Second
+	}
+
+	if options.HalfOpenMaxCalls <= 0 {
+		options.HalfOpenMaxCalls = 1
+	}
+
+	if options.IsFailure == nil {
+		options.IsFailure = IsRetryable
+	}
+
+	return &CircuitBreakerStore{
+		store:   store,
+		options: options,
+	}
+}
+
+// Unwrap returns the wrapped store.
+func (c *CircuitBreakerStore) Unwrap() KVStore {
+	return c.store
+}
+
+// State returns the current state of the circuit.
+// An open circuit becomes half-open on the first call after OpenTimeout.
+func (c *CircuitBreakerStore) State() CircuitState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.state
+}
+
+// call calls fn with the store if the circuit allows it, with the fallback store otherwise.
+func (c *CircuitBreakerStore) call(fn func(store KVStore) error) error {
+	allowed, probe := c.allow()
+	if !allowed {
+		if c.options.Fallback == nil {
+			return ErrCircuitOpen
+		}
+		return fn(c.options.Fallback)
+	}
+
+	err := fn(c.store)
+	c.done(probe, err)
+
+	return err
+}
+
+// allow reports whether a call can be sent to the store, and whether it is a probe.
+func (c *CircuitBreakerStore) allow() (bool, bool) {
+	c.mu.Lock()
+
+	from := c.state
+
+	if c.state == CircuitOpen {
+		if time.Since(c.openedAt) < c.options.OpenTimeout {
+			c.mu.Unlock()
+			return false, false
+		}
+
+		c.state = CircuitHalfOpen
+		c.probes = 0
+	}
+
+	allowed, probe := true, false
+	if c.state == CircuitHalfOpen {
+		if c.probes >= c.options.HalfOpenMaxCalls {
+			allowed = false
+		} else {
+			c.probes++
+			probe = true
+		}
+	}
+
+	to := c.state
+	c.mu.Unlock()
+
+	c.notify(from, to)
+
+	return allowed, probe
+}
+
+// done records the result of a call sent to the store.
+// Results of calls sent before the last state change are ignored.
+func (c *CircuitBreakerStore) done(probe bool, err error) {
+	failed := err != nil && c.options.IsFailure(err)
+
+	c.mu.Lock()
+
+	from := c.state
+
+	switch {
+	case c.state == CircuitHalfOpen && probe:
+		c.probes--
+		if failed {
+			c.open()
+		} else {
+			c.close()
+		}
+	case c.state == CircuitClosed && !probe:
+		now := time.Now()
+		if now.Sub(c.windowStart) >= c.options.Window {
+			c.windowStart = now
+			c.calls = 0
+			c.failures = 0
+		}
+
+		c.calls++
+
+		if failed {
+			c.failures++
+			c.consecutive++
+		} else {
+			c.consecutive = 0
+		}
+
+		if c.consecutive >= c.options.FailureThreshold ||
+			(c.options.ErrorRate > 0 && c.calls >= c.options.MinRequests &&
+				float64(c.failures)/float64(c.calls) >= c.options.ErrorRate) {
+			c.open()
+		}
+	}
+
+	to := c.state
+	c.mu.Unlock()
+
+	c.notify(from, to)
+}
+
+// open trips the circuit. It must be called with c.mu held.
+func (c *CircuitBreakerStore) open() {
+	c.state = CircuitOpen
+	c.openedAt = time.Now()
+}
+
+// close closes the circuit and resets its counters. It must be called with c.mu held.
+func (c *CircuitBreakerStore) close() {
+	c.state = CircuitClosed
+	c.consecutive = 0
+	c.windowStart = time.Now()
+	c.calls = 0
+	c.failures = 0
+}
+
+func (c *CircuitBreakerStore) notify(from CircuitState, to CircuitState) {
+	if from != to && c.options.OnStateChange != nil {
+		c.options.OnStateChange(from, to)
+	}
+}
+
+// Get returns value for the given key.
+func (c *CircuitBreakerStore) Get(ctx context.Context, key string) (interface{}, error) {
+	var v interface{}
+	err := c.call(func(store KVStore) (err error) {
+		v, err = store.Get(ctx, key)
+		return err
+	})
+	return v, err
+}
+
+// MGet returns map of key, value for a list of keys.
+func (c *CircuitBreakerStore) MGet(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	var v map[string]interface{}
+	err := c.call(func(store KVStore) (err error) {
+		v, err = store.MGet(ctx, keys)
+		return err
+	})
+	return v, err
+}
+
+// Set sets value for the given key.
+func (c *CircuitBreakerStore) Set(ctx context.Context, key string, value interface{}) error {
+	return c.call(func(store KVStore) error {
+		return store.Set(ctx, key, value)
+	})
+}
+
+// SetWithExpiration sets the value for the given key for a specified duration.
+func (c *CircuitBreakerStore) SetWithExpiration(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return c.call(func(store KVStore) error {
+		return store.SetWithExpiration(ctx, key, value, expiration)
+	})
+}
+
+// GetMap returns map for the given key.
+func (c *CircuitBreakerStore) GetMap(ctx context.Context, key string) (map[string]interface{}, error) {
+	var v map[string]interface{}
+	err := c.call(func(store KVStore) (err error) {
+		v, err = store.GetMap(ctx, key)
+		return err
+	})
+	return v, err
+}
+
+// GetMaps returns maps for the given keys.
+func (c *CircuitBreakerStore) GetMaps(ctx context.Context, keys []string) (map[string]map[string]interface{}, error) {
+	var v map[string]map[string]interface{}
+	err := c.call(func(store KVStore) (err error) {
+		v, err = store.GetMaps(ctx, keys)
+		return err
+	})
+	return v, err
+}
+
+// SetMap sets map for the given key.
+func (c *CircuitBreakerStore) SetMap(ctx context.Context, key string, value map[string]interface{}) error {
+	return c.call(func(store KVStore) error {
+		return store.SetMap(ctx, key, value)
+	})
+}
+
+// SetMaps sets the given maps.
+func (c *CircuitBreakerStore) SetMaps(ctx context.Context, maps map[string]map[string]interface{}) error {
+	return c.call(func(store KVStore) error {
+		return store.SetMaps(ctx, maps)
+	})
+}
+
+// DeleteMap removes the specified fields from the map stored at key.
+func (c *CircuitBreakerStore) DeleteMap(ctx context.Context, key string, fields ...string) error {
+	return c.call(func(store KVStore) error {
+		return store.DeleteMap(ctx, key, fields...)
+	})
+}
+
+// GetSlice returns slice for the given key.
+func (c *CircuitBreakerStore) GetSlice(ctx context.Context, key string) ([]interface{}, error) {
+	var v []interface{}
+	err := c.call(func(store KVStore) (err error) {
+		v, err = store.GetSlice(ctx, key)
+		return err
+	})
+	return v, err
+}
+
+// SetSlice sets slice for the given key.
+func (c *CircuitBreakerStore) SetSlice(ctx context.Context, key string, value []interface{}) error {
+	return c.call(func(store KVStore) error {
+		return store.SetSlice(ctx, key, value)
+	})
+}
+
+// AppendSlice appends values to an existing slice.
+// If key does not exist, creates slice.
+func (c *CircuitBreakerStore) AppendSlice(ctx context.Context, key string, values ...interface{}) error {
+	return c.call(func(store KVStore) error {
+		return store.AppendSlice(ctx, key, values...)
+	})
+}
+
+// Exists checks if all the given keys exist.
+func (c *CircuitBreakerStore) Exists(ctx context.Context, keys ...string) (bool, error) {
+	var v bool
+	err := c.call(func(store KVStore) (err error) {
+		v, err = store.Exists(ctx, keys...)
+		return err
+	})
+	return v, err
+}
+
+// ExistsAll checks if all the given keys exist.
+func (c *CircuitBreakerStore) ExistsAll(ctx context.Context, keys ...string) (bool, error) {
+	var v bool
+	err := c.call(func(store KVStore) (err error) {
+		v, err = store.ExistsAll(ctx, keys...)
+		return err
+	})
+	return v, err
+}
+
+// ExistsAny checks if at least one of the given keys exists.
+func (c *CircuitBreakerStore) ExistsAny(ctx context.Context, keys ...string) (bool, error) {
+	var v bool
+	err := c.call(func(store KVStore) (err error) {
+		v, err = store.ExistsAny(ctx, keys...)
+		return err
+	})
+	return v, err
+}
+
+// CountExisting returns the number of given keys that exist.
+func (c *CircuitBreakerStore) CountExisting(ctx context.Context, keys ...string) (int, error) {
+	var v int
+	err := c.call(func(store KVStore) (err error) {
+		v, err = store.CountExisting(ctx, keys...)
+		return err
+	})
+	return v, err
+}
+
+// Delete deletes the given key.
+func (c *CircuitBreakerStore) Delete(ctx context.Context, key string) error {
+	return c.call(func(store KVStore) error {
+		return store.Delete(ctx, key)
+	})
+}
+
+// Flush flushes the store.
+func (c *CircuitBreakerStore) Flush(ctx context.Context) error {
+	return c.call(func(store KVStore) error {
+		return store.Flush(ctx)
+	})
+}
+
+// Keys returns all keys matching pattern.
+func (c *CircuitBreakerStore) Keys(ctx context.Context, pattern string) ([]interface{}, error) {
+	var v []interface{}
+	err := c.call(func(store KVStore) (err error) {
+		v, err = store.Keys(ctx, pattern)
+		return err
+	})
+	return v, err
+}
+
+// Close closes the wrapped store, whatever the state of the circuit.
+// The fallback store is not closed.
+func (c *CircuitBreakerStore) Close() error {
+	return c.store.Close()
+}
+
+// RunScript runs script on the wrapped store, which must implement ScriptStore.
+func (c *CircuitBreakerStore) RunScript(ctx context.Context, script *Script, keys []string, args ...interface{}) *ScriptResult {
+	var v interface{}
+	err := c.call(func(store KVStore) (err error) {
+		v, err = RunScript(ctx, store, script, keys, args...).Result()
+		return err
+	})
+	return &ScriptResult{val: v, err: err}
+}
+
+// Health checks the wrapped store, which should implement HealthChecker.
+func (c *CircuitBreakerStore) Health(ctx context.Context) HealthStatus {
+	return Health(ctx, c.store)
+}
+
+var (
+	_ KVStore       = &CircuitBreakerStore{}
+	_ ScriptStore   = &CircuitBreakerStore{}
+	_ HealthChecker = &CircuitBreakerStore{}
+)
@@ -0,0 +1,148 @@
+package gokvstores_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+	"github.com/ulule/gokvstores/kvstoretest"
+)
+
+type stateChanges struct {
+	mu      sync.Mutex
+	changes []string
+}
+
+func (s *stateChanges) record(from gokvstores.CircuitState, to gokvstores.CircuitState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.changes = append(s.changes, from.String()+" -> "+to.String())
+}
+
+func (s *stateChanges) list() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.changes...)
+}
+
+func TestCircuitBreakerStore(t *testing.T) {
+	kvstoretest.RunConformance(t, func(t *testing.T) gokvstores.KVStore {
+		return gokvstores.NewCircuitBreakerStore(newChaosStore(t, gokvstores.ChaosOptions{}), gokvstores.CircuitBreakerOptions{})
+	})
+}
+
+func TestCircuitBreakerStore_Fallback(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	fallback, err := gokvstores.NewMemoryStore(time.Minute, time.Minute)
+	require.NoError(t, err)
+	is.NoError(fallback.Set(ctx, "key", "fallback"))
+
+	chaos := newChaosStore(t, gokvstores.ChaosOptions{})
+	is.NoError(chaos.Set(ctx, "key", "value"))
+
+	changes := &stateChanges{}
+
+	store := gokvstores.NewCircuitBreakerStore(chaos, gokvstores.CircuitBreakerOptions{
+		FailureThreshold: 3,
+		OpenTimeout:      50 * time.Millisecond,
+		Fallback:         fallback,
+		OnStateChange:    changes.record,
+	})
+
+	chaos.FailNext("", 3, gokvstores.ErrUnavailable)
+
+	for i := 0; i < 3; i++ {
+		_, err := store.Get(ctx, "key")
+		is.Equal(gokvstores.ErrUnavailable, err)
+	}
+
+	is.Equal(gokvstores.CircuitOpen, store.State())
+
+	v, err := store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("fallback", v)
+
+	// Failed probe
+
+	time.Sleep(60 * time.Millisecond)
+
+	chaos.FailNext("", 1, gokvstores.ErrTimeout)
+
+	_, err = store.Get(ctx, "key")
+	is.Equal(gokvstores.ErrTimeout, err)
+	is.Equal(gokvstores.CircuitOpen, store.State())
+
+	// Successful probe
+
+	time.Sleep(60 * time.Millisecond)
+
+	v, err = store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("value", v)
+	is.Equal(gokvstores.CircuitClosed, store.State())
+
+	is.Equal([]string{
+		"closed -> open",
+		"open -> half-open",
+		"half-open -> open",
+		"open -> half-open",
+		"half-open -> closed",
+	}, changes.list())
+}
+
+func TestCircuitBreakerStore_ErrorRate(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	chaos := newChaosStore(t, gokvstores.ChaosOptions{})
+
+	store := gokvstores.NewCircuitBreakerStore(chaos, gokvstores.CircuitBreakerOptions{
+		FailureThreshold: 100,
+		ErrorRate:        0.5,
+		MinRequests:      4,
+		Window:           time.Minute,
+	})
+
+	for i := 0; i < 2; i++ {
+		chaos.FailNext("", 1, gokvstores.ErrTimeout)
+		_, err := store.Get(ctx, "key")
+		is.Error(err)
+		is.Equal(gokvstores.CircuitClosed, store.State())
+
+		_, err = store.Get(ctx, "key")
+		is.NoError(err)
+	}
+
+	is.Equal(gokvstores.CircuitOpen, store.State())
+
+	_, err := store.Get(ctx, "key")
+	is.Equal(gokvstores.ErrCircuitOpen, err)
+}
+
+func TestCircuitBreakerStore_IsFailure(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	chaos := newChaosStore(t, gokvstores.ChaosOptions{})
+
+	store := gokvstores.NewCircuitBreakerStore(chaos, gokvstores.CircuitBreakerOptions{FailureThreshold: 1})
+
+	chaos.FailNext("", 1, gokvstores.ErrWrongType)
+	_, err := store.GetMap(ctx, "key")
+	is.Equal(gokvstores.ErrWrongType, err)
+	is.Equal(gokvstores.CircuitClosed, store.State())
+
+	chaos.FailNext("", 1, gokvstores.ErrUnavailable)
+	_, err = store.GetMap(ctx, "key")
+	is.Equal(gokvstores.ErrUnavailable, err)
+	is.Equal(gokvstores.CircuitOpen, store.State())
+}
@@ -67,11 +67,26 @@ func (DummyStore) AppendSlice(ctx context.Context, key string, values ...interfa
 	return nil
 }
 
-// Exists checks if the given key exists.
+// Exists checks if all the given keys exist.
 func (DummyStore) Exists(ctx context.Context, keys ...string) (bool, error) {
 	return false, nil
 }
 
+// ExistsAll checks if all the given keys exist.
+func (DummyStore) ExistsAll(ctx context.Context, keys ...string) (bool, error) {
+	return false, nil
+}
+
+// ExistsAny checks if at least one of the given keys exists.
+func (DummyStore) ExistsAny(ctx context.Context, keys ...string) (bool, error) {
+	return false, nil
+}
+
+// CountExisting returns the number of given keys that exist.
+func (DummyStore) CountExisting(ctx context.Context, keys ...string) (int, error) {
+	return 0, nil
+}
+
 // Delete deletes the given key.
 func (DummyStore) Delete(ctx context.Context, key string) error {
 	return nil
@@ -92,4 +107,15 @@ func (DummyStore) Close() error {
 	return nil
 }
 
-var _ KVStore = &DummyStore{}
+// Health reports that the store is healthy.
+func (DummyStore) Health(ctx context.Context) HealthStatus {
+	return HealthStatus{
+		Healthy: true,
+		Backend: BackendDummy,
+	}
+}
+
+var (
+	_ KVStore       = &DummyStore{}
+	_ HealthChecker = &DummyStore{}
+)
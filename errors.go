@@ -0,0 +1,153 @@
+package gokvstores
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	redis "github.com/go-redis/redis/v8"
+)
+
+var (
+	// ErrNotFound is returned when an operation requires a key which does not exist.
+	ErrNotFound = errors.New("gokvstores: key not found")
+
+	// ErrWrongType is returned when an operation is applied to a key holding
+	// a value of another type, e.g. GetMap on a key set with Set.
+	ErrWrongType = errors.New("gokvstores: wrong value type")
+
+	// ErrTimeout is returned when an operation did not complete in time.
+	ErrTimeout = errors.New("gokvstores: timeout")
+
+	// ErrUnavailable is returned when the store cannot be reached or cannot serve requests.
+	ErrUnavailable = errors.New("gokvstores: store unavailable")
+
+	// ErrNoGroup is returned when a stream operation refers to a consumer group
+	// which does not exist.
+	ErrNoGroup = errors.New("gokvstores: no such consumer group")
+)
+
+// OpError is the error returned by store operations.
+//
+// It matches, with errors.Is, the sentinel error (ErrNotFound, ErrWrongType, ErrTimeout,
+// ErrUnavailable or ErrNoGroup) corresponding to the underlying error, so that callers can decide
+// whether to fall back or retry without depending on the backend errors:
+//
+//	if errors.Is(err, gokvstores.ErrUnavailable) {
+//		// fall back
+//	}
+//
+// The underlying error is still available with errors.As or errors.Unwrap.
+type OpError struct {
+	// Op is the operation which failed (see the Op constants).
+	Op string
+
+	// Key is the key of the operation, or its first key for batch operations.
+	Key string
+
+	// Backend is the name of the store backend (see BackendName).
+	Backend string
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements error.
+func (e *OpError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("gokvstores: %s %s: %v", e.Backend, e.Op, e.Err)
+	}
+	return fmt.Sprintf("gokvstores: %s %s %q: %v", e.Backend, e.Op, e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the underlying error is classified as target,
+// target being one of the sentinel errors.
+func (e *OpError) Is(target error) bool {
+	switch target {
+	case ErrNotFound, ErrWrongType, ErrTimeout, ErrUnavailable, ErrNoGroup:
+		return classify(e.Err) == target
+	default:
+		return false
+	}
+}
+
+// newOpError returns an OpError for the given operation, or nil if err is nil.
+func newOpError(backend string, op string, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &OpError{
+		Op:      op,
+		Key:     key,
+		Backend: backend,
+		Err:     err,
+	}
+}
+
+// firstKey returns the first of the given keys, or an empty string.
+func firstKey(keys []string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[0]
+}
+
+// classify returns the sentinel error corresponding to err, or nil if err is not classified.
+func classify(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrNotFound), errors.Is(err, redis.Nil):
+		return ErrNotFound
+	case errors.Is(err, ErrWrongType):
+		return ErrWrongType
+	case errors.Is(err, ErrNoGroup):
+		return ErrNoGroup
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout
+	case errors.Is(err, ErrUnavailable),
+		errors.Is(err, redis.ErrClosed),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.EPIPE):
+		return ErrUnavailable
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrTimeout
+		}
+		return ErrUnavailable
+	}
+
+	msg := err.Error()
+
+	switch {
+	case strings.HasPrefix(msg, "WRONGTYPE "):
+		return ErrWrongType
+	case strings.HasPrefix(msg, "NOGROUP "):
+		return ErrNoGroup
+	case msg == "redis: connection pool timeout":
+		return ErrTimeout
+	case strings.HasPrefix(msg, "LOADING "),
+		strings.HasPrefix(msg, "MASTERDOWN "),
+		strings.HasPrefix(msg, "CLUSTERDOWN "),
+		strings.HasPrefix(msg, "TRYAGAIN "):
+		return ErrUnavailable
+	}
+
+	return nil
+}
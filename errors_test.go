@@ -0,0 +1,87 @@
+package gokvstores_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+
+	redis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ulule/gokvstores"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestOpError(t *testing.T) {
+	is := assert.New(t)
+
+	err := &gokvstores.OpError{
+		Op:      gokvstores.OpGet,
+		Key:     "key",
+		Backend: gokvstores.BackendRedis,
+		Err:     io.EOF,
+	}
+
+	is.Equal(`gokvstores: redis Get "key": EOF`, err.Error())
+	is.True(errors.Is(err, io.EOF))
+	is.Equal(io.EOF, errors.Unwrap(err))
+
+	err.Key = ""
+	is.Equal(`gokvstores: redis Get: EOF`, err.Error())
+
+	wrapped := fmt.Errorf("loading user: %w", err)
+
+	var opErr *gokvstores.OpError
+	is.True(errors.As(wrapped, &opErr))
+	is.Equal(gokvstores.OpGet, opErr.Op)
+	is.True(errors.Is(wrapped, gokvstores.ErrUnavailable))
+}
+
+func TestOpError_Is(t *testing.T) {
+	is := assert.New(t)
+
+	sentinels := []error{
+		gokvstores.ErrNotFound,
+		gokvstores.ErrWrongType,
+		gokvstores.ErrTimeout,
+		gokvstores.ErrUnavailable,
+		gokvstores.ErrNoGroup,
+	}
+
+	tests := []struct {
+		err      error
+		expected error
+	}{
+		{redis.Nil, gokvstores.ErrNotFound},
+		{gokvstores.ErrNotFound, gokvstores.ErrNotFound},
+		{errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"), gokvstores.ErrWrongType},
+		{context.DeadlineExceeded, gokvstores.ErrTimeout},
+		{timeoutError{}, gokvstores.ErrTimeout},
+		{errors.New("redis: connection pool timeout"), gokvstores.ErrTimeout},
+		{io.EOF, gokvstores.ErrUnavailable},
+		{redis.ErrClosed, gokvstores.ErrUnavailable},
+		{syscall.ECONNREFUSED, gokvstores.ErrUnavailable},
+		{errors.New("LOADING Redis is loading the dataset in memory"), gokvstores.ErrUnavailable},
+		{errors.New("CLUSTERDOWN The cluster is down"), gokvstores.ErrUnavailable},
+		{errors.New("NOGROUP No such key 'events' or consumer group 'workers'"), gokvstores.ErrNoGroup},
+		{gokvstores.ErrNoGroup, gokvstores.ErrNoGroup},
+		{errors.New("ERR syntax error"), nil},
+		{context.Canceled, nil},
+	}
+
+	for _, tt := range tests {
+		err := &gokvstores.OpError{Op: gokvstores.OpGet, Backend: gokvstores.BackendRedis, Err: tt.err}
+
+		for _, sentinel := range sentinels {
+			is.Equal(sentinel == tt.expected, errors.Is(err, sentinel), "%v is %v", tt.err, sentinel)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package gokvstores
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/ulule/gokvstores/internal/geohash"
+)
+
+// GeoUnit is the unit of a distance.
+type GeoUnit string
+
+// Distance units.
+const (
+	GeoMeters     GeoUnit = "m"
+	GeoKilometers GeoUnit = "km"
+	GeoMiles      GeoUnit = "mi"
+	GeoFeet       GeoUnit = "ft"
+)
+
+// GeoPosition is a position in degrees.
+type GeoPosition struct {
+	Longitude float64
+	Latitude  float64
+}
+
+// GeoLocation is a member of a geospatial index with its position.
+type GeoLocation struct {
+	Member    string
+	Longitude float64
+	Latitude  float64
+
+	// Dist is the distance from the center of a search, in the search unit.
+	// It is only set by GeoSearch.
+	Dist float64
+}
+
+// GeoSearchQuery is a search of the members of a geospatial index around a center,
+// which is either Member or the position given by Longitude and Latitude.
+type GeoSearchQuery struct {
+	// Member is the member at the center of the search, if not empty.
+	Member string
+
+	// Longitude and Latitude are the center of the search if Member is empty.
+	Longitude float64
+	Latitude  float64
+
+	// Radius is the radius of the searched circle, if it is not 0.
+	Radius float64
+
+	// Width and Height are the size of the searched box if Radius is 0.
+	Width  float64
+	Height float64
+
+	// Unit is the unit of Radius, Width, Height and of the returned distances,
+	// GeoMeters if empty.
+	Unit GeoUnit
+
+	// Descending sorts the results by descending instead of ascending distance.
+	Descending bool
+
+	// Count is the maximum number of returned members, the closest ones first
+	// (or the farthest ones if Descending is set), or all of them if it is 0.
+	Count int
+}
+
+// GeoStore is implemented by stores supporting geospatial indexes, e.g. to find the stores
+// close to a user. Indexes are sorted sets of members scored by the geohash of their position,
+// which is stored with a precision of about 0.6 meter, as in Redis.
+type GeoStore interface {
+	// GeoAdd adds the given members to the geospatial index stored at key, or updates their
+	// position, and returns the number of added members. Latitudes must be between -85.05112878
+	// and 85.05112878 degrees.
+	GeoAdd(ctx context.Context, key string, locations ...GeoLocation) (int, error)
+
+	// GeoPos returns the positions of the given members, nil for missing members.
+	GeoPos(ctx context.Context, key string, members ...string) ([]*GeoPosition, error)
+
+	// GeoDist returns the distance between two members in the given unit, GeoMeters if empty.
+	// It reports false if a member is missing.
+	GeoDist(ctx context.Context, key string, member1 string, member2 string, unit GeoUnit) (float64, bool, error)
+
+	// GeoSearch returns the members of the geospatial index stored at key within the area
+	// of query, with their position and distance, sorted by distance. It returns ErrNotFound
+	// if the center of the query is a missing member of an existing index.
+	GeoSearch(ctx context.Context, key string, query GeoSearchQuery) ([]GeoLocation, error)
+}
+
+// Names of the GeoStore operations.
+const (
+	OpGeoAdd    = "GeoAdd"
+	OpGeoPos    = "GeoPos"
+	OpGeoDist   = "GeoDist"
+	OpGeoSearch = "GeoSearch"
+)
+
+// meters returns the number of meters of the unit.
+func (u GeoUnit) meters() (float64, error) {
+	switch u {
+	case GeoMeters, "":
+		return 1, nil
+	case GeoKilometers:
+		return 1000, nil
+	case GeoMiles:
+		return 1609.34, nil
+	case GeoFeet:
+		return 0.3048, nil
+	default:
+		return 0, fmt.Errorf("gokvstores: unsupported distance unit %q", string(u))
+	}
+}
+
+// checkGeoLocations returns an error if a position cannot be indexed.
+func checkGeoLocations(locations []GeoLocation) error {
+	for _, l := range locations {
+		if !geohash.Valid(l.Longitude, l.Latitude) {
+			return fmt.Errorf("gokvstores: invalid longitude,latitude pair %v,%v", l.Longitude, l.Latitude)
+		}
+	}
+	return nil
+}
+
+// checkGeoSearchQuery returns an error if query is not valid.
+func checkGeoSearchQuery(query GeoSearchQuery) error {
+	if _, err := query.Unit.meters(); err != nil {
+		return err
+	}
+
+	if query.Radius < 0 || query.Width < 0 || query.Height < 0 || (query.Radius == 0 && (query.Width == 0 || query.Height == 0)) {
+		return fmt.Errorf("gokvstores: invalid search area")
+	}
+
+	if query.Member == "" && !geohash.Valid(query.Longitude, query.Latitude) {
+		return fmt.Errorf("gokvstores: invalid longitude,latitude pair %v,%v", query.Longitude, query.Latitude)
+	}
+
+	if query.Count < 0 {
+		return fmt.Errorf("gokvstores: invalid count %d", query.Count)
+	}
+
+	return nil
+}
+
+// geoDistance rounds a distance to 4 decimals, the precision of Redis replies.
+func geoDistance(distance float64) float64 {
+	f, _ := strconv.ParseFloat(strconv.FormatFloat(distance, 'f', 4, 64), 64)
+	return f
+}
+
+// geoCoordinate rounds a coordinate to 17 decimals, the precision of Redis replies.
+func geoCoordinate(degrees float64) float64 {
+	f, _ := strconv.ParseFloat(strconv.FormatFloat(degrees, 'f', 17, 64), 64)
+	return f
+}
@@ -0,0 +1,128 @@
+package gokvstores
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HealthStatus is the result of a store health check.
+type HealthStatus struct {
+	// Healthy reports whether the store can serve requests.
+	Healthy bool
+
+	// Backend is the name of the store backend (see BackendName).
+	Backend string
+
+	// Latency is the duration of the round trip to the store, if any.
+	Latency time.Duration
+
+	// Err is the error which made the store unhealthy, if any.
+	Err error
+
+	// Details holds backend specific information, e.g. the Redis replication role.
+	Details map[string]interface{}
+}
+
+// HealthChecker is implemented by stores which can report their health.
+type HealthChecker interface {
+	// Health checks the store and returns its status.
+	Health(ctx context.Context) HealthStatus
+}
+
+// Health checks store, which should implement HealthChecker, and returns its status.
+// Wrapper stores use it to forward Health to the store they wrap.
+func Health(ctx context.Context, store KVStore) HealthStatus {
+	checker, ok := store.(HealthChecker)
+	if !ok {
+		return HealthStatus{
+			Backend: BackendName(store),
+			Err: &OpError{
+				Op:      opHealth,
+				Backend: BackendName(store),
+				Err:     fmt.Errorf("gokvstores: %T does not report its health", store),
+			},
+		}
+	}
+
+	return checker.Health(ctx)
+}
+
+// healthResponse is the JSON representation of a HealthStatus.
+type healthResponse struct {
+	Healthy bool                   `json:"healthy"`
+	Backend string                 `json:"backend"`
+	Latency string                 `json:"latency,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// HealthHandler returns an http.Handler checking the given stores concurrently, to be
+// mounted on readiness probes. It responds with 200 OK if all the stores are healthy,
+// 503 Service Unavailable otherwise, and a JSON body with the status of every store:
+//
+//	{"healthy": true, "stores": {"cache": {"healthy": true, "backend": "redis", "latency": "312µs"}}}
+//
+// Checks use the request context, so that probe timeouts apply to them.
+func HealthHandler(checkers map[string]HealthChecker) http.Handler {
+	names := make([]string, 0, len(checkers))
+	for name := range checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		statuses := make([]HealthStatus, len(names))
+
+		var wg sync.WaitGroup
+		for i, name := range names {
+			wg.Add(1)
+			go func(i int, checker HealthChecker) {
+				defer wg.Done()
+				statuses[i] = checker.Health(r.Context())
+			}(i, checkers[name])
+		}
+		wg.Wait()
+
+		healthy := true
+		stores := make(map[string]healthResponse, len(names))
+
+		for i, name := range names {
+			status := statuses[i]
+
+			response := healthResponse{
+				Healthy: status.Healthy,
+				Backend: status.Backend,
+				Details: status.Details,
+			}
+
+			if status.Latency > 0 {
+				response.Latency = status.Latency.String()
+			}
+
+			if status.Err != nil {
+				response.Error = status.Err.Error()
+			}
+
+			healthy = healthy && status.Healthy
+			stores[name] = response
+		}
+
+		code := http.StatusOK
+		if !healthy {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+
+		json.NewEncoder(w).Encode(struct {
+			Healthy bool                      `json:"healthy"`
+			Stores  map[string]healthResponse `json:"stores"`
+		}{healthy, stores})
+	})
+}
@@ -0,0 +1,180 @@
+package gokvstores_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	redis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+	"github.com/ulule/gokvstores/redistest"
+)
+
+func TestRedisStore_Health(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, err := redistest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	store := newRedisClientStore(t, &gokvstores.RedisClientOptions{
+		Addr:       server.Addr(),
+		PoolSize:   4,
+		MaxRetries: -1,
+	})
+	defer store.Close()
+
+	status := store.(gokvstores.HealthChecker).Health(ctx)
+	is.True(status.Healthy)
+	is.NoError(status.Err)
+	is.Equal(gokvstores.BackendRedis, status.Backend)
+	is.True(status.Latency > 0)
+	is.Equal("master", status.Details["role"])
+	is.Equal(4, status.Details["pool_size"])
+	is.Contains(status.Details, "pool_saturation")
+	is.Contains(status.Details, "pool_total_conns")
+
+	server.FailNext("ping", 1, "LOADING Redis is loading the dataset in memory")
+
+	status = store.(gokvstores.HealthChecker).Health(ctx)
+	is.False(status.Healthy)
+	is.True(errors.Is(status.Err, gokvstores.ErrUnavailable))
+}
+
+func TestRedisStore_ClusterHealth(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, err := redistest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	store := gokvstores.NewRedisStoreFromClient(redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs:      []string{server.Addr()},
+		PoolSize:   4,
+		MaxRetries: -1,
+	}), time.Minute)
+	defer store.Close()
+
+	// The pool size of clusters is the sum of the pools of their nodes.
+	status := store.Health(ctx)
+	is.True(status.Healthy)
+	is.NoError(status.Err)
+	is.Equal("ok", status.Details["cluster_state"])
+	is.Equal(1, status.Details["cluster_known_nodes"])
+	is.Equal(4, status.Details["pool_size"])
+	is.Contains(status.Details, "pool_saturation")
+}
+
+func TestMemoryStore_Health(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store, err := gokvstores.NewMemoryStore(time.Minute, time.Minute)
+	is.NoError(err)
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	status := store.(gokvstores.HealthChecker).Health(ctx)
+	is.True(status.Healthy)
+	is.Equal(gokvstores.BackendMemory, status.Backend)
+	is.Equal(1, status.Details["items"])
+
+	status = gokvstores.DummyStore{}.Health(ctx)
+	is.True(status.Healthy)
+	is.Equal(gokvstores.BackendDummy, status.Backend)
+}
+
+func TestHealth(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store, err := gokvstores.NewMemoryStore(time.Minute, time.Minute)
+	is.NoError(err)
+
+	// Wrapper stores forward health checks to the store they wrap.
+	for _, wrapped := range []gokvstores.KVStore{
+		gokvstores.NewRetryStore(store, gokvstores.RetryPolicy{}),
+		gokvstores.NewCircuitBreakerStore(store, gokvstores.CircuitBreakerOptions{}),
+		gokvstores.NewLoggingStore(store, gokvstores.LoggingOptions{}),
+		gokvstores.NewChaosStore(store, gokvstores.ChaosOptions{}),
+	} {
+		status := wrapped.(gokvstores.HealthChecker).Health(ctx)
+		is.True(status.Healthy, "%T", wrapped)
+		is.Equal(gokvstores.BackendMemory, status.Backend, "%T", wrapped)
+	}
+
+	// Stores which do not report their health are unhealthy.
+	status := gokvstores.NewRetryStore(gokvstores.NewRecordingStore(store), gokvstores.RetryPolicy{}).Health(ctx)
+	is.False(status.Healthy)
+
+	var opErr *gokvstores.OpError
+	is.True(errors.As(status.Err, &opErr))
+	is.Equal("Health", opErr.Op)
+}
+
+type unhealthyStore struct{}
+
+func (unhealthyStore) Health(ctx context.Context) gokvstores.HealthStatus {
+	return gokvstores.HealthStatus{
+		Backend: "test",
+		Latency: time.Millisecond,
+		Err:     gokvstores.ErrUnavailable,
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	is := assert.New(t)
+
+	memory, err := gokvstores.NewMemoryStore(time.Minute, time.Minute)
+	is.NoError(err)
+
+	checkers := map[string]gokvstores.HealthChecker{
+		"memory": memory.(gokvstores.HealthChecker),
+		"dummy":  gokvstores.DummyStore{},
+	}
+
+	type response struct {
+		Healthy bool `json:"healthy"`
+		Stores  map[string]struct {
+			Healthy bool   `json:"healthy"`
+			Backend string `json:"backend"`
+			Latency string `json:"latency"`
+			Error   string `json:"error"`
+		} `json:"stores"`
+	}
+
+	recorder := httptest.NewRecorder()
+	gokvstores.HealthHandler(checkers).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+	is.Equal(http.StatusOK, recorder.Code)
+	is.Equal("application/json", recorder.Header().Get("Content-Type"))
+
+	var body response
+	is.NoError(json.Unmarshal(recorder.Body.Bytes(), &body))
+	is.True(body.Healthy)
+	is.Len(body.Stores, 2)
+	is.Equal(gokvstores.BackendMemory, body.Stores["memory"].Backend)
+
+	checkers["broken"] = unhealthyStore{}
+
+	recorder = httptest.NewRecorder()
+	gokvstores.HealthHandler(checkers).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+	is.Equal(http.StatusServiceUnavailable, recorder.Code)
+
+	body = response{}
+	is.NoError(json.Unmarshal(recorder.Body.Bytes(), &body))
+	is.False(body.Healthy)
+	is.True(body.Stores["memory"].Healthy)
+	is.False(body.Stores["broken"].Healthy)
+	is.Equal("1ms", body.Stores["broken"].Latency)
+	is.Equal(gokvstores.ErrUnavailable.Error(), body.Stores["broken"].Error)
+}
@@ -0,0 +1,135 @@
+// Package geohash implements the geohashes of Redis geospatial indexes: positions are stored
+// as sorted set scores holding 52-bit interleaved geohashes, so that they are decoded with the
+// same precision, and distances are computed with the same haversine formula.
+package geohash
+
+import "math"
+
+// Limits of the positions which can be encoded, as in EPSG:3857.
+const (
+	MinLongitude = -180
+	MaxLongitude = 180
+	MinLatitude  = -85.05112878
+	MaxLatitude  = 85.05112878
+)
+
+const (
+	// step is the number of bits of each coordinate in a geohash.
+	step = 26
+
+	// earthRadius is the Earth radius in meters used by Redis.
+	earthRadius = 6372797.560856
+)
+
+// Valid reports whether the given position can be encoded.
+func Valid(longitude float64, latitude float64) bool {
+	return longitude >= MinLongitude && longitude <= MaxLongitude &&
+		latitude >= MinLatitude && latitude <= MaxLatitude
+}
+
+// Encode returns the geohash of the given position, which must be valid.
+func Encode(longitude float64, latitude float64) uint64 {
+	latOffset := (latitude - MinLatitude) / (MaxLatitude - MinLatitude)
+	lonOffset := (longitude - MinLongitude) / (MaxLongitude - MinLongitude)
+
+	latOffset *= 1 << step
+	lonOffset *= 1 << step
+
+	return interleave(uint32(latOffset), uint32(lonOffset))
+}
+
+// Decode returns the position at the center of the area of the given geohash.
+func Decode(hash uint64) (float64, float64) {
+	lat, lon := deinterleave(hash)
+
+	latScale := float64(MaxLatitude - MinLatitude)
+	lonScale := float64(MaxLongitude - MinLongitude)
+
+	// Products are converted explicitly so that they are not fused into multiply-add
+	// instructions, which would round differently from Redis.
+	latMin := MinLatitude + float64(float64(lat)/(1<<step)*latScale)
+	latMax := MinLatitude + float64(float64(lat+1)/(1<<step)*latScale)
+	lonMin := MinLongitude + float64(float64(lon)/(1<<step)*lonScale)
+	lonMax := MinLongitude + float64(float64(lon+1)/(1<<step)*lonScale)
+
+	longitude := math.Min(math.Max((lonMin+lonMax)/2, MinLongitude), MaxLongitude)
+	latitude := math.Min(math.Max((latMin+latMax)/2, MinLatitude), MaxLatitude)
+
+	return longitude, latitude
+}
+
+// Distance returns the distance in meters between the given positions.
+func Distance(lon1 float64, lat1 float64, lon2 float64, lat2 float64) float64 {
+	v := math.Sin((radians(lon2) - radians(lon1)) / 2)
+	if v == 0 {
+		return latDistance(lat1, lat2)
+	}
+
+	lat1r, lat2r := radians(lat1), radians(lat2)
+	u := math.Sin((lat2r - lat1r) / 2)
+	a := float64(u*u) + float64(float64(float64(math.Cos(lat1r)*math.Cos(lat2r))*v)*v)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// InRadius returns the distance in meters from the center to the given position
+// and reports whether it is at most radius meters.
+func InRadius(centerLon float64, centerLat float64, radius float64, longitude float64, latitude float64) (float64, bool) {
+	distance := Distance(centerLon, centerLat, longitude, latitude)
+	return distance, distance <= radius
+}
+
+// InBox returns the distance in meters from the center to the given position and reports
+// whether it is within the box of the given width and height in meters around the center.
+func InBox(centerLon float64, centerLat float64, width float64, height float64, longitude float64, latitude float64) (float64, bool) {
+	if latDistance(latitude, centerLat) > height/2 {
+		return 0, false
+	}
+
+	if Distance(longitude, latitude, centerLon, latitude) > width/2 {
+		return 0, false
+	}
+
+	return Distance(centerLon, centerLat, longitude, latitude), true
+}
+
+// latDistance returns the distance in meters between the given latitudes.
+func latDistance(lat1 float64, lat2 float64) float64 {
+	return earthRadius * math.Abs(radians(lat2)-radians(lat1))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * (math.Pi / 180)
+}
+
+// interleave interleaves the bits of x and y, x taking the even bits.
+func interleave(x uint32, y uint32) uint64 {
+	return spread(x) | spread(y)<<1
+}
+
+// deinterleave returns the even and the odd bits of v.
+func deinterleave(v uint64) (uint32, uint32) {
+	return squash(v), squash(v >> 1)
+}
+
+// spread moves the bits of v to the even bits.
+func spread(v uint32) uint64 {
+	x := uint64(v)
+	x = (x | x<<16) & 0x0000FFFF0000FFFF
+	x = (x | x<<8) & 0x00FF00FF00FF00FF
+	x = (x | x<<4) & 0x0F0F0F0F0F0F0F0F
+	x = (x | x<<2) & 0x3333333333333333
+	x = (x | x<<1) & 0x5555555555555555
+	return x
+}
+
+// squash is the inverse of spread, ignoring the odd bits.
+func squash(v uint64) uint32 {
+	x := v & 0x5555555555555555
+	x = (x | x>>1) & 0x3333333333333333
+	x = (x | x>>2) & 0x0F0F0F0F0F0F0F0F
+	x = (x | x>>4) & 0x00FF00FF00FF00FF
+	x = (x | x>>8) & 0x0000FFFF0000FFFF
+	x = (x | x>>16) & 0x00000000FFFFFFFF
+	return uint32(x)
+}
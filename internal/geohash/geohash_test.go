@@ -0,0 +1,46 @@
+package geohash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	is := assert.New(t)
+
+	// Scores of the Redis documentation examples.
+	is.Equal(uint64(3479099956230698), Encode(13.361389, 38.115556))
+	is.Equal(uint64(3479447370796909), Encode(15.087269, 37.502669))
+
+	for _, p := range [][2]float64{{0, 0}, {-180, MinLatitude}, {179.99, 85}, {2.35, 48.85}} {
+		lon, lat := Decode(Encode(p[0], p[1]))
+		is.InDelta(p[0], lon, 0.00001)
+		is.InDelta(p[1], lat, 0.00001)
+	}
+}
+
+func TestValid(t *testing.T) {
+	is := assert.New(t)
+
+	is.True(Valid(180, MaxLatitude))
+	is.False(Valid(180.1, 0))
+	is.False(Valid(0, -85.1))
+}
+
+func TestDistance(t *testing.T) {
+	is := assert.New(t)
+
+	is.Equal(0.0, Distance(1, 2, 1, 2))
+	is.InDelta(166274.1516, Distance(13.361389, 38.115556, 15.087269, 37.502669), 1)
+
+	distance, ok := InRadius(0, 0, 111000, 1, 0)
+	is.False(ok)
+	is.InDelta(111226, distance, 1)
+
+	_, ok = InBox(0, 0, 300000, 100000, 1, 0.4)
+	is.True(ok)
+
+	_, ok = InBox(0, 0, 300000, 100000, 1, 0.5)
+	is.False(ok)
+}
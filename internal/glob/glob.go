@@ -0,0 +1,116 @@
+// Package glob implements Redis glob-style pattern matching.
+package glob
+
+// Match reports whether s matches the given Redis glob-style pattern.
+//
+// Supported patterns are the ones accepted by the KEYS command:
+//
+//	h?llo matches hello, hallo and hxllo
+//	h*llo matches hllo and heeeello
+//	h[ae]llo matches hello and hallo, but not hillo
+//	h[^e]llo matches hallo, hbllo, ... but not hello
+//	h[a-b]llo matches hallo and hbllo
+//
+// Use \ to escape special characters. Matching is done byte by byte, as Redis does.
+func Match(pattern string, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+
+			if len(pattern) == 1 {
+				return true
+			}
+
+			for i := 0; i <= len(s); i++ {
+				if Match(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+
+			s = s[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+
+			var matched bool
+			matched, pattern = matchClass(pattern[1:], s[0])
+			if !matched {
+				return false
+			}
+
+			s = s[1:]
+		default:
+			if pattern[0] == '\\' && len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+
+			s = s[1:]
+			pattern = pattern[1:]
+		}
+	}
+
+	return len(s) == 0
+}
+
+// matchClass matches c against the character class at the beginning of pattern,
+// the opening bracket being already consumed. It returns the rest of the pattern.
+func matchClass(pattern string, c byte) (bool, string) {
+	not := len(pattern) > 0 && pattern[0] == '^'
+	if not {
+		pattern = pattern[1:]
+	}
+
+	matched := false
+
+	for len(pattern) > 0 {
+		switch {
+		case pattern[0] == '\\' && len(pattern) >= 2:
+			if pattern[1] == c {
+				matched = true
+			}
+			pattern = pattern[2:]
+		case pattern[0] == ']':
+			pattern = pattern[1:]
+			if not {
+				return !matched, pattern
+			}
+			return matched, pattern
+		case len(pattern) >= 3 && pattern[1] == '-':
+			start, end := pattern[0], pattern[2]
+			if start > end {
+				start, end = end, start
+			}
+			if c >= start && c <= end {
+				matched = true
+			}
+			pattern = pattern[3:]
+		default:
+			if pattern[0] == c {
+				matched = true
+			}
+			pattern = pattern[1:]
+		}
+	}
+
+	// Unterminated class: Redis treats the end of the pattern as the closing bracket.
+	if not {
+		return !matched, pattern
+	}
+
+	return matched, pattern
+}
@@ -0,0 +1,50 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	is := assert.New(t)
+
+	cases := []struct {
+		pattern string
+		value   string
+		match   bool
+	}{
+		{"*", "", true},
+		{"*", "anything", true},
+		{"order*", "order1", true},
+		{"order*", "order", true},
+		{"order*", "orde", false},
+		{"*:user:*", "app:user:42", true},
+		{"*:user:*", "app:users", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h*llo", "hllo", true},
+		{"h*llo", "heeeello", true},
+		{"h[ae]llo", "hello", true},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{"h[b-a]llo", "hallo", true},
+		{"h[a-b]llo", "hcllo", false},
+		{"h\\*llo", "h*llo", true},
+		{"h\\*llo", "hello", false},
+		{"h[\\]]llo", "h]llo", true},
+		{"a/*", "a/b/c", true},
+		{"clé:*", "clé:🔑", true},
+		{"key", "key", true},
+		{"key", "key1", false},
+		{"", "", true},
+		{"", "a", false},
+	}
+
+	for _, c := range cases {
+		is.Equal(c.match, Match(c.pattern, c.value), "pattern %q, value %q", c.pattern, c.value)
+	}
+}
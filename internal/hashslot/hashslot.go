@@ -0,0 +1,45 @@
+// Package hashslot computes the Redis Cluster hash slots of keys.
+package hashslot
+
+import "strings"
+
+// Count is the number of hash slots of a Redis cluster.
+const Count = 16384
+
+// Slot returns the hash slot of key. If key contains a non-empty hash tag, the part
+// between the first "{" and the next "}", only the hash tag is hashed, so that
+// keys sharing a hash tag are in the same slot.
+func Slot(key string) int {
+	return int(crc16(Tag(key)) % Count)
+}
+
+// Tag returns the hashed part of key: its hash tag if it has one, or else key.
+func Tag(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		return key
+	}
+
+	return key[start+1 : start+1+end]
+}
+
+// crc16 returns the CRC16-CCITT (XMODEM) checksum of s, as computed by Redis.
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
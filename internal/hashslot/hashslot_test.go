@@ -0,0 +1,39 @@
+package hashslot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlot(t *testing.T) {
+	is := assert.New(t)
+
+	is.Equal(uint16(0x31c3), crc16("123456789"))
+
+	// Slots given by CLUSTER KEYSLOT.
+	is.Equal(12182, Slot("foo"))
+	is.Equal(5061, Slot("bar"))
+	is.Equal(11058, Slot("somekey"))
+	is.Equal(0, Slot(""))
+
+	is.Equal(Slot("user1000"), Slot("{user1000}.following"))
+	is.Equal(Slot("{user1000}.following"), Slot("{user1000}.followers"))
+}
+
+func TestTag(t *testing.T) {
+	is := assert.New(t)
+
+	for key, tag := range map[string]string{
+		"foo":           "foo",
+		"{user}.name":   "user",
+		"a{b}{c}":       "b",
+		"{}.name":       "{}.name",
+		"{.name":        "{.name",
+		"}{user}":       "user",
+		"foo{}{bar}":    "foo{}{bar}",
+		"foo{{bar}}zap": "{bar",
+	} {
+		is.Equal(tag, Tag(key), key)
+	}
+}
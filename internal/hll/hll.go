@@ -0,0 +1,133 @@
+// Package hll implements HyperLogLog sketches which give the same estimates as Redis
+// HyperLogLogs: 16384 6-bit registers, MurmurHash64A hashes and the improved estimator
+// of Otmar Ertl used since Redis 5.
+package hll
+
+import (
+	"math"
+
+	"github.com/ulule/gokvstores/internal/murmur"
+)
+
+const (
+	precision = 14
+	registers = 1 << precision
+
+	// q is the number of hash bits used to count leading zeros.
+	q = 64 - precision
+
+	seed = 0xadc83b19
+
+	alphaInf = 0.721347520444481703680
+)
+
+// Sketch is a HyperLogLog sketch. It is not safe for concurrent use.
+type Sketch struct {
+	registers [registers]uint8
+}
+
+// New returns an empty sketch.
+func New() *Sketch {
+	return &Sketch{}
+}
+
+// Add adds element to the sketch and reports whether a register was updated,
+// i.e. whether the estimate may have changed.
+func (s *Sketch) Add(element string) bool {
+	h := murmur.Hash64A([]byte(element), seed)
+
+	index := h & (registers - 1)
+
+	// The count of the trailing zeros of the remaining bits plus one, at most q+1.
+	h >>= precision
+	h |= 1 << q
+
+	count := uint8(1)
+	for bit := uint64(1); h&bit == 0; bit <<= 1 {
+		count++
+	}
+
+	if count <= s.registers[index] {
+		return false
+	}
+
+	s.registers[index] = count
+
+	return true
+}
+
+// Merge merges other into the sketch, so that it estimates the cardinality of their union.
+func (s *Sketch) Merge(other *Sketch) {
+	for i, v := range other.registers {
+		if v > s.registers[i] {
+			s.registers[i] = v
+		}
+	}
+}
+
+// Clone returns a copy of the sketch.
+func (s *Sketch) Clone() *Sketch {
+	c := *s
+	return &c
+}
+
+// Count returns the estimated number of distinct elements added to the sketch.
+func (s *Sketch) Count() int64 {
+	var histogram [q + 2]int
+	for _, v := range s.registers {
+		histogram[v]++
+	}
+
+	m := float64(registers)
+
+	z := m * tau((m-float64(histogram[q+1]))/m)
+	for j := q; j >= 1; j-- {
+		z += float64(histogram[j])
+		z *= 0.5
+	}
+	z += m * sigma(float64(histogram[0])/m)
+
+	return int64(math.Round(alphaInf * m * m / z))
+}
+
+// sigma is the sigma function of the estimator, for the ratio of empty registers.
+func sigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+
+	y := 1.0
+	z := x
+
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+
+		if prev == z {
+			return z
+		}
+	}
+}
+
+// tau is the tau function of the estimator, for the ratio of registers which did not overflow.
+func tau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+
+	y := 1.0
+	z := 1 - x
+
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= math.Pow(1-x, 2) * y
+
+		if prev == z {
+			return z / 3
+		}
+	}
+}
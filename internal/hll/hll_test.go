@@ -0,0 +1,68 @@
+package hll
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSketch(t *testing.T) {
+	is := assert.New(t)
+
+	s := New()
+	is.Equal(int64(0), s.Count())
+
+	is.True(s.Add("a"))
+	is.False(s.Add("a"))
+	is.True(s.Add("b"))
+	is.Equal(int64(2), s.Count())
+
+	for _, n := range []int{100, 1000, 10000, 100000, 1000000} {
+		s := New()
+		for i := 0; i < n; i++ {
+			s.Add(strconv.Itoa(i))
+		}
+
+		is.InEpsilon(n, s.Count(), 0.03, "%d elements", n)
+	}
+}
+
+func TestSketch_Merge(t *testing.T) {
+	is := assert.New(t)
+
+	a, b, union := New(), New(), New()
+	for i := 0; i < 5000; i++ {
+		a.Add("a" + strconv.Itoa(i))
+		b.Add("b" + strconv.Itoa(i))
+		union.Add("a" + strconv.Itoa(i))
+		union.Add("b" + strconv.Itoa(i))
+	}
+
+	merged := a.Clone()
+	merged.Merge(b)
+
+	is.Equal(union.Count(), merged.Count())
+	is.NotEqual(a.Count(), merged.Count())
+}
+
+func TestSketch_RedisExamples(t *testing.T) {
+	is := assert.New(t)
+
+	// Examples of the PFCOUNT and PFMERGE documentation of Redis.
+	s := New()
+	for _, e := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		s.Add(e)
+	}
+	is.Equal(int64(7), s.Count())
+
+	a, b := New(), New()
+	for _, e := range []string{"foo", "bar", "zap", "a"} {
+		a.Add(e)
+	}
+	for _, e := range []string{"a", "b", "c", "foo"} {
+		b.Add(e)
+	}
+	a.Merge(b)
+	is.Equal(int64(6), a.Count())
+}
@@ -0,0 +1,40 @@
+// Package murmur implements MurmurHash64A, the hash function used by Redis HyperLogLogs.
+package murmur
+
+import "encoding/binary"
+
+// Hash64A returns the MurmurHash64A hash of data with the given seed, reading data
+// as little-endian 64-bit blocks as Redis does on every platform.
+func Hash64A(data []byte, seed uint64) uint64 {
+	const (
+		m = 0xc6a4a7935bd1e995
+		r = 47
+	)
+
+	h := seed ^ (uint64(len(data)) * m)
+
+	for len(data) >= 8 {
+		k := binary.LittleEndian.Uint64(data)
+		k *= m
+		k ^= k >> r
+		k *= m
+
+		h ^= k
+		h *= m
+
+		data = data[8:]
+	}
+
+	if len(data) > 0 {
+		for i := len(data) - 1; i >= 0; i-- {
+			h ^= uint64(data[i]) << (8 * uint(i))
+		}
+		h *= m
+	}
+
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+
+	return h
+}
@@ -0,0 +1,40 @@
+package murmur
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHash64A(t *testing.T) {
+	is := assert.New(t)
+
+	is.Equal(uint64(0), Hash64A(nil, 0))
+
+	// Every tail length gives a distinct hash, which depends on the seed.
+	seen := map[uint64]bool{}
+	data := []byte("abcdefghijklmnopq")
+	for i := range data {
+		h := Hash64A(data[:i], 0xadc83b19)
+		is.False(seen[h], "length %d", i)
+		seen[h] = true
+
+		is.NotEqual(h, Hash64A(data[:i], 1))
+	}
+}
+
+// TestHash64A_Verification checks the verification value of SMHasher, which hashes the
+// prefixes of 0, 1, ..., 255 with seeds 256 down to 1, and then the concatenated hashes.
+func TestHash64A_Verification(t *testing.T) {
+	is := assert.New(t)
+
+	key := make([]byte, 256)
+	hashes := make([]byte, 256*8)
+	for i := range key {
+		key[i] = byte(i)
+		binary.LittleEndian.PutUint64(hashes[i*8:], Hash64A(key[:i], uint64(256-i)))
+	}
+
+	is.Equal(uint32(0x1f0d3804), uint32(Hash64A(hashes, 0)))
+}
@@ -0,0 +1,207 @@
+// Package skiplist implements a skip list of members ordered by score, then by member,
+// with O(log n) insertion, deletion and rank lookups, as used by Redis sorted sets.
+package skiplist
+
+import "math/rand"
+
+const (
+	maxLevel    = 32
+	probability = 0.25
+)
+
+// Element is a member of a List.
+type Element struct {
+	Member string
+	Score  float64
+
+	levels []level
+}
+
+// level is a forward link of an element, with the number of elements it spans.
+type level struct {
+	next *Element
+	span int
+}
+
+// Next returns the next element of the list or nil.
+func (e *Element) Next() *Element {
+	return e.levels[0].next
+}
+
+// List is a skip list. It is not safe for concurrent use.
+type List struct {
+	head   *Element
+	level  int
+	length int
+}
+
+// New returns an empty list.
+func New() *List {
+	return &List{
+		head:  &Element{levels: make([]level, maxLevel)},
+		level: 1,
+	}
+}
+
+// Len returns the number of elements of the list.
+func (l *List) Len() int {
+	return l.length
+}
+
+// Insert inserts the given member. The member must not already be in the list.
+func (l *List) Insert(score float64, member string) *Element {
+	var (
+		update [maxLevel]*Element
+		rank   [maxLevel]int
+	)
+
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		if i < l.level-1 {
+			rank[i] = rank[i+1]
+		}
+
+		for x.levels[i].next != nil && x.levels[i].next.less(score, member) {
+			rank[i] += x.levels[i].span
+			x = x.levels[i].next
+		}
+
+		update[i] = x
+	}
+
+	lvl := randomLevel()
+	if lvl > l.level {
+		for i := l.level; i < lvl; i++ {
+			rank[i] = 0
+			update[i] = l.head
+			update[i].levels[i].span = l.length
+		}
+		l.level = lvl
+	}
+
+	x = &Element{Member: member, Score: score, levels: make([]level, lvl)}
+
+	for i := 0; i < lvl; i++ {
+		x.levels[i].next = update[i].levels[i].next
+		update[i].levels[i].next = x
+
+		x.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = rank[0] - rank[i] + 1
+	}
+
+	for i := lvl; i < l.level; i++ {
+		update[i].levels[i].span++
+	}
+
+	l.length++
+
+	return x
+}
+
+// Delete removes the given member and reports whether it was in the list.
+func (l *List) Delete(score float64, member string) bool {
+	var update [maxLevel]*Element
+
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.levels[i].next != nil && x.levels[i].next.less(score, member) {
+			x = x.levels[i].next
+		}
+		update[i] = x
+	}
+
+	x = x.levels[0].next
+	if x == nil || x.Score != score || x.Member != member {
+		return false
+	}
+
+	for i := 0; i < l.level; i++ {
+		if update[i].levels[i].next == x {
+			update[i].levels[i].span += x.levels[i].span - 1
+			update[i].levels[i].next = x.levels[i].next
+		} else {
+			update[i].levels[i].span--
+		}
+	}
+
+	for l.level > 1 && l.head.levels[l.level-1].next == nil {
+		l.level--
+	}
+
+	l.length--
+
+	return true
+}
+
+// Rank returns the 0-based rank of the given member, or -1 if it is not in the list.
+func (l *List) Rank(score float64, member string) int {
+	rank := 0
+
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.levels[i].next != nil && !x.levels[i].next.greater(score, member) {
+			rank += x.levels[i].span
+			x = x.levels[i].next
+		}
+
+		if x != l.head && x.Score == score && x.Member == member {
+			return rank - 1
+		}
+	}
+
+	return -1
+}
+
+// ByRank returns the element with the given 0-based rank, or nil if out of range.
+func (l *List) ByRank(rank int) *Element {
+	if rank < 0 || rank >= l.length {
+		return nil
+	}
+
+	target := rank + 1
+	traversed := 0
+
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.levels[i].next != nil && traversed+x.levels[i].span <= target {
+			traversed += x.levels[i].span
+			x = x.levels[i].next
+		}
+
+		if traversed == target {
+			return x
+		}
+	}
+
+	return nil
+}
+
+// First returns the first element with a score greater than or equal to min, or nil.
+func (l *List) First(min float64) *Element {
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.levels[i].next != nil && x.levels[i].next.Score < min {
+			x = x.levels[i].next
+		}
+	}
+
+	return x.levels[0].next
+}
+
+// less reports whether e is ordered before the given member.
+func (e *Element) less(score float64, member string) bool {
+	return e.Score < score || (e.Score == score && e.Member < member)
+}
+
+// greater reports whether e is ordered after the given member.
+func (e *Element) greater(score float64, member string) bool {
+	return e.Score > score || (e.Score == score && e.Member > member)
+}
+
+func randomLevel() int {
+	lvl := 1
+	for lvl < maxLevel && rand.Float64() < probability {
+		lvl++
+	}
+	return lvl
+}
@@ -0,0 +1,96 @@
+package skiplist
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	is := assert.New(t)
+
+	l := New()
+	is.Equal(0, l.Len())
+	is.Nil(l.ByRank(0))
+	is.Nil(l.First(0))
+	is.Equal(-1, l.Rank(1, "a"))
+
+	l.Insert(2, "b")
+	l.Insert(1, "c")
+	l.Insert(2, "a")
+	l.Insert(3, "d")
+
+	is.Equal(4, l.Len())
+
+	var members []string
+	for e := l.ByRank(0); e != nil; e = e.Next() {
+		members = append(members, e.Member)
+	}
+	is.Equal([]string{"c", "a", "b", "d"}, members)
+
+	is.Equal(0, l.Rank(1, "c"))
+	is.Equal(1, l.Rank(2, "a"))
+	is.Equal(3, l.Rank(3, "d"))
+	is.Equal(-1, l.Rank(3, "a"))
+
+	is.Equal("a", l.First(1.5).Member)
+	is.Equal("d", l.First(3).Member)
+	is.Nil(l.First(4))
+
+	is.True(l.Delete(2, "a"))
+	is.False(l.Delete(2, "a"))
+	is.Equal(3, l.Len())
+	is.Equal("b", l.ByRank(1).Member)
+	is.Nil(l.ByRank(3))
+}
+
+func TestList_Random(t *testing.T) {
+	is := assert.New(t)
+
+	type entry struct {
+		member string
+		score  float64
+	}
+
+	l := New()
+	entries := map[string]float64{}
+
+	for i := 0; i < 2000; i++ {
+		member := fmt.Sprintf("m%d", rand.Intn(500))
+
+		if score, ok := entries[member]; ok {
+			is.True(l.Delete(score, member))
+			delete(entries, member)
+			continue
+		}
+
+		score := float64(rand.Intn(100))
+		l.Insert(score, member)
+		entries[member] = score
+	}
+
+	expected := make([]entry, 0, len(entries))
+	for member, score := range entries {
+		expected = append(expected, entry{member, score})
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		if expected[i].score != expected[j].score {
+			return expected[i].score < expected[j].score
+		}
+		return expected[i].member < expected[j].member
+	})
+
+	is.Equal(len(expected), l.Len())
+
+	for rank, e := range expected {
+		is.Equal(rank, l.Rank(e.score, e.member))
+
+		element := l.ByRank(rank)
+		if is.NotNil(element) {
+			is.Equal(e.member, element.Member)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+// Package kvstoreotel provides OpenTelemetry tracing for gokvstores.KVStore implementations.
+//
+//	store = kvstoreotel.NewStore(store, kvstoreotel.WithTracerProvider(provider))
+//
+// Every call creates a client span carrying the backend, the operation, the number of
+// keys, hits and misses for reads, and the error if any. Spans are children of the span
+// found in the context given to the call.
+package kvstoreotel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ulule/gokvstores"
+)
+
+const instrumentationName = "github.com/ulule/gokvstores/kvstoreotel"
+
+// Span attribute keys.
+const (
+	BackendKey      = attribute.Key("kvstore.backend")
+	OperationKey    = attribute.Key("kvstore.operation")
+	KeyCountKey     = attribute.Key("kvstore.keys")
+	HitKey          = attribute.Key("kvstore.hit")
+	HitsKey         = attribute.Key("kvstore.hits")
+	MissesKey       = attribute.Key("kvstore.misses")
+	RedisClusterKey = attribute.Key("kvstore.redis.cluster")
+
+	dbSystemKey         = attribute.Key("db.system")
+	dbOperationKey      = attribute.Key("db.operation")
+	dbRedisDatabaseKey  = attribute.Key("db.redis.database_index")
+	dbRedisPipelineSize = attribute.Key("db.redis.pipeline_length")
+)
+
+// Option configures a Store.
+type Option func(*config)
+
+type config struct {
+	provider   trace.TracerProvider
+	attributes []attribute.KeyValue
+	redisHook  bool
+}
+
+// WithTracerProvider sets the tracer provider. Defaults to the global one.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = provider
+	}
+}
+
+// WithAttributes adds the given attributes to every span.
+func WithAttributes(attributes ...attribute.KeyValue) Option {
+	return func(c *config) {
+		c.attributes = append(c.attributes, attributes...)
+	}
+}
+
+// WithRedisHook makes NewStore add a hook to the client of a wrapped *gokvstores.RedisStore,
+// so that every Redis command and pipeline gets a child span. Pipeline spans are also
+// linked to the span of the call which issued them. Since hooks cannot be removed
+// and would emit duplicate spans, it must only be used once per Redis client.
+func WithRedisHook() Option {
+	return func(c *config) {
+		c.redisHook = true
+	}
+}
+
+func newConfig(options []Option) *config {
+	c := &config{}
+	for _, option := range options {
+		option(c)
+	}
+
+	if c.provider == nil {
+		c.provider = otel.GetTracerProvider()
+	}
+
+	return c
+}
+
+// Store is a gokvstores.KVStore wrapper creating a span for every call.
+type Store struct {
+	store      gokvstores.KVStore
+	tracer     trace.Tracer
+	attributes []attribute.KeyValue
+}
+
+// NewStore returns a Store wrapping the given store.
+//
+// When the base store of the wrapped store (see gokvstores.BaseStore) is a
+// *gokvstores.RedisStore, spans also carry the database index or the cluster mode,
+// and Redis commands get child spans with WithRedisHook.
+func NewStore(store gokvstores.KVStore, options ...Option) *Store {
+	c := newConfig(options)
+
+	attributes := append([]attribute.KeyValue{BackendKey.String(gokvstores.BackendName(store))}, c.attributes...)
+
+	if redisStore, ok := gokvstores.BaseStore(store).(*gokvstores.RedisStore); ok {
+		attributes = append(attributes, redisAttributes(redisStore)...)
+
+		if c.redisHook {
+			redisStore.AddHook(NewRedisHook(options...))
+		}
+	}
+
+	return &Store{
+		store:      store,
+		tracer:     c.provider.Tracer(instrumentationName),
+		attributes: attributes,
+	}
+}
+
+// Unwrap returns the wrapped store.
+func (s *Store) Unwrap() gokvstores.KVStore {
+	return s.store
+}
+
+func redisAttributes(store *gokvstores.RedisStore) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{dbSystemKey.String("redis")}
+
+	if store.Cluster() {
+		return append(attributes, RedisClusterKey.Bool(true))
+	}
+
+	return append(attributes, dbRedisDatabaseKey.Int(store.DB()))
+}
+
+// start starts the span of a call to op on the given number of keys.
+func (s *Store) start(ctx context.Context, op string, keys int) (context.Context, trace.Span) {
+	attributes := make([]attribute.KeyValue, 0, len(s.attributes)+3)
+	attributes = append(attributes, s.attributes...)
+	attributes = append(attributes,
+		OperationKey.String(op),
+		dbOperationKey.String(op),
+		KeyCountKey.Int(keys),
+	)
+
+	return s.tracer.Start(ctx, "kvstore."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...))
+}
+
+// end ends the given span, recording err and the given attributes.
+func end(span trace.Span, err error, attributes ...attribute.KeyValue) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(attributes...)
+	}
+	span.End()
+}
+
+// hits returns the hit and miss attributes of a batch read.
+func hits(hits int, total int) []attribute.KeyValue {
+	return []attribute.KeyValue{HitsKey.Int(hits), MissesKey.Int(total - hits)}
+}
+
+// Get returns value for the given key.
+func (s *Store) Get(ctx context.Context, key string) (interface{}, error) {
+	ctx, span := s.start(ctx, gokvstores.OpGet, 1)
+	v, err := s.store.Get(ctx, key)
+	end(span, err, HitKey.Bool(v != nil))
+	return v, err
+}
+
+// MGet returns map of key, value for a list of keys.
+func (s *Store) MGet(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	ctx, span := s.start(ctx, gokvstores.OpMGet, len(keys))
+	values, err := s.store.MGet(ctx, keys)
+
+	found := 0
+	for _, v := range values {
+		if v != nil {
+			found++
+		}
+	}
+
+	end(span, err, hits(found, len(keys))...)
+	return values, err
+}
+
+// Set sets value for the given key.
+func (s *Store) Set(ctx context.Context, key string, value interface{}) error {
+	ctx, span := s.start(ctx, gokvstores.OpSet, 1)
+	err := s.store.Set(ctx, key, value)
+	end(span, err)
+	return err
+}
+
+// SetWithExpiration sets the value for the given key for a specified duration.
+func (s *Store) SetWithExpiration(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	ctx, span := s.start(ctx, gokvstores.OpSetWithExpiration, 1)
+	err := s.store.SetWithExpiration(ctx, key, value, expiration)
+	end(span, err)
+	return err
+}
+
+// GetMap returns map for the given key.
+func (s *Store) GetMap(ctx context.Context, key string) (map[string]interface{}, error) {
+	ctx, span := s.start(ctx, gokvstores.OpGetMap, 1)
+	v, err := s.store.GetMap(ctx, key)
+	end(span, err, HitKey.Bool(len(v) > 0))
+	return v, err
+}
+
+// GetMaps returns maps for the given keys.
+func (s *Store) GetMaps(ctx context.Context, keys []string) (map[string]map[string]interface{}, error) {
+	ctx, span := s.start(ctx, gokvstores.OpGetMaps, len(keys))
+	values, err := s.store.GetMaps(ctx, keys)
+
+	found := 0
+	for _, v := range values {
+		if len(v) > 0 {
+			found++
+		}
+	}
+
+	end(span, err, hits(found, len(keys))...)
+	return values, err
+}
+
+// SetMap sets map for the given key.
+func (s *Store) SetMap(ctx context.Context, key string, value map[string]interface{}) error {
+	ctx, span := s.start(ctx, gokvstores.OpSetMap, 1)
+	err := s.store.SetMap(ctx, key, value)
+	end(span, err)
+	return err
+}
+
+// SetMaps sets the given maps.
+func (s *Store) SetMaps(ctx context.Context, maps map[string]map[string]interface{}) error {
+	ctx, span := s.start(ctx, gokvstores.OpSetMaps, len(maps))
+	err := s.store.SetMaps(ctx, maps)
+	end(span, err)
+	return err
+}
+
+// DeleteMap removes the specified fields from the map stored at key.
+func (s *Store) DeleteMap(ctx context.Context, key string, fields ...string) error {
+	ctx, span := s.start(ctx, gokvstores.OpDeleteMap, 1)
+	err := s.store.DeleteMap(ctx, key, fields...)
+	end(span, err)
+	return err
+}
+
+// GetSlice returns slice for the given key.
+func (s *Store) GetSlice(ctx context.Context, key string) ([]interface{}, error) {
+	ctx, span := s.start(ctx, gokvstores.OpGetSlice, 1)
+	v, err := s.store.GetSlice(ctx, key)
+	end(span, err, HitKey.Bool(len(v) > 0))
+	return v, err
+}
+
+// SetSlice sets slice for the given key.
+func (s *Store) SetSlice(ctx context.Context, key string, value []interface{}) error {
+	ctx, span := s.start(ctx, gokvstores.OpSetSlice, 1)
+	err := s.store.SetSlice(ctx, key, value)
+	end(span, err)
+	return err
+}
+
+// AppendSlice appends values to an existing slice.
+// If key does not exist, creates slice.
+func (s *Store) AppendSlice(ctx context.Context, key string, values ...interface{}) error {
+	ctx, span := s.start(ctx, gokvstores.OpAppendSlice, 1)
+	err := s.store.AppendSlice(ctx, key, values...)
+	end(span, err)
+	return err
+}
+
+// Exists checks if all the given keys exist.
+func (s *Store) Exists(ctx context.Context, keys ...string) (bool, error) {
+	ctx, span := s.start(ctx, gokvstores.OpExists, len(keys))
+	v, err := s.store.Exists(ctx, keys...)
+	end(span, err, HitKey.Bool(v))
+	return v, err
+}
+
+// ExistsAll checks if all the given keys exist.
+func (s *Store) ExistsAll(ctx context.Context, keys ...string) (bool, error) {
+	ctx, span := s.start(ctx, gokvstores.OpExistsAll, len(keys))
+	v, err := s.store.ExistsAll(ctx, keys...)
+	end(span, err, HitKey.Bool(v))
+	return v, err
+}
+
+// ExistsAny checks if at least one of the given keys exists.
+func (s *Store) ExistsAny(ctx context.Context, keys ...string) (bool, error) {
+	ctx, span := s.start(ctx, gokvstores.OpExistsAny, len(keys))
+	v, err := s.store.ExistsAny(ctx, keys...)
+	end(span, err, HitKey.Bool(v))
+	return v, err
+}
+
+// CountExisting returns the number of given keys that exist.
+func (s *Store) CountExisting(ctx context.Context, keys ...string) (int, error) {
+	ctx, span := s.start(ctx, gokvstores.OpCountExisting, len(keys))
+	v, err := s.store.CountExisting(ctx, keys...)
+	end(span, err, hits(v, len(keys))...)
+	return v, err
+}
+
+// Delete deletes the given key.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	ctx, span := s.start(ctx, gokvstores.OpDelete, 1)
+	err := s.store.Delete(ctx, key)
+	end(span, err)
+	return err
+}
+
+// Flush flushes the store.
+func (s *Store) Flush(ctx context.Context) error {
+	ctx, span := s.start(ctx, gokvstores.OpFlush, 0)
+	err := s.store.Flush(ctx)
+	end(span, err)
+	return err
+}
+
+// Keys returns all keys matching pattern.
+func (s *Store) Keys(ctx context.Context, pattern string) ([]interface{}, error) {
+	ctx, span := s.start(ctx, gokvstores.OpKeys, 0)
+	v, err := s.store.Keys(ctx, pattern)
+	end(span, err, HitKey.Bool(len(v) > 0))
+	return v, err
+}
+
+// Close closes the wrapped store. It is not traced, since it does not take a context.
+func (s *Store) Close() error {
+	return s.store.Close()
+}
+
+// RunScript runs script on the wrapped store, which must implement gokvstores.ScriptStore.
+func (s *Store) RunScript(ctx context.Context, script *gokvstores.Script, keys []string, args ...interface{}) *gokvstores.ScriptResult {
+	ctx, span := s.start(ctx, gokvstores.OpRunScript, len(keys))
+	res := gokvstores.RunScript(ctx, s.store, script, keys, args...)
+	end(span, res.Err())
+	return res
+}
+
+// Health checks the wrapped store, which should implement gokvstores.HealthChecker.
+func (s *Store) Health(ctx context.Context) gokvstores.HealthStatus {
+	return gokvstores.Health(ctx, s.store)
+}
+
+var (
+	_ gokvstores.KVStore       = &Store{}
+	_ gokvstores.ScriptStore   = &Store{}
+	_ gokvstores.HealthChecker = &Store{}
+)
@@ -0,0 +1,193 @@
+package kvstoreotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/ulule/gokvstores"
+	"github.com/ulule/gokvstores/kvstoretest"
+	"github.com/ulule/gokvstores/redistest"
+)
+
+func newProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), exporter
+}
+
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	values := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func TestStore(t *testing.T) {
+	provider, _ := newProvider()
+
+	kvstoretest.RunConformance(t, func(t *testing.T) gokvstores.KVStore {
+		store, err := gokvstores.NewMemoryStore(time.Minute, time.Minute)
+		require.NoError(t, err)
+
+		return NewStore(store, WithTracerProvider(provider))
+	})
+}
+
+func TestStore_Spans(t *testing.T) {
+	is := assert.New(t)
+
+	provider, exporter := newProvider()
+
+	memory, err := gokvstores.NewMemoryStore(time.Minute, time.Minute)
+	is.NoError(err)
+
+	chaos := gokvstores.NewChaosStore(memory, gokvstores.ChaosOptions{})
+	store := NewStore(chaos, WithTracerProvider(provider), WithAttributes(attribute.String("service", "test")))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	_, err = store.Get(ctx, "key")
+	is.NoError(err)
+
+	_, err = store.MGet(ctx, []string{"key", "missing"})
+	is.NoError(err)
+
+	chaos.FailNext(gokvstores.OpGet, 1, nil)
+	_, err = store.Get(ctx, "key")
+	is.Error(err)
+
+	parent.End()
+
+	spans := exporter.GetSpans()
+	is.Len(spans, 5)
+
+	for _, span := range spans[:4] {
+		is.Equal(parent.SpanContext().SpanID(), span.Parent.SpanID())
+
+		attrs := attributes(span)
+		is.Equal(gokvstores.BackendMemory, attrs[BackendKey].AsString())
+		is.Equal("test", attrs["service"].AsString())
+	}
+
+	is.Equal("kvstore.Set", spans[0].Name)
+	is.Equal(int64(1), attributes(spans[0])[KeyCountKey].AsInt64())
+
+	is.Equal("kvstore.Get", spans[1].Name)
+	is.True(attributes(spans[1])[HitKey].AsBool())
+
+	is.Equal("kvstore.MGet", spans[2].Name)
+	is.Equal(int64(1), attributes(spans[2])[HitsKey].AsInt64())
+	is.Equal(int64(1), attributes(spans[2])[MissesKey].AsInt64())
+
+	is.Equal(codes.Error, spans[3].Status.Code)
+	is.Len(spans[3].Events, 1)
+}
+
+func TestStore_RunScript(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	provider, exporter := newProvider()
+
+	memory, err := gokvstores.NewMemoryStore(time.Minute, time.Minute)
+	is.NoError(err)
+
+	script := gokvstores.NewScript("return redis.call('GET', KEYS[1])")
+	memory.(*gokvstores.MemoryStore).RegisterScript(script, func(ctx context.Context, store gokvstores.KVStore, keys []string, args []string) (interface{}, error) {
+		return store.Get(ctx, keys[0])
+	})
+
+	store := NewStore(memory, WithTracerProvider(provider))
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	// Scripts are forwarded to the wrapped store.
+	v, err := store.RunScript(ctx, script, []string{"key"}).Text()
+	is.NoError(err)
+	is.Equal("value", v)
+
+	spans := exporter.GetSpans()
+	is.Len(spans, 2)
+	is.Equal("kvstore.RunScript", spans[1].Name)
+	is.Equal(int64(1), attributes(spans[1])[KeyCountKey].AsInt64())
+
+	// So are health checks.
+	is.True(store.Health(ctx).Healthy)
+	is.Equal(gokvstores.BackendMemory, store.Health(ctx).Backend)
+}
+
+func TestStore_Redis(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, err := redistest.NewServer()
+	is.NoError(err)
+	defer server.Close()
+
+	redisStore, err := gokvstores.NewRedisClientStore(ctx, &gokvstores.RedisClientOptions{
+		Addr: server.Addr(),
+		DB:   2,
+	}, time.Minute)
+	is.NoError(err)
+
+	// Without WithRedisHook, Redis commands have no spans.
+	provider, exporter := newProvider()
+	_, err = NewStore(redisStore, WithTracerProvider(provider)).Exists(ctx, "key")
+	is.NoError(err)
+
+	spans := exporter.GetSpans()
+	is.Len(spans, 1)
+	is.Equal("kvstore.Exists", spans[0].Name)
+
+	provider, exporter = newProvider()
+	store := NewStore(redisStore, WithTracerProvider(provider), WithRedisHook())
+	defer store.Close()
+
+	is.Equal(redisStore, store.Unwrap())
+
+	_, err = store.Get(ctx, "key")
+	is.NoError(err)
+
+	_, err = store.GetMaps(ctx, []string{"a", "b"})
+	is.NoError(err)
+
+	spans = exporter.GetSpans()
+	is.Len(spans, 4)
+
+	// Command spans end before the KVStore call spans.
+
+	is.Equal("redis.get", spans[0].Name)
+	is.Equal("kvstore.Get", spans[1].Name)
+	is.Equal(spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+	is.Equal("redis", attributes(spans[1])[BackendKey].AsString())
+	is.Equal(int64(2), attributes(spans[1])[dbRedisDatabaseKey].AsInt64())
+	is.False(attributes(spans[1])[HitKey].AsBool())
+
+	is.Equal("redis.pipeline", spans[2].Name)
+	is.Equal("kvstore.GetMaps", spans[3].Name)
+	is.Equal(spans[3].SpanContext.SpanID(), spans[2].Parent.SpanID())
+	is.Equal(int64(2), attributes(spans[2])[dbRedisPipelineSize].AsInt64())
+
+	// Pipelines are linked to the span which issued them.
+	is.Len(spans[2].Links, 1)
+	is.Equal(spans[3].SpanContext.SpanID(), spans[2].Links[0].SpanContext.SpanID())
+
+	// Redis stores are recognized behind other wrappers.
+	provider, exporter = newProvider()
+	_, err = NewStore(gokvstores.NewRetryStore(redisStore, gokvstores.RetryPolicy{}), WithTracerProvider(provider)).Exists(ctx, "key")
+	is.NoError(err)
+
+	spans = exporter.GetSpans()
+	is.Len(spans, 1)
+	is.Equal("redis", attributes(spans[0])[BackendKey].AsString())
+	is.Equal(int64(2), attributes(spans[0])[dbRedisDatabaseKey].AsInt64())
+}
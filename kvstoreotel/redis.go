@@ -0,0 +1,103 @@
+package kvstoreotel
+
+import (
+	"context"
+	"strings"
+
+	redis "github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redisHook is a go-redis hook creating a span for every Redis command and pipeline.
+type redisHook struct {
+	tracer trace.Tracer
+	config *config
+}
+
+// NewRedisHook returns a go-redis hook creating a span for every Redis command and pipeline.
+// NewStore adds it to wrapped Redis stores with WithRedisHook, so that command spans
+// are children of the span of the KVStore call which issued them.
+func NewRedisHook(options ...Option) redis.Hook {
+	c := newConfig(options)
+
+	return &redisHook{
+		tracer: c.provider.Tracer(instrumentationName),
+		config: c,
+	}
+}
+
+// BeforeProcess starts the span of a command.
+func (h *redisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, _ = h.tracer.Start(ctx, "redis."+cmd.Name(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(h.config.attributes...),
+		trace.WithAttributes(
+			dbSystemKey.String("redis"),
+			dbOperationKey.String(cmd.Name()),
+		))
+
+	return ctx, nil
+}
+
+// AfterProcess ends the span of a command.
+func (h *redisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	span := trace.SpanFromContext(ctx)
+	recordRedisError(span, cmd.Err())
+	span.End()
+
+	return nil
+}
+
+// BeforeProcessPipeline starts the span of a pipeline, linked to the span found in ctx.
+func (h *redisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.Name()
+	}
+
+	options := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(h.config.attributes...),
+		trace.WithAttributes(
+			dbSystemKey.String("redis"),
+			dbOperationKey.String(strings.Join(names, " ")),
+			dbRedisPipelineSize.Int(len(cmds)),
+		),
+	}
+
+	// Pipelines are batches of commands, which OpenTelemetry links to the span issuing them.
+	if link := trace.LinkFromContext(ctx); link.SpanContext.IsValid() {
+		options = append(options, trace.WithLinks(link))
+	}
+
+	ctx, _ = h.tracer.Start(ctx, "redis.pipeline", options...)
+
+	return ctx, nil
+}
+
+// AfterProcessPipeline ends the span of a pipeline.
+func (h *redisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	span := trace.SpanFromContext(ctx)
+
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && err != redis.Nil {
+			recordRedisError(span, err)
+			break
+		}
+	}
+
+	span.End()
+
+	return nil
+}
+
+// recordRedisError records err on span, a missing key not being an error.
+func recordRedisError(span trace.Span, err error) {
+	if err == nil || err == redis.Nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
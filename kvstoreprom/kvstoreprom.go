@@ -0,0 +1,435 @@
+// Package kvstoreprom provides Prometheus metrics for gokvstores.KVStore implementations.
+//
+//	collector := kvstoreprom.NewCollector(kvstoreprom.Options{
+//		KeyPrefix: kvstoreprom.PrefixBefore(":"),
+//	})
+//	prometheus.MustRegister(collector)
+//
+//	store = collector.Wrap("sessions", store)
+//
+// Every call is counted per store, operation and key prefix, with hits and misses
+// for reads, errors and a latency histogram. Redis stores also export their
+// connection pool statistics.
+package kvstoreprom
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ulule/gokvstores"
+)
+
+// Options are Collector options.
+type Options struct {
+	// Namespace prefixes metric names. Defaults to "gokvstores".
+	Namespace string
+
+	// Buckets are the latency histogram buckets, in seconds.
+	// Defaults to prometheus.DefBuckets.
+	Buckets []float64
+
+	// KeyPrefix returns the prefix label of a key. Defaults to an empty prefix for
+	// every key, since keys are usually too numerous to be used as labels.
+	KeyPrefix func(key string) string
+}
+
+// PrefixBefore returns a KeyPrefix function returning the part of a key before
+// the first occurrence of sep, or an empty prefix when key does not contain sep.
+func PrefixBefore(sep string) func(key string) string {
+	return func(key string) string {
+		if i := strings.Index(key, sep); i >= 0 {
+			return key[:i]
+		}
+		return ""
+	}
+}
+
+// Collector is a prometheus.Collector for the metrics of wrapped stores.
+type Collector struct {
+	keyPrefix func(key string) string
+
+	calls    *prometheus.CounterVec
+	hits     *prometheus.CounterVec
+	misses   *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+
+	poolHits       *prometheus.Desc
+	poolMisses     *prometheus.Desc
+	poolTimeouts   *prometheus.Desc
+	poolTotalConns *prometheus.Desc
+	poolIdleConns  *prometheus.Desc
+	poolStaleConns *prometheus.Desc
+
+	mu    sync.Mutex
+	pools map[string]*gokvstores.RedisStore
+}
+
+// NewCollector returns a new Collector.
+func NewCollector(options Options) *Collector {
+	if options.Namespace == "" {
+		options.Namespace = "gokvstores"
+	}
+
+	if options.Buckets == nil {
+		options.Buckets = prometheus.DefBuckets
+	}
+
+	if options.KeyPrefix == nil {
+		options.KeyPrefix = func(string) string { return "" }
+	}
+
+	labels := []string{"store", "operation", "prefix"}
+
+	counter := func(name string, help string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Name:      name,
+			Help:      help,
+		}, labels)
+	}
+
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(options.Namespace, "redis_pool", name), help, []string{"store"}, nil)
+	}
+
+	return &Collector{
+		keyPrefix: options.KeyPrefix,
+		calls:     counter("calls_total", "Number of store calls."),
+		hits:      counter("hits_total", "Number of keys found by store reads."),
+		misses:    counter("misses_total", "Number of keys not found by store reads."),
+		errors:    counter("errors_total", "Number of failed store calls."),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: options.Namespace,
+			Name:      "call_duration_seconds",
+			Help:      "Duration of store calls.",
+			Buckets:   options.Buckets,
+		}, labels),
+
+		poolHits:       desc("hits_total", "Number of times a free connection was found in the pool."),
+		poolMisses:     desc("misses_total", "Number of times a free connection was not found in the pool."),
+		poolTimeouts:   desc("timeouts_total", "Number of times a wait for a connection timed out."),
+		poolTotalConns: desc("total_conns", "Number of connections in the pool."),
+		poolIdleConns:  desc("idle_conns", "Number of idle connections in the pool."),
+		poolStaleConns: desc("stale_conns_total", "Number of stale connections removed from the pool."),
+
+		pools: map[string]*gokvstores.RedisStore{},
+	}
+}
+
+// Wrap returns a Store recording the metrics of the given store under the given name.
+// Pool statistics of Redis stores are collected until they are closed.
+func (c *Collector) Wrap(name string, store gokvstores.KVStore) *Store {
+	if redisStore, ok := store.(*gokvstores.RedisStore); ok {
+		c.mu.Lock()
+		c.pools[name] = redisStore
+		c.mu.Unlock()
+	}
+
+	return &Store{
+		store:     store,
+		name:      name,
+		collector: c,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.calls.Describe(ch)
+	c.hits.Describe(ch)
+	c.misses.Describe(ch)
+	c.errors.Describe(ch)
+	c.duration.Describe(ch)
+
+	ch <- c.poolHits
+	ch <- c.poolMisses
+	ch <- c.poolTimeouts
+	ch <- c.poolTotalConns
+	ch <- c.poolIdleConns
+	ch <- c.poolStaleConns
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.calls.Collect(ch)
+	c.hits.Collect(ch)
+	c.misses.Collect(ch)
+	c.errors.Collect(ch)
+	c.duration.Collect(ch)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, store := range c.pools {
+		stats := store.PoolStats()
+		if stats == nil {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.poolHits, prometheus.CounterValue, float64(stats.Hits), name)
+		ch <- prometheus.MustNewConstMetric(c.poolMisses, prometheus.CounterValue, float64(stats.Misses), name)
+		ch <- prometheus.MustNewConstMetric(c.poolTimeouts, prometheus.CounterValue, float64(stats.Timeouts), name)
+		ch <- prometheus.MustNewConstMetric(c.poolTotalConns, prometheus.GaugeValue, float64(stats.TotalConns), name)
+		ch <- prometheus.MustNewConstMetric(c.poolIdleConns, prometheus.GaugeValue, float64(stats.IdleConns), name)
+		ch <- prometheus.MustNewConstMetric(c.poolStaleConns, prometheus.CounterValue, float64(stats.StaleConns), name)
+	}
+}
+
+// unregister stops collecting the pool statistics of the given store.
+func (c *Collector) unregister(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.pools, name)
+}
+
+var _ prometheus.Collector = &Collector{}
+
+// Store is a gokvstores.KVStore wrapper recording metrics in a Collector.
+type Store struct {
+	store     gokvstores.KVStore
+	name      string
+	collector *Collector
+}
+
+// Unwrap returns the wrapped store.
+func (s *Store) Unwrap() gokvstores.KVStore {
+	return s.store
+}
+
+// observe records a call to op on the given keys.
+// found is the number of keys found for reads, or -1 for writes.
+func (s *Store) observe(op string, keys []string, start time.Time, err error, found int) {
+	c := s.collector
+
+	prefix := ""
+	if len(keys) > 0 {
+		prefix = c.keyPrefix(keys[0])
+	}
+
+	c.calls.WithLabelValues(s.name, op, prefix).Inc()
+	c.duration.WithLabelValues(s.name, op, prefix).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		c.errors.WithLabelValues(s.name, op, prefix).Inc()
+		return
+	}
+
+	if found < 0 {
+		return
+	}
+
+	c.hits.WithLabelValues(s.name, op, prefix).Add(float64(found))
+	c.misses.WithLabelValues(s.name, op, prefix).Add(float64(len(keys) - found))
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Get returns value for the given key.
+func (s *Store) Get(ctx context.Context, key string) (interface{}, error) {
+	start := time.Now()
+	v, err := s.store.Get(ctx, key)
+	s.observe(gokvstores.OpGet, []string{key}, start, err, boolToInt(v != nil))
+	return v, err
+}
+
+// MGet returns map of key, value for a list of keys.
+func (s *Store) MGet(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	start := time.Now()
+	values, err := s.store.MGet(ctx, keys)
+
+	found := 0
+	for _, v := range values {
+		if v != nil {
+			found++
+		}
+	}
+
+	s.observe(gokvstores.OpMGet, keys, start, err, found)
+	return values, err
+}
+
+// Set sets value for the given key.
+func (s *Store) Set(ctx context.Context, key string, value interface{}) error {
+	start := time.Now()
+	err := s.store.Set(ctx, key, value)
+	s.observe(gokvstores.OpSet, []string{key}, start, err, -1)
+	return err
+}
+
+// SetWithExpiration sets the value for the given key for a specified duration.
+func (s *Store) SetWithExpiration(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	start := time.Now()
+	err := s.store.SetWithExpiration(ctx, key, value, expiration)
+	s.observe(gokvstores.OpSetWithExpiration, []string{key}, start, err, -1)
+	return err
+}
+
+// GetMap returns map for the given key.
+func (s *Store) GetMap(ctx context.Context, key string) (map[string]interface{}, error) {
+	start := time.Now()
+	v, err := s.store.GetMap(ctx, key)
+	s.observe(gokvstores.OpGetMap, []string{key}, start, err, boolToInt(len(v) > 0))
+	return v, err
+}
+
+// GetMaps returns maps for the given keys.
+func (s *Store) GetMaps(ctx context.Context, keys []string) (map[string]map[string]interface{}, error) {
+	start := time.Now()
+	values, err := s.store.GetMaps(ctx, keys)
+
+	found := 0
+	for _, v := range values {
+		if len(v) > 0 {
+			found++
+		}
+	}
+
+	s.observe(gokvstores.OpGetMaps, keys, start, err, found)
+	return values, err
+}
+
+// SetMap sets map for the given key.
+func (s *Store) SetMap(ctx context.Context, key string, value map[string]interface{}) error {
+	start := time.Now()
+	err := s.store.SetMap(ctx, key, value)
+	s.observe(gokvstores.OpSetMap, []string{key}, start, err, -1)
+	return err
+}
+
+// SetMaps sets the given maps.
+func (s *Store) SetMaps(ctx context.Context, maps map[string]map[string]interface{}) error {
+	start := time.Now()
+	err := s.store.SetMaps(ctx, maps)
+
+	keys := make([]string, 0, len(maps))
+	for key := range maps {
+		keys = append(keys, key)
+	}
+
+	s.observe(gokvstores.OpSetMaps, keys, start, err, -1)
+	return err
+}
+
+// DeleteMap removes the specified fields from the map stored at key.
+func (s *Store) DeleteMap(ctx context.Context, key string, fields ...string) error {
+	start := time.Now()
+	err := s.store.DeleteMap(ctx, key, fields...)
+	s.observe(gokvstores.OpDeleteMap, []string{key}, start, err, -1)
+	return err
+}
+
+// GetSlice returns slice for the given key.
+func (s *Store) GetSlice(ctx context.Context, key string) ([]interface{}, error) {
+	start := time.Now()
+	v, err := s.store.GetSlice(ctx, key)
+	s.observe(gokvstores.OpGetSlice, []string{key}, start, err, boolToInt(len(v) > 0))
+	return v, err
+}
+
+// SetSlice sets slice for the given key.
+func (s *Store) SetSlice(ctx context.Context, key string, value []interface{}) error {
+	start := time.Now()
+	err := s.store.SetSlice(ctx, key, value)
+	s.observe(gokvstores.OpSetSlice, []string{key}, start, err, -1)
+	return err
+}
+
+// AppendSlice appends values to an existing slice.
+// If key does not exist, creates slice.
+func (s *Store) AppendSlice(ctx context.Context, key string, values ...interface{}) error {
+	start := time.Now()
+	err := s.store.AppendSlice(ctx, key, values...)
+	s.observe(gokvstores.OpAppendSlice, []string{key}, start, err, -1)
+	return err
+}
+
+// Exists checks if all the given keys exist.
+func (s *Store) Exists(ctx context.Context, keys ...string) (bool, error) {
+	start := time.Now()
+	v, err := s.store.Exists(ctx, keys...)
+	s.observe(gokvstores.OpExists, keys, start, err, -1)
+	return v, err
+}
+
+// ExistsAll checks if all the given keys exist.
+func (s *Store) ExistsAll(ctx context.Context, keys ...string) (bool, error) {
+	start := time.Now()
+	v, err := s.store.ExistsAll(ctx, keys...)
+	s.observe(gokvstores.OpExistsAll, keys, start, err, -1)
+	return v, err
+}
+
+// ExistsAny checks if at least one of the given keys exists.
+func (s *Store) ExistsAny(ctx context.Context, keys ...string) (bool, error) {
+	start := time.Now()
+	v, err := s.store.ExistsAny(ctx, keys...)
+	s.observe(gokvstores.OpExistsAny, keys, start, err, -1)
+	return v, err
+}
+
+// CountExisting returns the number of given keys that exist.
+func (s *Store) CountExisting(ctx context.Context, keys ...string) (int, error) {
+	start := time.Now()
+	v, err := s.store.CountExisting(ctx, keys...)
+	s.observe(gokvstores.OpCountExisting, keys, start, err, v)
+	return v, err
+}
+
+// Delete deletes the given key.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := s.store.Delete(ctx, key)
+	s.observe(gokvstores.OpDelete, []string{key}, start, err, -1)
+	return err
+}
+
+// Flush flushes the store.
+func (s *Store) Flush(ctx context.Context) error {
+	start := time.Now()
+	err := s.store.Flush(ctx)
+	s.observe(gokvstores.OpFlush, nil, start, err, -1)
+	return err
+}
+
+// Keys returns all keys matching pattern.
+func (s *Store) Keys(ctx context.Context, pattern string) ([]interface{}, error) {
+	start := time.Now()
+	v, err := s.store.Keys(ctx, pattern)
+	s.observe(gokvstores.OpKeys, []string{pattern}, start, err, -1)
+	return v, err
+}
+
+// Close closes the wrapped store and stops collecting its pool statistics.
+func (s *Store) Close() error {
+	s.collector.unregister(s.name)
+	return s.store.Close()
+}
+
+// RunScript runs script on the wrapped store, which must implement gokvstores.ScriptStore.
+func (s *Store) RunScript(ctx context.Context, script *gokvstores.Script, keys []string, args ...interface{}) *gokvstores.ScriptResult {
+	start := time.Now()
+	res := gokvstores.RunScript(ctx, s.store, script, keys, args...)
+	s.observe(gokvstores.OpRunScript, keys, start, res.Err(), -1)
+	return res
+}
+
+// Health checks the wrapped store, which should implement gokvstores.HealthChecker.
+func (s *Store) Health(ctx context.Context) gokvstores.HealthStatus {
+	return gokvstores.Health(ctx, s.store)
+}
+
+var (
+	_ gokvstores.KVStore       = &Store{}
+	_ gokvstores.ScriptStore   = &Store{}
+	_ gokvstores.HealthChecker = &Store{}
+)
@@ -0,0 +1,131 @@
+package kvstoreprom
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+	"github.com/ulule/gokvstores/kvstoretest"
+	"github.com/ulule/gokvstores/redistest"
+)
+
+func TestStore(t *testing.T) {
+	collector := NewCollector(Options{})
+
+	kvstoretest.RunConformance(t, func(t *testing.T) gokvstores.KVStore {
+		store, err := gokvstores.NewMemoryStore(time.Minute, time.Minute)
+		require.NoError(t, err)
+
+		return collector.Wrap("memory", store)
+	})
+}
+
+func TestStore_Metrics(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	collector := NewCollector(Options{KeyPrefix: PrefixBefore(":")})
+
+	registry := prometheus.NewRegistry()
+	is.NoError(registry.Register(collector))
+
+	memory, err := gokvstores.NewMemoryStore(time.Minute, time.Minute)
+	is.NoError(err)
+
+	chaos := gokvstores.NewChaosStore(memory, gokvstores.ChaosOptions{})
+	store := collector.Wrap("sessions", chaos)
+
+	is.NoError(store.Set(ctx, "user:1", "value"))
+
+	_, err = store.Get(ctx, "user:1")
+	is.NoError(err)
+
+	_, err = store.Get(ctx, "user:2")
+	is.NoError(err)
+
+	_, err = store.MGet(ctx, []string{"user:1", "user:2", "user:3"})
+	is.NoError(err)
+
+	chaos.FailNext(gokvstores.OpGet, 1, nil)
+	_, err = store.Get(ctx, "user:1")
+	is.Error(err)
+
+	is.Equal(float64(3), testutil.ToFloat64(collector.calls.WithLabelValues("sessions", gokvstores.OpGet, "user")))
+	is.Equal(float64(1), testutil.ToFloat64(collector.hits.WithLabelValues("sessions", gokvstores.OpGet, "user")))
+	is.Equal(float64(1), testutil.ToFloat64(collector.misses.WithLabelValues("sessions", gokvstores.OpGet, "user")))
+	is.Equal(float64(1), testutil.ToFloat64(collector.errors.WithLabelValues("sessions", gokvstores.OpGet, "user")))
+	is.Equal(float64(1), testutil.ToFloat64(collector.hits.WithLabelValues("sessions", gokvstores.OpMGet, "user")))
+	is.Equal(float64(2), testutil.ToFloat64(collector.misses.WithLabelValues("sessions", gokvstores.OpMGet, "user")))
+	is.Equal(float64(1), testutil.ToFloat64(collector.calls.WithLabelValues("sessions", gokvstores.OpSet, "user")))
+
+	// Scripts are forwarded to the wrapped store.
+	script := gokvstores.NewScript("return redis.call('GET', KEYS[1])")
+	memory.(*gokvstores.MemoryStore).RegisterScript(script, func(ctx context.Context, store gokvstores.KVStore, keys []string, args []string) (interface{}, error) {
+		return store.Get(ctx, keys[0])
+	})
+
+	v, err := store.RunScript(ctx, script, []string{"user:1"}).Text()
+	is.NoError(err)
+	is.Equal("value", v)
+	is.Equal(float64(1), testutil.ToFloat64(collector.calls.WithLabelValues("sessions", gokvstores.OpRunScript, "user")))
+
+	count, err := testutil.GatherAndCount(registry, "gokvstores_call_duration_seconds")
+	is.NoError(err)
+	is.Equal(4, count)
+
+	// Health checks are forwarded to the wrapped store.
+	is.True(store.Health(ctx).Healthy)
+}
+
+func TestStore_RedisPoolStats(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, err := redistest.NewServer()
+	is.NoError(err)
+	defer server.Close()
+
+	redisStore, err := gokvstores.NewRedisClientStore(ctx, &gokvstores.RedisClientOptions{Addr: server.Addr()}, time.Minute)
+	is.NoError(err)
+
+	collector := NewCollector(Options{Namespace: "app"})
+	store := collector.Wrap("cache", redisStore)
+	is.Equal(redisStore, store.Unwrap())
+
+	_, err = store.Get(ctx, "key")
+	is.NoError(err)
+
+	registry := prometheus.NewRegistry()
+	is.NoError(registry.Register(collector))
+
+	count, err := testutil.GatherAndCount(registry,
+		"app_redis_pool_hits_total",
+		"app_redis_pool_total_conns",
+		"app_redis_pool_idle_conns",
+		"app_redis_pool_stale_conns_total")
+	is.NoError(err)
+	is.Equal(4, count)
+
+	// Cumulative statistics are counters.
+	families, err := registry.Gather()
+	is.NoError(err)
+
+	for _, family := range families {
+		if strings.HasSuffix(family.GetName(), "_total") {
+			is.Equal("COUNTER", family.GetType().String(), family.GetName())
+		}
+	}
+
+	is.NoError(store.Close())
+
+	count, err = testutil.GatherAndCount(registry, "app_redis_pool_total_conns")
+	is.NoError(err)
+	is.Equal(0, count)
+}
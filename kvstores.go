@@ -2,7 +2,7 @@ package gokvstores
 
 import (
 	"context"
-	"sort"
+	"fmt"
 	"time"
 )
 
@@ -14,10 +14,11 @@ type KVStore interface {
 	// MGet returns map of key, value for a list of keys.
 	MGet(ctx context.Context, keys []string) (map[string]interface{}, error)
 
-	// Set sets value for the given key.
+	// Set sets value for the given key. A nil value is stored as an empty string.
 	Set(ctx context.Context, key string, value interface{}) error
 
 	// SetWithExpiration sets the value for the given key for a specified duration.
+	// A nil value is stored as an empty string.
 	SetWithExpiration(ctx context.Context, key string, value interface{}, expiration time.Duration) error
 
 	// GetMap returns map for the given key.
@@ -45,9 +46,22 @@ type KVStore interface {
 	// If key does not exist, creates slice.
 	AppendSlice(ctx context.Context, key string, values ...interface{}) error
 
-	// Exists checks if the given key exists.
+	// Exists checks if all the given keys exist.
+	// It is equivalent to ExistsAll.
 	Exists(ctx context.Context, keys ...string) (bool, error)
 
+	// ExistsAll checks if all the given keys exist.
+	// It returns false when no keys are given.
+	ExistsAll(ctx context.Context, keys ...string) (bool, error)
+
+	// ExistsAny checks if at least one of the given keys exists.
+	// It returns false when no keys are given.
+	ExistsAny(ctx context.Context, keys ...string) (bool, error)
+
+	// CountExisting returns the number of given keys that exist.
+	// A key given multiple times is counted multiple times.
+	CountExisting(ctx context.Context, keys ...string) (int, error)
+
 	// Delete deletes the given key.
 	Delete(ctx context.Context, key string) error
 
@@ -61,19 +75,67 @@ type KVStore interface {
 	Close() error
 }
 
-func stringSlice(values []interface{}) ([]string, error) {
-	converted := []string{}
+// Names of the KVStore operations, as reported by wrapper stores.
+const (
+	OpGet               = "Get"
+	OpMGet              = "MGet"
+	OpSet               = "Set"
+	OpSetWithExpiration = "SetWithExpiration"
+	OpGetMap            = "GetMap"
+	OpGetMaps           = "GetMaps"
+	OpSetMap            = "SetMap"
+	OpSetMaps           = "SetMaps"
+	OpDeleteMap         = "DeleteMap"
+	OpGetSlice          = "GetSlice"
+	OpSetSlice          = "SetSlice"
+	OpAppendSlice       = "AppendSlice"
+	OpExists            = "Exists"
+	OpExistsAll         = "ExistsAll"
+	OpExistsAny         = "ExistsAny"
+	OpCountExisting     = "CountExisting"
+	OpDelete            = "Delete"
+	OpFlush             = "Flush"
+	OpKeys              = "Keys"
+	OpClose             = "Close"
+)
+
+// Names of the built-in backends.
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+	BackendDummy  = "dummy"
+)
+
+// BaseStore returns the store at the bottom of a chain of wrapper stores, such as
+// LoggingStore or RetryStore, which expose the store they wrap with an Unwrap method.
+// It returns store itself if it does not wrap another store.
+func BaseStore(store KVStore) KVStore {
+	for {
+		wrapper, ok := store.(interface{ Unwrap() KVStore })
+		if !ok {
+			return store
+		}
 
-	for _, v := range values {
-		if v != nil {
-			val, ok := v.(string)
-			if ok {
-				converted = append(converted, val)
-			}
+		wrapped := wrapper.Unwrap()
+		if wrapped == nil {
+			return store
 		}
-	}
 
-	sort.Strings(converted)
+		store = wrapped
+	}
+}
 
-	return converted, nil
+// BackendName returns the backend name of the base store of the given store (see BaseStore):
+// one of the Backend constants for built-in stores, or its type name otherwise.
+func BackendName(store KVStore) string {
+	switch store := BaseStore(store); store.(type) {
+	case *RedisStore:
+		return BackendRedis
+	case *MemoryStore:
+		return BackendMemory
+	case DummyStore, *DummyStore:
+		return BackendDummy
+	default:
+		return fmt.Sprintf("%T", store)
+	}
 }
@@ -1,185 +1,139 @@
-package gokvstores
+package gokvstores_test
 
 import (
 	"context"
-	"sort"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
-)
-
-func testStore(t *testing.T, store KVStore) {
-	is := assert.New(t)
-	ctx := context.Background()
-
-	err := store.Flush(ctx)
-	is.NoError(err)
-
-	itemResults := map[string]interface{}{
-		"key1": "1",
-		"key2": "2",
-		"key3": "3",
-	}
-
-	for key, expected := range itemResults {
-		// Set
-
-		err = store.Set(ctx, key, expected)
-		is.NoError(err)
-
-		// Get
-
-		v, err := store.Get(ctx, key)
-		is.NoError(err)
-		val, ok := v.(string)
-		is.True(ok)
-		is.Equal(expected, val)
+	"github.com/stretchr/testify/require"
 
-		exists, err := store.Exists(ctx, key)
-		is.NoError(err)
-		is.True(exists)
-
-	}
-
-	keys := []string{"key1", "key2", "key3"}
+	"github.com/ulule/gokvstores"
+	"github.com/ulule/gokvstores/kvstoretest"
+)
 
-	mResults, err := store.MGet(ctx, keys)
+// capabilityConformance are the conformance suites of the capability interfaces,
+// which are all implemented by MemoryStore and RedisStore.
+var capabilityConformance = []struct {
+	name string
+	run  func(t *testing.T, factory kvstoretest.Factory)
+}{
+	{"SortedSet", kvstoretest.RunSortedSetConformance},
+	{"List", kvstoretest.RunListConformance},
+	{"Stream", kvstoretest.RunStreamConformance},
+	{"PubSub", kvstoretest.RunPubSubConformance},
+	{"Watch", kvstoretest.RunWatchConformance},
+	{"Probabilistic", kvstoretest.RunProbabilisticConformance},
+	{"Bitmap", kvstoretest.RunBitmapConformance},
+	{"Geo", kvstoretest.RunGeoConformance},
+	{"Script", kvstoretest.RunScriptConformance},
+}
 
-	for key, result := range mResults {
-		val, ok := result.(string)
-		is.True(ok)
-		is.Equal(val, itemResults[key])
+// runCapabilityConformance runs the capability conformance suites against stores
+// returned by factory, each as a subtest.
+func runCapabilityConformance(t *testing.T, factory kvstoretest.Factory) {
+	for _, suite := range capabilityConformance {
+		suite := suite
+		t.Run(suite.name, func(t *testing.T) {
+			suite.run(t, factory)
+		})
 	}
+}
 
-	for key := range itemResults {
-		// Delete
-
-		err = store.Delete(ctx, key)
-		is.NoError(err)
-
-		v, _ := store.Get(ctx, key)
-		is.Nil(v)
-
-		exists, err := store.Exists(ctx, key)
-		is.NoError(err)
-		is.False(exists)
-	}
+// createCapabilityKeys creates a key with every capability interface and returns them.
+func createCapabilityKeys(t *testing.T, store gokvstores.KVStore) []string {
+	ctx := context.Background()
 
-	// Map
+	_, err := store.(gokvstores.SortedSetStore).ZAdd(ctx, "zset", gokvstores.Z{Member: "a", Score: 1})
+	require.NoError(t, err)
 
-	mapResults := map[string]map[string]interface{}{
-		"key1": {"language": "go"},
-		"key2": {"integer": "1"},
-		"key3": {"float": "20.2"},
-	}
+	_, err = store.(gokvstores.ListStore).PushRight(ctx, "list", "a")
+	require.NoError(t, err)
 
-	for key, expected := range mapResults {
-		err = store.SetMap(ctx, key, expected)
-		is.NoError(err)
+	_, err = store.(gokvstores.StreamStore).Append(ctx, "stream", map[string]string{"a": "1"})
+	require.NoError(t, err)
 
-		v, err := store.GetMap(ctx, key)
-		is.Equal(expected, v)
+	_, err = store.(gokvstores.HyperLogLogStore).PFAdd(ctx, "hll", "a")
+	require.NoError(t, err)
 
-		exists, err := store.Exists(ctx, key)
-		is.NoError(err)
-		is.True(exists)
-	}
+	require.NoError(t, store.(gokvstores.BloomFilterStore).BFReserve(ctx, "reserved", 100, 0.01))
 
-	results, err := store.GetMaps(ctx, keys)
-	is.NoError(err)
+	_, err = store.(gokvstores.BloomFilterStore).BFAdd(ctx, "bloom", "a")
+	require.NoError(t, err)
 
-	for key, result := range results {
-		is.Equal(result, mapResults[key])
-	}
+	_, err = store.(gokvstores.BitmapStore).SetBit(ctx, "bitmap", 1, true)
+	require.NoError(t, err)
 
-	for key := range mapResults {
-		err = store.Delete(ctx, key)
-		is.NoError(err)
+	_, err = store.(gokvstores.GeoStore).GeoAdd(ctx, "geo", gokvstores.GeoLocation{Member: "a", Longitude: 2.35, Latitude: 48.85})
+	require.NoError(t, err)
 
-		v, _ := store.GetMap(ctx, key)
-		is.Nil(v)
+	return []string{"zset", "list", "stream", "hll", "reserved", "bloom", "bitmap", "geo"}
+}
 
-		exists, err := store.Exists(ctx, key)
-		is.NoError(err)
-		is.False(exists)
-	}
+func TestBaseStore(t *testing.T) {
+	is := assert.New(t)
 
-	is.NoError(store.SetMaps(ctx, mapResults))
-	results, err = store.GetMaps(ctx, keys)
+	store, err := gokvstores.NewMemoryStore(time.Second*10, time.Second*10)
 	is.NoError(err)
-	for key, result := range results {
-		is.Equal(result, mapResults[key])
-		is.NoError(store.Delete(ctx, key))
-	}
-
-	// Slices
-
-	sliceResults := map[string][]interface{}{
-		"key1": {"one", "two", "three", "four"},
-		"key2": {"1", "2", "3", "4"},
-		"key3": {"1.0", "1.1", "1.2", "1.3"},
-	}
-
-	for key, expected := range sliceResults {
-		err = store.SetSlice(ctx, key, expected)
-		is.NoError(err)
-
-		expectedStrings, err := stringSlice(expected)
-		is.NoError(err)
 
-		v, err := store.GetSlice(ctx, key)
-		is.NoError(err)
-		strings, err := stringSlice(v)
-		is.NoError(err)
-		is.Equal(expectedStrings, strings)
+	is.Equal(store, gokvstores.BaseStore(store))
 
-		exists, err := store.Exists(ctx, key)
-		is.NoError(err)
-		is.True(exists)
+	wrapped := gokvstores.NewRetryStore(
+		gokvstores.NewCircuitBreakerStore(
+			gokvstores.NewLoggingStore(
+				gokvstores.NewChaosStore(store, gokvstores.ChaosOptions{}),
+				gokvstores.LoggingOptions{}),
+			gokvstores.CircuitBreakerOptions{}),
+		gokvstores.RetryPolicy{})
+	is.Equal(store, gokvstores.BaseStore(wrapped))
 
-		err = store.AppendSlice(ctx, key, "append1", "append2")
-		is.NoError(err)
+	// Stores without an Unwrap method are not unwrapped.
+	recording := gokvstores.NewRecordingStore(store)
+	is.Equal(recording, gokvstores.BaseStore(gokvstores.NewRetryStore(recording, gokvstores.RetryPolicy{})))
+}
 
-		v, err = store.GetSlice(ctx, key)
-		is.NoError(err)
+func TestBackendName(t *testing.T) {
+	is := assert.New(t)
 
-		expectedStrings = append(expectedStrings, []string{"append1", "append2"}...)
-		sort.Strings(expectedStrings)
-		values, err := stringSlice(v)
-		is.NoError(err)
-		is.Equal(expectedStrings, values)
+	store := newMemoryStore(t)
+	is.Equal(gokvstores.BackendMemory, gokvstores.BackendName(store))
+	is.Equal(gokvstores.BackendDummy, gokvstores.BackendName(gokvstores.DummyStore{}))
 
-		err = store.Delete(ctx, key)
-		is.NoError(err)
+	// Wrapper stores are named after the store they wrap.
+	wrapped := gokvstores.NewRetryStore(
+		gokvstores.NewLoggingStore(store, gokvstores.LoggingOptions{}),
+		gokvstores.RetryPolicy{})
+	is.Equal(gokvstores.BackendMemory, gokvstores.BackendName(wrapped))
 
-		v, _ = store.GetSlice(ctx, key)
-		is.Nil(v)
+	recording := gokvstores.NewRecordingStore(store)
+	is.Equal("*gokvstores.RecordingStore", gokvstores.BackendName(gokvstores.NewRetryStore(recording, gokvstores.RetryPolicy{})))
+}
 
-		exists, err = store.Exists(ctx, key)
-		is.NoError(err)
-		is.False(exists)
+func TestRunScript(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
 
+	store := newMemoryStore(t)
+
+	// Wrapper stores forward scripts to the store they wrap.
+	for _, wrapped := range []gokvstores.KVStore{
+		gokvstores.NewRetryStore(store, gokvstores.RetryPolicy{}),
+		gokvstores.NewCircuitBreakerStore(store, gokvstores.CircuitBreakerOptions{}),
+		gokvstores.NewLoggingStore(store, gokvstores.LoggingOptions{}),
+		gokvstores.NewChaosStore(store, gokvstores.ChaosOptions{}),
+	} {
+		strs, err := wrapped.(gokvstores.ScriptStore).RunScript(ctx, kvstoretest.EchoScript, []string{"key"}, "value").StringSlice()
+		is.NoError(err, "%T", wrapped)
+		is.Equal([]string{"key", "value"}, strs, "%T", wrapped)
 	}
 
-	// Test set with duration
-	expiration := 1
-	err = store.SetWithExpiration(ctx, "foo", "bar", time.Duration(expiration)*time.Second)
-	is.NoError(err)
-
-	v, err := store.Get(ctx, "foo")
-	is.NoError(err)
-	val, ok := v.(string)
-	is.True(ok)
-	is.Equal("bar", val)
-
-	time.Sleep(time.Duration(expiration) * time.Second)
-
-	v, _ = store.Get(ctx, "foo")
-	is.Nil(v)
-
-	exists, err := store.Exists(ctx, "foo")
-	is.NoError(err)
-	is.False(exists)
+	// Scripts fail on stores which do not run them.
+	err := gokvstores.NewRetryStore(gokvstores.DummyStore{}, gokvstores.RetryPolicy{}).RunScript(ctx, kvstoretest.EchoScript, []string{"key"}).Err()
+	is.Error(err)
 
+	var opErr *gokvstores.OpError
+	is.True(errors.As(err, &opErr))
+	is.Equal(gokvstores.OpRunScript, opErr.Op)
+	is.Equal("key", opErr.Key)
 }
@@ -0,0 +1,239 @@
+package kvstoretest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+)
+
+// RunBitmapConformance runs the bitmap conformance suite against stores
+// returned by factory, which must implement gokvstores.BitmapStore.
+func RunBitmapConformance(t *testing.T, factory Factory) {
+	run(t, factory, []testCase{
+		{"SetBit", testSetBit},
+		{"BitCount", testBitCount},
+		{"BitOp", testBitOp},
+		{"BitPos", testBitPos},
+		{"BitmapWrongType", testBitmapWrongType},
+	})
+}
+
+func bitmapStore(t *testing.T, store gokvstores.KVStore) gokvstores.BitmapStore {
+	bstore, ok := store.(gokvstores.BitmapStore)
+	require.True(t, ok, "%T does not implement BitmapStore", store)
+	return bstore
+}
+
+// setBits sets the bits at the given offsets of the bitmap stored at key.
+func setBits(t *testing.T, bstore gokvstores.BitmapStore, key string, offsets ...int64) {
+	for _, offset := range offsets {
+		_, err := bstore.SetBit(context.Background(), key, offset, true)
+		require.NoError(t, err)
+	}
+}
+
+func testSetBit(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	bstore := bitmapStore(t, store)
+
+	bit, err := bstore.GetBit(ctx, "active", 7)
+	is.NoError(err)
+	is.False(bit)
+
+	previous, err := bstore.SetBit(ctx, "active", 7, true)
+	is.NoError(err)
+	is.False(previous)
+
+	previous, err = bstore.SetBit(ctx, "active", 7, true)
+	is.NoError(err)
+	is.True(previous)
+
+	bit, err = bstore.GetBit(ctx, "active", 7)
+	is.NoError(err)
+	is.True(bit)
+
+	for _, offset := range []int64{0, 6, 8, 1000} {
+		bit, err = bstore.GetBit(ctx, "active", offset)
+		is.NoError(err)
+		is.False(bit, offset)
+	}
+
+	// Bitmaps grow as needed.
+	previous, err = bstore.SetBit(ctx, "active", 100000, true)
+	is.NoError(err)
+	is.False(previous)
+
+	bit, err = bstore.GetBit(ctx, "active", 100000)
+	is.NoError(err)
+	is.True(bit)
+
+	previous, err = bstore.SetBit(ctx, "active", 7, false)
+	is.NoError(err)
+	is.True(previous)
+
+	bit, err = bstore.GetBit(ctx, "active", 7)
+	is.NoError(err)
+	is.False(bit)
+
+	// Clearing a bit of a missing bitmap creates it.
+	_, err = bstore.SetBit(ctx, "cleared", 3, false)
+	is.NoError(err)
+
+	exists, err := store.Exists(ctx, "cleared")
+	is.NoError(err)
+	is.True(exists)
+
+	_, err = bstore.SetBit(ctx, "active", -1, true)
+	is.Error(err)
+}
+
+func testBitCount(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	bstore := bitmapStore(t, store)
+
+	count, err := bstore.BitCount(ctx, "bitmap", 0, -1)
+	is.NoError(err)
+	is.Equal(int64(0), count)
+
+	// Bits 0, 1 and 7 of the first byte, 9 of the second one and 31 of the last one.
+	setBits(t, bstore, "bitmap", 0, 1, 7, 9, 31)
+
+	for _, tt := range []struct {
+		start, end int64
+		expected   int64
+	}{
+		{0, -1, 5},
+		{0, 0, 3},
+		{1, 1, 1},
+		{1, 2, 1},
+		{-1, -1, 1},
+		{-2, -1, 1},
+		{2, 100, 1},
+		{-100, 0, 3},
+		{3, 1, 0},
+		{10, 20, 0},
+	} {
+		count, err := bstore.BitCount(ctx, "bitmap", tt.start, tt.end)
+		is.NoError(err)
+		is.Equal(tt.expected, count, "%d %d", tt.start, tt.end)
+	}
+}
+
+func testBitOp(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	bstore := bitmapStore(t, store)
+
+	// Users active on each day.
+	setBits(t, bstore, "{days}:1", 1, 2, 3)
+	setBits(t, bstore, "{days}:2", 2, 3, 4, 20)
+
+	for _, tt := range []struct {
+		op       gokvstores.BitOperation
+		expected []int64
+	}{
+		{gokvstores.BitAnd, []int64{2, 3}},
+		{gokvstores.BitOr, []int64{1, 2, 3, 4, 20}},
+		{gokvstores.BitXor, []int64{1, 4, 20}},
+	} {
+		size, err := bstore.BitOp(ctx, tt.op, "{days}:result", "{days}:1", "{days}:2")
+		is.NoError(err)
+		is.Equal(int64(3), size, tt.op.String())
+
+		count, err := bstore.BitCount(ctx, "{days}:result", 0, -1)
+		is.NoError(err)
+		is.Equal(int64(len(tt.expected)), count, tt.op.String())
+
+		for _, offset := range tt.expected {
+			bit, err := bstore.GetBit(ctx, "{days}:result", offset)
+			is.NoError(err)
+			is.True(bit, "%s %d", tt.op, offset)
+		}
+	}
+
+	// Missing bitmaps are zeros.
+	size, err := bstore.BitOp(ctx, gokvstores.BitAnd, "{days}:result", "{days}:1", "{days}:missing")
+	is.NoError(err)
+	is.Equal(int64(1), size)
+
+	count, err := bstore.BitCount(ctx, "{days}:result", 0, -1)
+	is.NoError(err)
+	is.Equal(int64(0), count)
+
+	size, err = bstore.BitOp(ctx, gokvstores.BitOr, "{days}:result", "{days}:missing")
+	is.NoError(err)
+	is.Equal(int64(0), size)
+
+	exists, err := store.Exists(ctx, "{days}:result")
+	is.NoError(err)
+	is.False(exists)
+
+	_, err = bstore.BitOp(ctx, gokvstores.BitOperation(0), "{days}:result", "{days}:1")
+	is.Error(err)
+}
+
+func testBitPos(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	bstore := bitmapStore(t, store)
+
+	pos, err := bstore.BitPos(ctx, "bitmap", true, 0, -1)
+	is.NoError(err)
+	is.Equal(int64(-1), pos)
+
+	pos, err = bstore.BitPos(ctx, "bitmap", false, 0, -1)
+	is.NoError(err)
+	is.Equal(int64(0), pos)
+
+	// The first byte is all ones and the second one has bits 12 and 15 set.
+	setBits(t, bstore, "bitmap", 0, 1, 2, 3, 4, 5, 6, 7, 12, 15)
+
+	for _, tt := range []struct {
+		value      bool
+		start, end int64
+		expected   int64
+	}{
+		{true, 0, -1, 0},
+		{true, 1, -1, 12},
+		{true, -1, -1, 12},
+		{false, 0, -1, 8},
+		{false, 0, 0, -1},
+		{false, 1, 1, 8},
+		{true, 2, 10, -1},
+		{true, 1, 0, -1},
+	} {
+		pos, err := bstore.BitPos(ctx, "bitmap", tt.value, tt.start, tt.end)
+		is.NoError(err)
+		is.Equal(tt.expected, pos, "%v %d %d", tt.value, tt.start, tt.end)
+	}
+}
+
+func testBitmapWrongType(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	bstore := bitmapStore(t, store)
+
+	is.NoError(store.SetMap(ctx, "map", map[string]interface{}{"field": "value"}))
+
+	_, err := bstore.SetBit(ctx, "map", 1, true)
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, err = bstore.GetBit(ctx, "map", 1)
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, err = bstore.BitCount(ctx, "map", 0, -1)
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, err = bstore.BitPos(ctx, "map", true, 0, -1)
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, err = bstore.BitOp(ctx, gokvstores.BitOr, "dest", "map")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+}
@@ -0,0 +1,263 @@
+package kvstoretest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+)
+
+// RunGeoConformance runs the geospatial index conformance suite against stores
+// returned by factory, which must implement gokvstores.GeoStore.
+//
+// Expected positions and distances are the ones given by Redis: most of them come
+// from the examples of the Redis documentation.
+func RunGeoConformance(t *testing.T, factory Factory) {
+	run(t, factory, []testCase{
+		{"GeoAdd", testGeoAdd},
+		{"GeoDist", testGeoDist},
+		{"GeoSearchRadius", testGeoSearchRadius},
+		{"GeoSearchBox", testGeoSearchBox},
+		{"GeoSearchMember", testGeoSearchMember},
+		{"GeoWrongType", testGeoWrongType},
+	})
+}
+
+func geoStore(t *testing.T, store gokvstores.KVStore) gokvstores.GeoStore {
+	gstore, ok := store.(gokvstores.GeoStore)
+	require.True(t, ok, "%T does not implement GeoStore", store)
+	return gstore
+}
+
+// addSicily indexes the cities of the Redis documentation examples at key.
+func addSicily(t *testing.T, gstore gokvstores.GeoStore, key string) {
+	_, err := gstore.GeoAdd(context.Background(), key,
+		gokvstores.GeoLocation{Member: "Palermo", Longitude: 13.361389, Latitude: 38.115556},
+		gokvstores.GeoLocation{Member: "Catania", Longitude: 15.087269, Latitude: 37.502669},
+		gokvstores.GeoLocation{Member: "edge1", Longitude: 12.758489, Latitude: 38.788135},
+		gokvstores.GeoLocation{Member: "edge2", Longitude: 17.241510, Latitude: 38.788135},
+	)
+	require.NoError(t, err)
+}
+
+var (
+	palermo = gokvstores.GeoPosition{Longitude: 13.36138933897018433, Latitude: 38.11555639549629859}
+	catania = gokvstores.GeoPosition{Longitude: 15.08726745843887329, Latitude: 37.50266842333162032}
+	edge1   = gokvstores.GeoPosition{Longitude: 12.75848776102066040, Latitude: 38.78813451624225195}
+	edge2   = gokvstores.GeoPosition{Longitude: 17.24151045083999634, Latitude: 38.78813451624225195}
+)
+
+func testGeoAdd(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	gstore := geoStore(t, store)
+
+	positions, err := gstore.GeoPos(ctx, "sicily", "Palermo")
+	is.NoError(err)
+	is.Equal([]*gokvstores.GeoPosition{nil}, positions)
+
+	count, err := gstore.GeoAdd(ctx, "sicily",
+		gokvstores.GeoLocation{Member: "Palermo", Longitude: 13.361389, Latitude: 38.115556},
+		gokvstores.GeoLocation{Member: "Catania", Longitude: 15, Latitude: 37},
+	)
+	is.NoError(err)
+	is.Equal(2, count)
+
+	count, err = gstore.GeoAdd(ctx, "sicily", gokvstores.GeoLocation{Member: "Catania", Longitude: 15.087269, Latitude: 37.502669})
+	is.NoError(err)
+	is.Equal(0, count)
+
+	count, err = gstore.GeoAdd(ctx, "sicily")
+	is.NoError(err)
+	is.Equal(0, count)
+
+	// Positions are decoded from their geohash.
+	positions, err = gstore.GeoPos(ctx, "sicily", "Palermo", "missing", "Catania")
+	is.NoError(err)
+	is.Equal([]*gokvstores.GeoPosition{&palermo, nil, &catania}, positions)
+
+	// Indexes are sorted sets.
+	exists, err := store.Exists(ctx, "sicily")
+	is.NoError(err)
+	is.True(exists)
+
+	if zstore, ok := store.(gokvstores.SortedSetStore); ok {
+		count, err := zstore.ZCard(ctx, "sicily")
+		is.NoError(err)
+		is.Equal(2, count)
+	}
+
+	for _, l := range []gokvstores.GeoLocation{
+		{Member: "north", Longitude: 0, Latitude: 86},
+		{Member: "east", Longitude: 181, Latitude: 0},
+	} {
+		_, err = gstore.GeoAdd(ctx, "sicily", l)
+		is.Error(err, l.Member)
+	}
+}
+
+func testGeoDist(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	gstore := geoStore(t, store)
+
+	addSicily(t, gstore, "sicily")
+
+	for unit, expected := range map[gokvstores.GeoUnit]float64{
+		"":                       166274.1516,
+		gokvstores.GeoMeters:     166274.1516,
+		gokvstores.GeoKilometers: 166.2742,
+		gokvstores.GeoMiles:      103.3182,
+		gokvstores.GeoFeet:       545518.8700,
+	} {
+		distance, ok, err := gstore.GeoDist(ctx, "sicily", "Palermo", "Catania", unit)
+		is.NoError(err)
+		is.True(ok)
+		is.Equal(expected, distance, string(unit))
+	}
+
+	distance, ok, err := gstore.GeoDist(ctx, "sicily", "Palermo", "Palermo", gokvstores.GeoMeters)
+	is.NoError(err)
+	is.True(ok)
+	is.Equal(0.0, distance)
+
+	_, ok, err = gstore.GeoDist(ctx, "sicily", "Palermo", "missing", gokvstores.GeoMeters)
+	is.NoError(err)
+	is.False(ok)
+
+	_, ok, err = gstore.GeoDist(ctx, "missing", "Palermo", "Catania", gokvstores.GeoMeters)
+	is.NoError(err)
+	is.False(ok)
+
+	_, _, err = gstore.GeoDist(ctx, "sicily", "Palermo", "Catania", "parsec")
+	is.Error(err)
+}
+
+func testGeoSearchRadius(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	gstore := geoStore(t, store)
+
+	addSicily(t, gstore, "sicily")
+
+	query := gokvstores.GeoSearchQuery{Longitude: 15, Latitude: 37, Radius: 200, Unit: gokvstores.GeoKilometers}
+
+	locations, err := gstore.GeoSearch(ctx, "sicily", query)
+	is.NoError(err)
+	is.Equal([]gokvstores.GeoLocation{
+		{Member: "Catania", Longitude: catania.Longitude, Latitude: catania.Latitude, Dist: 56.4413},
+		{Member: "Palermo", Longitude: palermo.Longitude, Latitude: palermo.Latitude, Dist: 190.4424},
+	}, locations)
+
+	query.Descending = true
+	query.Count = 1
+
+	locations, err = gstore.GeoSearch(ctx, "sicily", query)
+	is.NoError(err)
+	is.Equal([]gokvstores.GeoLocation{
+		{Member: "Palermo", Longitude: palermo.Longitude, Latitude: palermo.Latitude, Dist: 190.4424},
+	}, locations)
+
+	query = gokvstores.GeoSearchQuery{Longitude: 15, Latitude: 37, Radius: 56441}
+
+	locations, err = gstore.GeoSearch(ctx, "sicily", query)
+	is.NoError(err)
+	is.Empty(locations)
+
+	query.Radius = 56442
+
+	locations, err = gstore.GeoSearch(ctx, "sicily", query)
+	is.NoError(err)
+	is.Equal([]gokvstores.GeoLocation{
+		{Member: "Catania", Longitude: catania.Longitude, Latitude: catania.Latitude, Dist: 56441.2579},
+	}, locations)
+
+	locations, err = gstore.GeoSearch(ctx, "missing", query)
+	is.NoError(err)
+	is.Empty(locations)
+
+	query.Radius = -1
+
+	_, err = gstore.GeoSearch(ctx, "sicily", query)
+	is.Error(err)
+}
+
+func testGeoSearchBox(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	gstore := geoStore(t, store)
+
+	addSicily(t, gstore, "sicily")
+
+	query := gokvstores.GeoSearchQuery{Longitude: 15, Latitude: 37, Width: 400, Height: 400, Unit: gokvstores.GeoKilometers}
+
+	locations, err := gstore.GeoSearch(ctx, "sicily", query)
+	is.NoError(err)
+	is.Equal([]gokvstores.GeoLocation{
+		{Member: "Catania", Longitude: catania.Longitude, Latitude: catania.Latitude, Dist: 56.4413},
+		{Member: "Palermo", Longitude: palermo.Longitude, Latitude: palermo.Latitude, Dist: 190.4424},
+		{Member: "edge2", Longitude: edge2.Longitude, Latitude: edge2.Latitude, Dist: 279.7403},
+		{Member: "edge1", Longitude: edge1.Longitude, Latitude: edge1.Latitude, Dist: 279.7405},
+	}, locations)
+
+	// Palermo is within 300 km but more than 100 km north.
+	query.Width, query.Height = 600, 200
+
+	locations, err = gstore.GeoSearch(ctx, "sicily", query)
+	is.NoError(err)
+	is.Equal([]gokvstores.GeoLocation{
+		{Member: "Catania", Longitude: catania.Longitude, Latitude: catania.Latitude, Dist: 56.4413},
+	}, locations)
+
+	query.Width, query.Height = 0, 0
+
+	_, err = gstore.GeoSearch(ctx, "sicily", query)
+	is.Error(err)
+}
+
+func testGeoSearchMember(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	gstore := geoStore(t, store)
+
+	addSicily(t, gstore, "sicily")
+
+	locations, err := gstore.GeoSearch(ctx, "sicily", gokvstores.GeoSearchQuery{
+		Member: "Palermo",
+		Radius: 200,
+		Unit:   gokvstores.GeoKilometers,
+	})
+	is.NoError(err)
+	is.Equal([]gokvstores.GeoLocation{
+		{Member: "Palermo", Longitude: palermo.Longitude, Latitude: palermo.Latitude, Dist: 0},
+		{Member: "edge1", Longitude: edge1.Longitude, Latitude: edge1.Latitude, Dist: 91.4007},
+		{Member: "Catania", Longitude: catania.Longitude, Latitude: catania.Latitude, Dist: 166.2742},
+	}, locations)
+
+	_, err = gstore.GeoSearch(ctx, "sicily", gokvstores.GeoSearchQuery{Member: "missing", Radius: 200})
+	is.True(errors.Is(err, gokvstores.ErrNotFound))
+}
+
+func testGeoWrongType(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	gstore := geoStore(t, store)
+
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	_, err := gstore.GeoAdd(ctx, "key", gokvstores.GeoLocation{Member: "a", Longitude: 1, Latitude: 1})
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, err = gstore.GeoPos(ctx, "key", "a")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, _, err = gstore.GeoDist(ctx, "key", "a", "b", gokvstores.GeoMeters)
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, err = gstore.GeoSearch(ctx, "key", gokvstores.GeoSearchQuery{Longitude: 1, Latitude: 1, Radius: 1})
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+}
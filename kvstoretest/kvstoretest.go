@@ -0,0 +1,555 @@
+// Package kvstoretest provides a conformance test suite for gokvstores.KVStore implementations.
+//
+// Built-in backends are tested with it and custom backends or wrapper stores can use it
+// to check they behave like them:
+//
+//	func TestMyStore(t *testing.T) {
+//		kvstoretest.RunConformance(t, func(t *testing.T) gokvstores.KVStore {
+//			return NewMyStore()
+//		})
+//	}
+package kvstoretest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+)
+
+// Factory returns the store to test.
+// It is called once per test case and the returned store is flushed before use
+// and closed afterwards, so it must not hold data that has to be kept.
+type Factory func(t *testing.T) gokvstores.KVStore
+
+// RunConformance runs the conformance suite against stores returned by factory.
+//
+// Values are only written as strings, since backends are not required to keep
+// the type of stored values.
+func RunConformance(t *testing.T, factory Factory) {
+	run(t, factory, []testCase{
+		{"Get", testGet},
+		{"MGet", testMGet},
+		{"SetWithExpiration", testSetWithExpiration},
+		{"Exists", testExists},
+		{"Delete", testDelete},
+		{"Map", testMap},
+		{"Maps", testMaps},
+		{"Slice", testSlice},
+		{"Keys", testKeys},
+		{"Flush", testFlush},
+		{"NilValues", testNilValues},
+		{"EmptyValues", testEmptyValues},
+		{"UnicodeKeys", testUnicodeKeys},
+		{"LargeValues", testLargeValues},
+		{"ConcurrentAccess", testConcurrentAccess},
+	})
+}
+
+// testCase is a conformance test case.
+type testCase struct {
+	name string
+	fn   func(t *testing.T, store gokvstores.KVStore)
+}
+
+// run runs the given test cases, each against a fresh store returned by factory.
+func run(t *testing.T, factory Factory, tests []testCase) {
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			store := factory(t)
+			require.NotNil(t, store)
+			defer func() {
+				assert.NoError(t, store.Close())
+			}()
+
+			require.NoError(t, store.Flush(context.Background()))
+
+			tt.fn(t, store)
+		})
+	}
+}
+
+func testGet(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	v, err := store.Get(ctx, "missing")
+	is.NoError(err)
+	is.Nil(v)
+
+	items := map[string]string{
+		"key1": "1",
+		"key2": "2",
+		"key3": "3",
+	}
+
+	for key, expected := range items {
+		is.NoError(store.Set(ctx, key, expected))
+
+		v, err := store.Get(ctx, key)
+		is.NoError(err)
+		is.Equal(expected, v)
+	}
+
+	is.NoError(store.Set(ctx, "key1", "overwritten"))
+
+	v, err = store.Get(ctx, "key1")
+	is.NoError(err)
+	is.Equal("overwritten", v)
+}
+
+func testMGet(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	is.NoError(store.Set(ctx, "key1", "1"))
+	is.NoError(store.Set(ctx, "key2", "2"))
+
+	values, err := store.MGet(ctx, []string{"key1", "key2", "missing"})
+	is.NoError(err)
+	is.Len(values, 3)
+	is.Equal("1", values["key1"])
+	is.Equal("2", values["key2"])
+	is.Nil(values["missing"])
+}
+
+func testSetWithExpiration(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	is.NoError(store.SetWithExpiration(ctx, "foo", "bar", 300*time.Millisecond))
+
+	v, err := store.Get(ctx, "foo")
+	is.NoError(err)
+	is.Equal("bar", v)
+
+	time.Sleep(500 * time.Millisecond)
+
+	v, err = store.Get(ctx, "foo")
+	is.NoError(err)
+	is.Nil(v)
+
+	exists, err := store.Exists(ctx, "foo")
+	is.NoError(err)
+	is.False(exists)
+}
+
+func testExists(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	keys := []string{"key1", "key2", "key3"}
+	for _, key := range keys {
+		is.NoError(store.Set(ctx, key, key))
+	}
+
+	exists, err := store.Exists(ctx, "key1")
+	is.NoError(err)
+	is.True(exists)
+
+	exists, err = store.Exists(ctx, "key1", "missing")
+	is.NoError(err)
+	is.False(exists)
+
+	exists, err = store.ExistsAll(ctx, keys...)
+	is.NoError(err)
+	is.True(exists)
+
+	exists, err = store.ExistsAll(ctx, "key1", "missing")
+	is.NoError(err)
+	is.False(exists)
+
+	exists, err = store.ExistsAll(ctx)
+	is.NoError(err)
+	is.False(exists)
+
+	exists, err = store.ExistsAny(ctx, "key1", "missing")
+	is.NoError(err)
+	is.True(exists)
+
+	exists, err = store.ExistsAny(ctx, "missing", "other")
+	is.NoError(err)
+	is.False(exists)
+
+	exists, err = store.ExistsAny(ctx)
+	is.NoError(err)
+	is.False(exists)
+
+	count, err := store.CountExisting(ctx, "key1", "key2", "missing")
+	is.NoError(err)
+	is.Equal(2, count)
+
+	count, err = store.CountExisting(ctx, "key1", "key1")
+	is.NoError(err)
+	is.Equal(2, count)
+
+	count, err = store.CountExisting(ctx)
+	is.NoError(err)
+	is.Equal(0, count)
+}
+
+func testDelete(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	is.NoError(store.Set(ctx, "key1", "1"))
+	is.NoError(store.Delete(ctx, "key1"))
+
+	v, err := store.Get(ctx, "key1")
+	is.NoError(err)
+	is.Nil(v)
+
+	exists, err := store.Exists(ctx, "key1")
+	is.NoError(err)
+	is.False(exists)
+
+	is.NoError(store.Delete(ctx, "missing"))
+}
+
+func testMap(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	v, err := store.GetMap(ctx, "missing")
+	is.NoError(err)
+	is.Nil(v)
+
+	maps := map[string]map[string]interface{}{
+		"key1": {"language": "go"},
+		"key2": {"integer": "1"},
+		"key3": {"float": "20.2"},
+	}
+
+	for key, expected := range maps {
+		is.NoError(store.SetMap(ctx, key, expected))
+
+		v, err := store.GetMap(ctx, key)
+		is.NoError(err)
+		is.Equal(expected, v)
+
+		exists, err := store.Exists(ctx, key)
+		is.NoError(err)
+		is.True(exists)
+
+		is.NoError(store.Delete(ctx, key))
+
+		v, err = store.GetMap(ctx, key)
+		is.NoError(err)
+		is.Nil(v)
+	}
+
+	is.NoError(store.SetMap(ctx, "fields", map[string]interface{}{"a": "1", "b": "2", "c": "3"}))
+	is.NoError(store.DeleteMap(ctx, "fields", "a", "missing"))
+
+	v, err = store.GetMap(ctx, "fields")
+	is.NoError(err)
+	is.Equal(map[string]interface{}{"b": "2", "c": "3"}, v)
+
+	is.NoError(store.DeleteMap(ctx, "fields", "b", "c"))
+
+	v, err = store.GetMap(ctx, "fields")
+	is.NoError(err)
+	is.Nil(v)
+
+	exists, err := store.Exists(ctx, "fields")
+	is.NoError(err)
+	is.False(exists)
+
+	is.NoError(store.DeleteMap(ctx, "missing", "a"))
+
+	exists, err = store.Exists(ctx, "missing")
+	is.NoError(err)
+	is.False(exists)
+}
+
+func testMaps(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	maps := map[string]map[string]interface{}{
+		"key1": {"language": "go"},
+		"key2": {"integer": "1"},
+		"key3": {"float": "20.2"},
+	}
+
+	is.NoError(store.SetMaps(ctx, maps))
+
+	results, err := store.GetMaps(ctx, []string{"key1", "key2", "key3", "missing"})
+	is.NoError(err)
+
+	for key, expected := range maps {
+		is.Equal(expected, results[key])
+	}
+	is.Len(results["missing"], 0)
+}
+
+func testSlice(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	v, err := store.GetSlice(ctx, "missing")
+	is.NoError(err)
+	is.Nil(v)
+
+	slices := map[string][]interface{}{
+		"key1": {"one", "two", "three", "four"},
+		"key2": {"1", "2", "3", "4"},
+		"key3": {"1.0", "1.1", "1.2", "1.3"},
+	}
+
+	for key, expected := range slices {
+		is.NoError(store.SetSlice(ctx, key, expected))
+
+		v, err := store.GetSlice(ctx, key)
+		is.NoError(err)
+		is.Equal(stringSlice(expected), stringSlice(v))
+
+		exists, err := store.Exists(ctx, key)
+		is.NoError(err)
+		is.True(exists)
+
+		is.NoError(store.AppendSlice(ctx, key, "append1", "append2"))
+
+		v, err = store.GetSlice(ctx, key)
+		is.NoError(err)
+		is.Equal(stringSlice(append(expected, "append1", "append2")), stringSlice(v))
+
+		is.NoError(store.Delete(ctx, key))
+
+		v, err = store.GetSlice(ctx, key)
+		is.NoError(err)
+		is.Nil(v)
+	}
+
+	is.NoError(store.AppendSlice(ctx, "created", "one", "two"))
+
+	v, err = store.GetSlice(ctx, "created")
+	is.NoError(err)
+	is.Equal([]string{"one", "two"}, stringSlice(v))
+}
+
+func testKeys(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"order1", "order2", "order3", "other"} {
+		is.NoError(store.Set(ctx, key, key))
+	}
+
+	values, err := store.Keys(ctx, "order*")
+	is.NoError(err)
+	is.Equal([]string{"order1", "order2", "order3"}, stringSlice(values))
+
+	values, err = store.Keys(ctx, "o?her")
+	is.NoError(err)
+	is.Equal([]string{"other"}, stringSlice(values))
+
+	values, err = store.Keys(ctx, "*")
+	is.NoError(err)
+	is.Equal([]string{"order1", "order2", "order3", "other"}, stringSlice(values))
+
+	values, err = store.Keys(ctx, "nomatch*")
+	is.NoError(err)
+	is.Len(values, 0)
+}
+
+func testFlush(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	is.NoError(store.Set(ctx, "key1", "1"))
+	is.NoError(store.SetMap(ctx, "key2", map[string]interface{}{"a": "1"}))
+	is.NoError(store.SetSlice(ctx, "key3", []interface{}{"a"}))
+
+	is.NoError(store.Flush(ctx))
+
+	count, err := store.CountExisting(ctx, "key1", "key2", "key3")
+	is.NoError(err)
+	is.Equal(0, count)
+
+	values, err := store.Keys(ctx, "*")
+	is.NoError(err)
+	is.Len(values, 0)
+}
+
+func testNilValues(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	is.NoError(store.Set(ctx, "nil", nil))
+
+	// nil values are stored as empty strings, so that they can be told from missing keys.
+	v, err := store.Get(ctx, "nil")
+	is.NoError(err)
+	is.Equal("", v)
+
+	values, err := store.MGet(ctx, []string{"nil"})
+	is.NoError(err)
+	is.Equal(map[string]interface{}{"nil": ""}, values)
+
+	exists, err := store.Exists(ctx, "nil")
+	is.NoError(err)
+	is.True(exists)
+
+	is.NoError(store.SetWithExpiration(ctx, "nil", nil, time.Minute))
+
+	v, err = store.Get(ctx, "nil")
+	is.NoError(err)
+	is.Equal("", v)
+
+	is.NoError(store.SetSlice(ctx, "slice", []interface{}{"one", nil, "two"}))
+
+	slice, err := store.GetSlice(ctx, "slice")
+	is.NoError(err)
+	is.Equal([]string{"one", "two"}, stringSlice(slice))
+
+	is.NoError(store.AppendSlice(ctx, "slice", nil, "three"))
+
+	slice, err = store.GetSlice(ctx, "slice")
+	is.NoError(err)
+	is.Equal([]string{"one", "three", "two"}, stringSlice(slice))
+}
+
+func testEmptyValues(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	is.NoError(store.Set(ctx, "empty", ""))
+
+	v, err := store.Get(ctx, "empty")
+	is.NoError(err)
+	is.Equal("", v)
+
+	is.NoError(store.SetMap(ctx, "map", map[string]interface{}{}))
+
+	m, err := store.GetMap(ctx, "map")
+	is.NoError(err)
+	is.Len(m, 0)
+
+	is.NoError(store.SetMaps(ctx, map[string]map[string]interface{}{}))
+
+	maps, err := store.GetMaps(ctx, []string{})
+	is.NoError(err)
+	is.Len(maps, 0)
+
+	is.NoError(store.SetSlice(ctx, "slice", []interface{}{}))
+
+	s, err := store.GetSlice(ctx, "slice")
+	is.NoError(err)
+	is.Len(s, 0)
+
+	values, err := store.MGet(ctx, []string{})
+	is.NoError(err)
+	is.Len(values, 0)
+}
+
+func testUnicodeKeys(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	keys := []string{"🔑:clé", "🔑:ключ", "🔑:鍵"}
+
+	for _, key := range keys {
+		is.NoError(store.Set(ctx, key, "välue-"+key))
+
+		v, err := store.Get(ctx, key)
+		is.NoError(err)
+		is.Equal("välue-"+key, v)
+	}
+
+	is.NoError(store.SetMap(ctx, "🗺", map[string]interface{}{"ñame": "ünïcode"}))
+
+	m, err := store.GetMap(ctx, "🗺")
+	is.NoError(err)
+	is.Equal(map[string]interface{}{"ñame": "ünïcode"}, m)
+
+	values, err := store.Keys(ctx, "🔑:*")
+	is.NoError(err)
+	is.Equal(stringSlice([]interface{}{keys[0], keys[1], keys[2]}), stringSlice(values))
+}
+
+func testLargeValues(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	large := strings.Repeat("0123456789abcdef", 1<<16)
+
+	is.NoError(store.Set(ctx, "large", large))
+
+	v, err := store.Get(ctx, "large")
+	is.NoError(err)
+	is.Equal(large, v)
+
+	is.NoError(store.SetMap(ctx, "map", map[string]interface{}{"large": large}))
+
+	m, err := store.GetMap(ctx, "map")
+	is.NoError(err)
+	is.Equal(map[string]interface{}{"large": large}, m)
+}
+
+func testConcurrentAccess(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	const (
+		workers    = 16
+		iterations = 20
+	)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < iterations; j++ {
+				key := fmt.Sprintf("concurrent:%d:%d", i, j)
+				value := fmt.Sprintf("%d-%d", i, j)
+
+				is.NoError(store.Set(ctx, key, value))
+
+				v, err := store.Get(ctx, key)
+				is.NoError(err)
+				is.Equal(value, v)
+
+				is.NoError(store.AppendSlice(ctx, "concurrent:slice", value))
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	v, err := store.GetSlice(ctx, "concurrent:slice")
+	is.NoError(err)
+	is.Len(stringSlice(v), workers*iterations)
+
+	count, err := store.CountExisting(ctx, "concurrent:0:0", fmt.Sprintf("concurrent:%d:%d", workers-1, iterations-1))
+	is.NoError(err)
+	is.Equal(2, count)
+}
+
+// stringSlice returns the sorted string values of the given slice, skipping other values.
+func stringSlice(values []interface{}) []string {
+	converted := []string{}
+
+	for _, v := range values {
+		if val, ok := v.(string); ok {
+			converted = append(converted, val)
+		}
+	}
+
+	sort.Strings(converted)
+
+	return converted
+}
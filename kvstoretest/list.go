@@ -0,0 +1,240 @@
+package kvstoretest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+)
+
+// RunListConformance runs the list conformance suite against stores
+// returned by factory, which must implement gokvstores.ListStore.
+//
+// Blocking pops wait at least a second, since Redis timeouts have a one second resolution.
+func RunListConformance(t *testing.T, factory Factory) {
+	run(t, factory, []testCase{
+		{"Push", testPush},
+		{"Pop", testPop},
+		{"Range", testRange},
+		{"BlockingPop", testBlockingPop},
+		{"BlockingPopWait", testBlockingPopWait},
+		{"BlockingPopTimeout", testBlockingPopTimeout},
+		{"BlockingPopContext", testBlockingPopContext},
+		{"ListWrongType", testListWrongType},
+	})
+}
+
+func listStore(t *testing.T, store gokvstores.KVStore) gokvstores.ListStore {
+	lstore, ok := store.(gokvstores.ListStore)
+	require.True(t, ok, "%T does not implement ListStore", store)
+	return lstore
+}
+
+func testPush(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	lstore := listStore(t, store)
+
+	length, err := lstore.PushRight(ctx, "list")
+	is.NoError(err)
+	is.Equal(0, length)
+
+	length, err = lstore.PushRight(ctx, "list", "c", "d")
+	is.NoError(err)
+	is.Equal(2, length)
+
+	length, err = lstore.PushLeft(ctx, "list", "b", "a")
+	is.NoError(err)
+	is.Equal(4, length)
+
+	length, err = lstore.Len(ctx, "list")
+	is.NoError(err)
+	is.Equal(4, length)
+
+	values, err := lstore.Range(ctx, "list", 0, -1)
+	is.NoError(err)
+	is.Equal([]string{"a", "b", "c", "d"}, values)
+
+	exists, err := store.Exists(ctx, "list")
+	is.NoError(err)
+	is.True(exists)
+}
+
+func testPop(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	lstore := listStore(t, store)
+
+	_, ok, err := lstore.PopLeft(ctx, "queue")
+	is.NoError(err)
+	is.False(ok)
+
+	_, err = lstore.PushRight(ctx, "queue", "1", "2", "3")
+	is.NoError(err)
+
+	value, ok, err := lstore.PopLeft(ctx, "queue")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal("1", value)
+
+	value, ok, err = lstore.PopRight(ctx, "queue")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal("3", value)
+
+	value, ok, err = lstore.PopRight(ctx, "queue")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal("2", value)
+
+	_, ok, err = lstore.PopRight(ctx, "queue")
+	is.NoError(err)
+	is.False(ok)
+
+	length, err := lstore.Len(ctx, "queue")
+	is.NoError(err)
+	is.Equal(0, length)
+
+	exists, err := store.Exists(ctx, "queue")
+	is.NoError(err)
+	is.False(exists)
+}
+
+func testRange(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	lstore := listStore(t, store)
+
+	values, err := lstore.Range(ctx, "list", 0, -1)
+	is.NoError(err)
+	is.Empty(values)
+
+	// Many values, so that the memory deque wraps around.
+	for i := 0; i < 10; i++ {
+		_, err = lstore.PushLeft(ctx, "list", string(rune('a'+i)))
+		is.NoError(err)
+	}
+
+	values, err = lstore.Range(ctx, "list", 0, 2)
+	is.NoError(err)
+	is.Equal([]string{"j", "i", "h"}, values)
+
+	values, err = lstore.Range(ctx, "list", -2, -1)
+	is.NoError(err)
+	is.Equal([]string{"b", "a"}, values)
+
+	values, err = lstore.Range(ctx, "list", 8, 100)
+	is.NoError(err)
+	is.Equal([]string{"b", "a"}, values)
+
+	values, err = lstore.Range(ctx, "list", 2, 1)
+	is.NoError(err)
+	is.Empty(values)
+}
+
+func testBlockingPop(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	lstore := listStore(t, store)
+
+	_, err := lstore.PushRight(ctx, "{queue}2", "a", "b")
+	is.NoError(err)
+
+	key, value, err := lstore.BlockingPop(ctx, time.Second, "{queue}1", "{queue}2")
+	is.NoError(err)
+	is.Equal("{queue}2", key)
+	is.Equal("a", value)
+
+	_, err = lstore.PushRight(ctx, "{queue}1", "c")
+	is.NoError(err)
+
+	key, value, err = lstore.BlockingPop(ctx, time.Second, "{queue}1", "{queue}2")
+	is.NoError(err)
+	is.Equal("{queue}1", key)
+	is.Equal("c", value)
+}
+
+func testBlockingPopWait(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	lstore := listStore(t, store)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		lstore.PushRight(ctx, "jobs", "job")
+	}()
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	key, value, err := lstore.BlockingPop(waitCtx, 0, "jobs")
+	is.NoError(err)
+	is.Equal("jobs", key)
+	is.Equal("job", value)
+}
+
+func testBlockingPopTimeout(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	lstore := listStore(t, store)
+
+	start := time.Now()
+
+	key, value, err := lstore.BlockingPop(ctx, time.Second, "jobs")
+	is.NoError(err)
+	is.Empty(key)
+	is.Empty(value)
+	is.True(time.Since(start) >= time.Second)
+}
+
+func testBlockingPopContext(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	lstore := listStore(t, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, _, err := lstore.BlockingPop(ctx, 0, "jobs")
+	is.True(errors.Is(err, context.Canceled))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, _, err = lstore.BlockingPop(ctx, 0, "jobs")
+	is.True(errors.Is(err, context.DeadlineExceeded))
+	is.True(errors.Is(err, gokvstores.ErrTimeout))
+
+	// The store is still usable after a cancelled pop.
+	_, err = lstore.PushRight(context.Background(), "jobs", "job")
+	is.NoError(err)
+
+	value, ok, err := lstore.PopLeft(context.Background(), "jobs")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal("job", value)
+}
+
+func testListWrongType(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	lstore := listStore(t, store)
+
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	_, err := lstore.PushRight(ctx, "key", "a")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, _, err = lstore.PopLeft(ctx, "key")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, _, err = lstore.BlockingPop(ctx, time.Second, "key")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, err = lstore.Range(ctx, "key", 0, -1)
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+}
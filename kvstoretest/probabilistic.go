@@ -0,0 +1,273 @@
+package kvstoretest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+)
+
+// RunProbabilisticConformance runs the HyperLogLog and Bloom filter conformance suite against
+// stores returned by factory, which must implement gokvstores.HyperLogLogStore and
+// gokvstores.BloomFilterStore.
+func RunProbabilisticConformance(t *testing.T, factory Factory) {
+	run(t, factory, []testCase{
+		{"PFAdd", testPFAdd},
+		{"PFCountAccuracy", testPFCountAccuracy},
+		{"PFMerge", testPFMerge},
+		{"BFAdd", testBFAdd},
+		{"BFReserve", testBFReserve},
+		{"BFErrorRate", testBFErrorRate},
+		{"ProbabilisticWrongType", testProbabilisticWrongType},
+	})
+}
+
+func hyperLogLogStore(t *testing.T, store gokvstores.KVStore) gokvstores.HyperLogLogStore {
+	hstore, ok := store.(gokvstores.HyperLogLogStore)
+	require.True(t, ok, "%T does not implement HyperLogLogStore", store)
+	return hstore
+}
+
+func bloomFilterStore(t *testing.T, store gokvstores.KVStore) gokvstores.BloomFilterStore {
+	bstore, ok := store.(gokvstores.BloomFilterStore)
+	require.True(t, ok, "%T does not implement BloomFilterStore", store)
+	return bstore
+}
+
+func testPFAdd(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	hstore := hyperLogLogStore(t, store)
+
+	count, err := hstore.PFCount(ctx, "visitors")
+	is.NoError(err)
+	is.Equal(int64(0), count)
+
+	changed, err := hstore.PFAdd(ctx, "visitors", "a", "b", "c")
+	is.NoError(err)
+	is.True(changed)
+
+	changed, err = hstore.PFAdd(ctx, "visitors", "a", "b")
+	is.NoError(err)
+	is.False(changed)
+
+	changed, err = hstore.PFAdd(ctx, "visitors")
+	is.NoError(err)
+	is.False(changed)
+
+	count, err = hstore.PFCount(ctx, "visitors")
+	is.NoError(err)
+	is.Equal(int64(3), count)
+
+	// Creating an empty HyperLogLog is a change.
+	changed, err = hstore.PFAdd(ctx, "empty")
+	is.NoError(err)
+	is.True(changed)
+
+	exists, err := store.Exists(ctx, "empty")
+	is.NoError(err)
+	is.True(exists)
+
+	count, err = hstore.PFCount(ctx, "empty", "missing")
+	is.NoError(err)
+	is.Equal(int64(0), count)
+}
+
+func testPFCountAccuracy(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	hstore := hyperLogLogStore(t, store)
+
+	const total = 20000
+
+	elements := make([]string, 0, 1000)
+	for i := 0; i < total; i++ {
+		elements = append(elements, fmt.Sprintf("element:%d", i))
+
+		if len(elements) == cap(elements) {
+			_, err := hstore.PFAdd(ctx, "hll", elements...)
+			require.NoError(t, err)
+
+			elements = elements[:0]
+		}
+	}
+
+	count, err := hstore.PFCount(ctx, "hll")
+	is.NoError(err)
+	is.InEpsilon(total, count, 0.03)
+
+	// Elements added again do not change the estimate.
+	_, err = hstore.PFAdd(ctx, "hll", "element:1", "element:2")
+	is.NoError(err)
+
+	again, err := hstore.PFCount(ctx, "hll")
+	is.NoError(err)
+	is.Equal(count, again)
+}
+
+func testPFMerge(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	hstore := hyperLogLogStore(t, store)
+
+	for i := 0; i < 100; i++ {
+		_, err := hstore.PFAdd(ctx, "{hll}:a", fmt.Sprintf("a:%d", i), fmt.Sprintf("shared:%d", i))
+		require.NoError(t, err)
+
+		_, err = hstore.PFAdd(ctx, "{hll}:b", fmt.Sprintf("b:%d", i), fmt.Sprintf("shared:%d", i))
+		require.NoError(t, err)
+	}
+
+	union, err := hstore.PFCount(ctx, "{hll}:a", "{hll}:b", "{hll}:missing")
+	is.NoError(err)
+	is.InEpsilon(300, union, 0.03)
+
+	is.NoError(hstore.PFMerge(ctx, "{hll}:union", "{hll}:a", "{hll}:b", "{hll}:missing"))
+
+	count, err := hstore.PFCount(ctx, "{hll}:union")
+	is.NoError(err)
+	is.Equal(union, count)
+
+	// Sources are unchanged and dest is merged too.
+	count, err = hstore.PFCount(ctx, "{hll}:a")
+	is.NoError(err)
+	is.InEpsilon(200, count, 0.03)
+
+	is.NoError(hstore.PFMerge(ctx, "{hll}:a", "{hll}:union"))
+
+	count, err = hstore.PFCount(ctx, "{hll}:a")
+	is.NoError(err)
+	is.Equal(union, count)
+
+	// Merging no keys creates an empty dest.
+	is.NoError(hstore.PFMerge(ctx, "{hll}:empty"))
+
+	exists, err := store.Exists(ctx, "{hll}:empty")
+	is.NoError(err)
+	is.True(exists)
+}
+
+func testBFAdd(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	bstore := bloomFilterStore(t, store)
+
+	exists, err := bstore.BFExists(ctx, "bloom", "a")
+	is.NoError(err)
+	is.False(exists)
+
+	added, err := bstore.BFAdd(ctx, "bloom", "a")
+	is.NoError(err)
+	is.True(added)
+
+	added, err = bstore.BFAdd(ctx, "bloom", "a")
+	is.NoError(err)
+	is.False(added)
+
+	exists, err = bstore.BFExists(ctx, "bloom", "a")
+	is.NoError(err)
+	is.True(exists)
+
+	exists, err = bstore.BFExists(ctx, "bloom", "b")
+	is.NoError(err)
+	is.False(exists)
+}
+
+func testBFReserve(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	bstore := bloomFilterStore(t, store)
+
+	is.Error(bstore.BFReserve(ctx, "bloom", 0, 0.01))
+	is.Error(bstore.BFReserve(ctx, "bloom", 100, 0))
+	is.Error(bstore.BFReserve(ctx, "bloom", 100, 1))
+
+	exists, err := store.Exists(ctx, "bloom")
+	is.NoError(err)
+	is.False(exists)
+
+	is.NoError(bstore.BFReserve(ctx, "bloom", 1000, 0.001))
+
+	exists, err = store.Exists(ctx, "bloom")
+	is.NoError(err)
+	is.True(exists)
+
+	added, err := bstore.BFAdd(ctx, "bloom", "a")
+	is.NoError(err)
+	is.True(added)
+
+	// Reserving an existing filter keeps it.
+	is.NoError(bstore.BFReserve(ctx, "bloom", 10, 0.1))
+
+	exists, err = bstore.BFExists(ctx, "bloom", "a")
+	is.NoError(err)
+	is.True(exists)
+}
+
+func testBFErrorRate(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	bstore := bloomFilterStore(t, store)
+
+	const capacity = 1000
+
+	is.NoError(bstore.BFReserve(ctx, "bloom", capacity, 0.01))
+
+	for i := 0; i < capacity; i++ {
+		_, err := bstore.BFAdd(ctx, "bloom", fmt.Sprintf("item:%d", i))
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < capacity; i++ {
+		exists, err := bstore.BFExists(ctx, "bloom", fmt.Sprintf("item:%d", i))
+		require.NoError(t, err)
+		is.True(exists, "item:%d", i)
+	}
+
+	positives := 0
+	for i := 0; i < capacity; i++ {
+		exists, err := bstore.BFExists(ctx, "bloom", fmt.Sprintf("other:%d", i))
+		require.NoError(t, err)
+
+		if exists {
+			positives++
+		}
+	}
+
+	is.LessOrEqual(positives, 30, "false positives")
+}
+
+func testProbabilisticWrongType(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	hstore := hyperLogLogStore(t, store)
+	bstore := bloomFilterStore(t, store)
+
+	is.NoError(store.Set(ctx, "key", "value"))
+	is.NoError(store.SetMap(ctx, "map", map[string]interface{}{"field": "value"}))
+
+	_, err := hstore.PFAdd(ctx, "key", "a")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, err = hstore.PFCount(ctx, "key")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	for _, key := range []string{"key", "map"} {
+		_, err = bstore.BFAdd(ctx, key, "a")
+		is.True(errors.Is(err, gokvstores.ErrWrongType), key)
+
+		_, err = bstore.BFExists(ctx, key, "a")
+		is.True(errors.Is(err, gokvstores.ErrWrongType), key)
+	}
+
+	_, err = hstore.PFAdd(ctx, "hll", "a")
+	is.NoError(err)
+
+	_, err = bstore.BFAdd(ctx, "hll", "a")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+}
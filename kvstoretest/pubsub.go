@@ -0,0 +1,156 @@
+package kvstoretest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+)
+
+// RunPubSubConformance runs the Pub/Sub conformance suite against stores
+// returned by factory, which must implement gokvstores.PubSubStore.
+func RunPubSubConformance(t *testing.T, factory Factory) {
+	run(t, factory, []testCase{
+		{"Subscribe", testSubscribe},
+		{"PSubscribe", testPSubscribe},
+		{"Subscribers", testSubscribers},
+		{"Unsubscribe", testUnsubscribe},
+		{"NoChannels", testNoChannels},
+	})
+}
+
+func pubSubStore(t *testing.T, store gokvstores.KVStore) gokvstores.PubSubStore {
+	pstore, ok := store.(gokvstores.PubSubStore)
+	require.True(t, ok, "%T does not implement PubSubStore", store)
+	return pstore
+}
+
+// receive returns the next message received on messages, failing the test
+// if none is received within a few seconds.
+func receive(t *testing.T, messages <-chan gokvstores.Message) gokvstores.Message {
+	t.Helper()
+
+	select {
+	case msg, ok := <-messages:
+		require.True(t, ok, "subscription closed")
+		return msg
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no message received")
+		return gokvstores.Message{}
+	}
+}
+
+func testSubscribe(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pstore := pubSubStore(t, store)
+
+	messages, err := pstore.Subscribe(ctx, "news", "sports")
+	require.NoError(t, err)
+
+	count, err := pstore.Publish(ctx, "weather", "sunny")
+	is.NoError(err)
+	is.Equal(0, count)
+
+	count, err = pstore.Publish(ctx, "news", "hello")
+	is.NoError(err)
+	is.Equal(1, count)
+
+	count, err = pstore.Publish(ctx, "sports", "goal")
+	is.NoError(err)
+	is.Equal(1, count)
+
+	is.Equal(gokvstores.Message{Channel: "news", Payload: "hello"}, receive(t, messages))
+	is.Equal(gokvstores.Message{Channel: "sports", Payload: "goal"}, receive(t, messages))
+}
+
+func testPSubscribe(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pstore := pubSubStore(t, store)
+
+	messages, err := pstore.PSubscribe(ctx, "user:*", "h?llo", "item:[ab]")
+	require.NoError(t, err)
+
+	for _, channel := range []string{"users", "hllo", "item:c"} {
+		count, err := pstore.Publish(ctx, channel, "ignored")
+		is.NoError(err)
+		is.Equal(0, count, channel)
+	}
+
+	count, err := pstore.Publish(ctx, "user:1", "updated")
+	is.NoError(err)
+	is.Equal(1, count)
+
+	is.Equal(gokvstores.Message{Channel: "user:1", Pattern: "user:*", Payload: "updated"}, receive(t, messages))
+
+	_, err = pstore.Publish(ctx, "hallo", "hi")
+	is.NoError(err)
+	is.Equal(gokvstores.Message{Channel: "hallo", Pattern: "h?llo", Payload: "hi"}, receive(t, messages))
+
+	_, err = pstore.Publish(ctx, "item:b", "sold")
+	is.NoError(err)
+	is.Equal(gokvstores.Message{Channel: "item:b", Pattern: "item:[ab]", Payload: "sold"}, receive(t, messages))
+}
+
+func testSubscribers(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pstore := pubSubStore(t, store)
+
+	messages1, err := pstore.Subscribe(ctx, "invalidations")
+	require.NoError(t, err)
+
+	messages2, err := pstore.PSubscribe(ctx, "invalid*")
+	require.NoError(t, err)
+
+	count, err := pstore.Publish(ctx, "invalidations", "key")
+	is.NoError(err)
+	is.Equal(2, count)
+
+	is.Equal("key", receive(t, messages1).Payload)
+	is.Equal("key", receive(t, messages2).Payload)
+}
+
+func testUnsubscribe(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	pstore := pubSubStore(t, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	messages, err := pstore.Subscribe(ctx, "news")
+	require.NoError(t, err)
+
+	cancel()
+
+	select {
+	case _, ok := <-messages:
+		is.False(ok)
+	case <-time.After(5 * time.Second):
+		is.Fail("subscription not closed")
+	}
+
+	is.Eventually(func() bool {
+		count, err := pstore.Publish(context.Background(), "news", "hello")
+		return err == nil && count == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func testNoChannels(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	pstore := pubSubStore(t, store)
+
+	_, err := pstore.Subscribe(ctx)
+	is.Error(err)
+
+	_, err = pstore.PSubscribe(ctx)
+	is.Error(err)
+}
@@ -0,0 +1,240 @@
+package kvstoretest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+)
+
+// Scripts run by RunScriptConformance.
+var (
+	// CompareAndSwapScript sets KEYS[1] to ARGV[2] and returns 1 if its value is ARGV[1],
+	// an empty ARGV[1] matching a missing key, or else returns 0.
+	CompareAndSwapScript = gokvstores.NewScript(`
+local value = redis.call('GET', KEYS[1]) or ''
+if value ~= ARGV[1] then
+	return 0
+end
+
+redis.call('SET', KEYS[1], ARGV[2])
+return 1
+`)
+
+	// GetScript returns the value of KEYS[1], or false if it is missing.
+	GetScript = gokvstores.NewScript(`return redis.call('GET', KEYS[1])`)
+
+	// EchoScript returns its keys followed by its arguments.
+	EchoScript = gokvstores.NewScript(`
+local values = {}
+for _, key in ipairs(KEYS) do
+	table.insert(values, key)
+end
+for _, arg in ipairs(ARGV) do
+	table.insert(values, arg)
+end
+return values
+`)
+)
+
+// ScriptFuncs are the Go equivalents of the scripts run by RunScriptConformance,
+// to register on stores which cannot run Lua, e.g. with MemoryStore.RegisterScript.
+var ScriptFuncs = map[*gokvstores.Script]gokvstores.ScriptFunc{
+	CompareAndSwapScript: func(ctx context.Context, store gokvstores.KVStore, keys []string, args []string) (interface{}, error) {
+		value, err := store.Get(ctx, keys[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if s, _ := value.(string); s != args[0] {
+			return 0, nil
+		}
+
+		return 1, store.Set(ctx, keys[0], args[1])
+	},
+	GetScript: func(ctx context.Context, store gokvstores.KVStore, keys []string, args []string) (interface{}, error) {
+		value, err := store.Get(ctx, keys[0])
+		if value == nil || err != nil {
+			return false, err
+		}
+
+		return value, nil
+	},
+	EchoScript: func(ctx context.Context, store gokvstores.KVStore, keys []string, args []string) (interface{}, error) {
+		return append(append([]string{}, keys...), args...), nil
+	},
+}
+
+// RunScriptConformance runs the scripting conformance suite against stores returned
+// by factory, which must implement gokvstores.ScriptStore and run the scripts of this
+// package: stores which cannot run Lua must have the ScriptFuncs registered.
+func RunScriptConformance(t *testing.T, factory Factory) {
+	run(t, factory, []testCase{
+		{"CompareAndSwap", testScriptCompareAndSwap},
+		{"Results", testScriptResults},
+		{"Arguments", testScriptArguments},
+		{"ConcurrentRuns", testScriptConcurrentRuns},
+	})
+}
+
+func scriptStore(t *testing.T, store gokvstores.KVStore) gokvstores.ScriptStore {
+	sstore, ok := store.(gokvstores.ScriptStore)
+	require.True(t, ok, "%T does not implement ScriptStore", store)
+	return sstore
+}
+
+func testScriptCompareAndSwap(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	sstore := scriptStore(t, store)
+
+	swapped, err := sstore.RunScript(ctx, CompareAndSwapScript, []string{"key"}, "old", "new").Bool()
+	is.NoError(err)
+	is.False(swapped)
+
+	swapped, err = sstore.RunScript(ctx, CompareAndSwapScript, []string{"key"}, "", "old").Bool()
+	is.NoError(err)
+	is.True(swapped)
+
+	result := sstore.RunScript(ctx, CompareAndSwapScript, []string{"key"}, "old", "new")
+	is.NoError(result.Err())
+	is.Equal(int64(1), result.Val())
+
+	v, err := store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("new", v)
+
+	swapped, err = sstore.RunScript(ctx, CompareAndSwapScript, []string{"key"}, "old", "newer").Bool()
+	is.NoError(err)
+	is.False(swapped)
+
+	v, err = store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("new", v)
+}
+
+func testScriptResults(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	sstore := scriptStore(t, store)
+
+	// Lua false is nil.
+	v, err := sstore.RunScript(ctx, GetScript, []string{"key"}).Result()
+	is.NoError(err)
+	is.Nil(v)
+
+	_, err = sstore.RunScript(ctx, GetScript, []string{"key"}).Text()
+	is.True(errors.Is(err, gokvstores.ErrNotFound))
+
+	ok, err := sstore.RunScript(ctx, GetScript, []string{"key"}).Bool()
+	is.NoError(err)
+	is.False(ok)
+
+	is.NoError(store.Set(ctx, "key", "42"))
+
+	text, err := sstore.RunScript(ctx, GetScript, []string{"key"}).Text()
+	is.NoError(err)
+	is.Equal("42", text)
+
+	n, err := sstore.RunScript(ctx, GetScript, []string{"key"}).Int64()
+	is.NoError(err)
+	is.Equal(int64(42), n)
+
+	is.NoError(store.Set(ctx, "key", "1.5"))
+
+	f, err := sstore.RunScript(ctx, GetScript, []string{"key"}).Float64()
+	is.NoError(err)
+	is.Equal(1.5, f)
+
+	_, err = sstore.RunScript(ctx, GetScript, []string{"key"}).Int64()
+	is.Error(err)
+
+	_, err = sstore.RunScript(ctx, GetScript, []string{"key"}).Slice()
+	is.Error(err)
+
+	values, err := sstore.RunScript(ctx, EchoScript, []string{"a", "b"}, "c").Slice()
+	is.NoError(err)
+	is.Equal([]interface{}{"a", "b", "c"}, values)
+
+	strs, err := sstore.RunScript(ctx, EchoScript, nil).StringSlice()
+	is.NoError(err)
+	is.Empty(strs)
+
+	ints, err := sstore.RunScript(ctx, EchoScript, nil, 1, "2", -3).Int64Slice()
+	is.NoError(err)
+	is.Equal([]int64{1, 2, -3}, ints)
+
+	_, err = sstore.RunScript(ctx, EchoScript, nil, "a").Int64Slice()
+	is.Error(err)
+}
+
+func testScriptArguments(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	sstore := scriptStore(t, store)
+
+	// Arguments are formatted as command arguments.
+	strs, err := sstore.RunScript(ctx, EchoScript, []string{"key"}, "a", []byte("b"), 42, int64(-1), uint8(7), 1.5, true, false).StringSlice()
+	is.NoError(err)
+	is.Equal([]string{"key", "a", "b", "42", "-1", "7", "1.5", "1", "0"}, strs)
+
+	err = sstore.RunScript(ctx, EchoScript, []string{"key"}, struct{}{}).Err()
+	is.Error(err)
+}
+
+func testScriptConcurrentRuns(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	sstore := scriptStore(t, store)
+
+	const workers = 10
+
+	values := make([]string, workers+1)
+	for i := range values {
+		values[i] = string(rune('a' + i))
+	}
+
+	is.NoError(store.Set(ctx, "key", values[0]))
+
+	// Each value is swapped by a single worker.
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		swapped = map[int]int{}
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < workers; i++ {
+				ok, err := sstore.RunScript(ctx, CompareAndSwapScript, []string{"key"}, values[i], values[i+1]).Bool()
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				if ok {
+					mu.Lock()
+					swapped[i]++
+					mu.Unlock()
+				}
+			}
+		}(w)
+	}
+
+	wg.Wait()
+
+	for i := 0; i < workers; i++ {
+		is.Equal(1, swapped[i], values[i])
+	}
+
+	v, err := store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal(values[workers], v)
+}
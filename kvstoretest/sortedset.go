@@ -0,0 +1,201 @@
+package kvstoretest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+)
+
+// RunSortedSetConformance runs the sorted set conformance suite against stores
+// returned by factory, which must implement gokvstores.SortedSetStore.
+func RunSortedSetConformance(t *testing.T, factory Factory) {
+	run(t, factory, []testCase{
+		{"ZAdd", testZAdd},
+		{"ZIncrBy", testZIncrBy},
+		{"ZRangeByScore", testZRangeByScore},
+		{"ZRevRange", testZRevRange},
+		{"ZRem", testZRem},
+		{"ZWrongType", testZWrongType},
+	})
+}
+
+func sortedSetStore(t *testing.T, store gokvstores.KVStore) gokvstores.SortedSetStore {
+	zstore, ok := store.(gokvstores.SortedSetStore)
+	require.True(t, ok, "%T does not implement SortedSetStore", store)
+	return zstore
+}
+
+func testZAdd(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	zstore := sortedSetStore(t, store)
+
+	count, err := zstore.ZCard(ctx, "zset")
+	is.NoError(err)
+	is.Equal(0, count)
+
+	count, err = zstore.ZAdd(ctx, "zset", gokvstores.Z{Member: "a", Score: 1}, gokvstores.Z{Member: "b", Score: 2})
+	is.NoError(err)
+	is.Equal(2, count)
+
+	count, err = zstore.ZAdd(ctx, "zset", gokvstores.Z{Member: "a", Score: 3}, gokvstores.Z{Member: "c", Score: 0})
+	is.NoError(err)
+	is.Equal(1, count)
+
+	count, err = zstore.ZAdd(ctx, "zset")
+	is.NoError(err)
+	is.Equal(0, count)
+
+	count, err = zstore.ZCard(ctx, "zset")
+	is.NoError(err)
+	is.Equal(3, count)
+
+	for member, expected := range map[string]int{"c": 0, "b": 1, "a": 2, "missing": -1} {
+		rank, err := zstore.ZRank(ctx, "zset", member)
+		is.NoError(err)
+		is.Equal(expected, rank, member)
+	}
+
+	rank, err := zstore.ZRank(ctx, "missing", "a")
+	is.NoError(err)
+	is.Equal(-1, rank)
+
+	exists, err := store.Exists(ctx, "zset")
+	is.NoError(err)
+	is.True(exists)
+}
+
+func testZIncrBy(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	zstore := sortedSetStore(t, store)
+
+	score, err := zstore.ZIncrBy(ctx, "scores", 2.5, "player")
+	is.NoError(err)
+	is.Equal(2.5, score)
+
+	score, err = zstore.ZIncrBy(ctx, "scores", -1, "player")
+	is.NoError(err)
+	is.Equal(1.5, score)
+
+	values, err := zstore.ZRevRange(ctx, "scores", 0, -1)
+	is.NoError(err)
+	is.Equal([]gokvstores.Z{{Member: "player", Score: 1.5}}, values)
+}
+
+func testZRangeByScore(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	zstore := sortedSetStore(t, store)
+
+	values, err := zstore.ZRangeByScore(ctx, "feed", 0, 10, 0, 0)
+	is.NoError(err)
+	is.Empty(values)
+
+	_, err = zstore.ZAdd(ctx, "feed",
+		gokvstores.Z{Member: "e", Score: 5},
+		gokvstores.Z{Member: "a", Score: 1},
+		gokvstores.Z{Member: "c", Score: 3},
+		gokvstores.Z{Member: "b", Score: 2},
+		gokvstores.Z{Member: "d", Score: 3},
+	)
+	is.NoError(err)
+
+	values, err = zstore.ZRangeByScore(ctx, "feed", 2, 3, 0, 0)
+	is.NoError(err)
+	is.Equal([]gokvstores.Z{{Member: "b", Score: 2}, {Member: "c", Score: 3}, {Member: "d", Score: 3}}, values)
+
+	values, err = zstore.ZRangeByScore(ctx, "feed", math.Inf(-1), math.Inf(1), 1, 2)
+	is.NoError(err)
+	is.Equal([]gokvstores.Z{{Member: "b", Score: 2}, {Member: "c", Score: 3}}, values)
+
+	values, err = zstore.ZRangeByScore(ctx, "feed", 4, math.Inf(1), 0, 10)
+	is.NoError(err)
+	is.Equal([]gokvstores.Z{{Member: "e", Score: 5}}, values)
+
+	values, err = zstore.ZRangeByScore(ctx, "feed", 0, 10, 5, 0)
+	is.NoError(err)
+	is.Empty(values)
+}
+
+func testZRevRange(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	zstore := sortedSetStore(t, store)
+
+	values, err := zstore.ZRevRange(ctx, "leaderboard", 0, -1)
+	is.NoError(err)
+	is.Empty(values)
+
+	_, err = zstore.ZAdd(ctx, "leaderboard",
+		gokvstores.Z{Member: "alice", Score: 30},
+		gokvstores.Z{Member: "bob", Score: 10},
+		gokvstores.Z{Member: "carol", Score: 20},
+		gokvstores.Z{Member: "dave", Score: 40},
+	)
+	is.NoError(err)
+
+	values, err = zstore.ZRevRange(ctx, "leaderboard", 0, 2)
+	is.NoError(err)
+	is.Equal([]gokvstores.Z{{Member: "dave", Score: 40}, {Member: "alice", Score: 30}, {Member: "carol", Score: 20}}, values)
+
+	values, err = zstore.ZRevRange(ctx, "leaderboard", -2, -1)
+	is.NoError(err)
+	is.Equal([]gokvstores.Z{{Member: "carol", Score: 20}, {Member: "bob", Score: 10}}, values)
+
+	values, err = zstore.ZRevRange(ctx, "leaderboard", 3, 100)
+	is.NoError(err)
+	is.Equal([]gokvstores.Z{{Member: "bob", Score: 10}}, values)
+
+	values, err = zstore.ZRevRange(ctx, "leaderboard", 2, 1)
+	is.NoError(err)
+	is.Empty(values)
+}
+
+func testZRem(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	zstore := sortedSetStore(t, store)
+
+	count, err := zstore.ZRem(ctx, "zset", "a")
+	is.NoError(err)
+	is.Equal(0, count)
+
+	_, err = zstore.ZAdd(ctx, "zset", gokvstores.Z{Member: "a", Score: 1}, gokvstores.Z{Member: "b", Score: 2})
+	is.NoError(err)
+
+	count, err = zstore.ZRem(ctx, "zset", "a", "missing")
+	is.NoError(err)
+	is.Equal(1, count)
+
+	count, err = zstore.ZRem(ctx, "zset", "b")
+	is.NoError(err)
+	is.Equal(1, count)
+
+	exists, err := store.Exists(ctx, "zset")
+	is.NoError(err)
+	is.False(exists)
+}
+
+func testZWrongType(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	zstore := sortedSetStore(t, store)
+
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	_, err := zstore.ZAdd(ctx, "key", gokvstores.Z{Member: "a", Score: 1})
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, err = zstore.ZRevRange(ctx, "key", 0, -1)
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, err = zstore.ZCard(ctx, "key")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+}
@@ -0,0 +1,328 @@
+package kvstoretest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+)
+
+// RunStreamConformance runs the stream conformance suite against stores
+// returned by factory, which must implement gokvstores.StreamStore.
+func RunStreamConformance(t *testing.T, factory Factory) {
+	run(t, factory, []testCase{
+		{"Append", testAppend},
+		{"Trim", testTrim},
+		{"ReadGroup", testReadGroup},
+		{"ReadGroupNew", testReadGroupNew},
+		{"ReadGroupBlock", testReadGroupBlock},
+		{"ReadGroupContext", testReadGroupContext},
+		{"Claim", testClaim},
+		{"NoGroup", testNoGroup},
+		{"StreamWrongType", testStreamWrongType},
+	})
+}
+
+func streamStore(t *testing.T, store gokvstores.KVStore) gokvstores.StreamStore {
+	sstore, ok := store.(gokvstores.StreamStore)
+	require.True(t, ok, "%T does not implement StreamStore", store)
+	return sstore
+}
+
+func messageIDs(messages []gokvstores.StreamMessage) []string {
+	ids := make([]string, len(messages))
+	for i := range messages {
+		ids[i] = messages[i].ID
+	}
+	return ids
+}
+
+func appendMessages(t *testing.T, sstore gokvstores.StreamStore, key string, count int) []string {
+	ids := make([]string, count)
+	for i := range ids {
+		id, err := sstore.Append(context.Background(), key, map[string]string{"n": string(rune('a' + i))})
+		require.NoError(t, err)
+		ids[i] = id
+	}
+	return ids
+}
+
+func testAppend(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	sstore := streamStore(t, store)
+
+	messages, err := sstore.Read(ctx, "events", "0", 0)
+	is.NoError(err)
+	is.Empty(messages)
+
+	_, err = sstore.Append(ctx, "events", nil)
+	is.Error(err)
+
+	id1, err := sstore.Append(ctx, "events", map[string]string{"type": "created", "id": "1"})
+	is.NoError(err)
+	is.NotEmpty(id1)
+
+	id2, err := sstore.Append(ctx, "events", map[string]string{"type": "deleted", "id": "1"})
+	is.NoError(err)
+	is.NotEqual(id1, id2)
+
+	messages, err = sstore.Read(ctx, "events", "0", 0)
+	is.NoError(err)
+	is.Equal([]gokvstores.StreamMessage{
+		{ID: id1, Values: map[string]string{"type": "created", "id": "1"}},
+		{ID: id2, Values: map[string]string{"type": "deleted", "id": "1"}},
+	}, messages)
+
+	messages, err = sstore.Read(ctx, "events", "0", 1)
+	is.NoError(err)
+	is.Equal([]string{id1}, messageIDs(messages))
+
+	messages, err = sstore.Read(ctx, "events", id1, 0)
+	is.NoError(err)
+	is.Equal([]string{id2}, messageIDs(messages))
+
+	messages, err = sstore.Read(ctx, "events", id2, 0)
+	is.NoError(err)
+	is.Empty(messages)
+
+	exists, err := store.Exists(ctx, "events")
+	is.NoError(err)
+	is.True(exists)
+}
+
+func testTrim(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	sstore := streamStore(t, store)
+
+	count, err := sstore.Trim(ctx, "events", 2)
+	is.NoError(err)
+	is.Equal(0, count)
+
+	ids := appendMessages(t, sstore, "events", 5)
+
+	count, err = sstore.Trim(ctx, "events", 2)
+	is.NoError(err)
+	is.Equal(3, count)
+
+	count, err = sstore.Trim(ctx, "events", 10)
+	is.NoError(err)
+	is.Equal(0, count)
+
+	messages, err := sstore.Read(ctx, "events", "0", 0)
+	is.NoError(err)
+	is.Equal(ids[3:], messageIDs(messages))
+}
+
+func testReadGroup(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	sstore := streamStore(t, store)
+
+	ids := appendMessages(t, sstore, "events", 3)
+
+	is.NoError(sstore.CreateGroup(ctx, "events", "workers", "0"))
+	is.NoError(sstore.CreateGroup(ctx, "events", "workers", "0"))
+
+	messages, err := sstore.ReadGroup(ctx, "events", "workers", "alice", 2, 0)
+	is.NoError(err)
+	is.Equal(ids[:2], messageIDs(messages))
+	is.Equal(map[string]string{"n": "a"}, messages[0].Values)
+
+	messages, err = sstore.ReadGroup(ctx, "events", "workers", "bob", 0, 0)
+	is.NoError(err)
+	is.Equal(ids[2:], messageIDs(messages))
+
+	messages, err = sstore.ReadGroup(ctx, "events", "workers", "bob", 0, 0)
+	is.NoError(err)
+	is.Empty(messages)
+
+	pending, err := sstore.Pending(ctx, "events", "workers", 0)
+	is.NoError(err)
+	if is.Len(pending, 3) {
+		is.Equal(ids[0], pending[0].ID)
+		is.Equal("alice", pending[0].Consumer)
+		is.Equal(1, pending[0].Deliveries)
+		is.Equal("bob", pending[2].Consumer)
+	}
+
+	pending, err = sstore.Pending(ctx, "events", "workers", 1)
+	is.NoError(err)
+	is.Len(pending, 1)
+
+	count, err := sstore.Ack(ctx, "events", "workers", ids[0], ids[2])
+	is.NoError(err)
+	is.Equal(2, count)
+
+	count, err = sstore.Ack(ctx, "events", "workers", ids[0])
+	is.NoError(err)
+	is.Equal(0, count)
+
+	pending, err = sstore.Pending(ctx, "events", "workers", 0)
+	is.NoError(err)
+	if is.Len(pending, 1) {
+		is.Equal(ids[1], pending[0].ID)
+	}
+
+	// Another group has its own position.
+	is.NoError(sstore.CreateGroup(ctx, "events", "audit", "0"))
+
+	messages, err = sstore.ReadGroup(ctx, "events", "audit", "carol", 0, 0)
+	is.NoError(err)
+	is.Equal(ids, messageIDs(messages))
+}
+
+func testReadGroupNew(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	sstore := streamStore(t, store)
+
+	is.NoError(sstore.CreateGroup(ctx, "events", "workers", "$"))
+
+	exists, err := store.Exists(ctx, "events")
+	is.NoError(err)
+	is.True(exists)
+
+	appendMessages(t, sstore, "events", 2)
+	is.NoError(sstore.CreateGroup(ctx, "events", "late", "$"))
+	ids := appendMessages(t, sstore, "events", 1)
+
+	messages, err := sstore.ReadGroup(ctx, "events", "workers", "alice", 0, 0)
+	is.NoError(err)
+	is.Len(messages, 3)
+
+	messages, err = sstore.ReadGroup(ctx, "events", "late", "alice", 0, 0)
+	is.NoError(err)
+	is.Equal(ids, messageIDs(messages))
+}
+
+func testReadGroupBlock(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	sstore := streamStore(t, store)
+
+	is.NoError(sstore.CreateGroup(ctx, "events", "workers", "$"))
+
+	start := time.Now()
+
+	messages, err := sstore.ReadGroup(ctx, "events", "workers", "alice", 0, 100*time.Millisecond)
+	is.NoError(err)
+	is.Empty(messages)
+	is.True(time.Since(start) >= 100*time.Millisecond)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		sstore.Append(ctx, "events", map[string]string{"type": "created"})
+	}()
+
+	messages, err = sstore.ReadGroup(ctx, "events", "workers", "alice", 0, 5*time.Second)
+	is.NoError(err)
+	if is.Len(messages, 1) {
+		is.Equal(map[string]string{"type": "created"}, messages[0].Values)
+	}
+}
+
+func testReadGroupContext(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	sstore := streamStore(t, store)
+
+	is.NoError(sstore.CreateGroup(context.Background(), "events", "workers", "$"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := sstore.ReadGroup(ctx, "events", "workers", "alice", 0, time.Minute)
+	is.True(errors.Is(err, context.Canceled))
+}
+
+func testClaim(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	sstore := streamStore(t, store)
+
+	ids := appendMessages(t, sstore, "events", 3)
+	is.NoError(sstore.CreateGroup(ctx, "events", "workers", "0"))
+
+	_, err := sstore.ReadGroup(ctx, "events", "workers", "alice", 0, 0)
+	is.NoError(err)
+
+	messages, err := sstore.Claim(ctx, "events", "workers", "bob", time.Hour, 0)
+	is.NoError(err)
+	is.Empty(messages)
+
+	time.Sleep(20 * time.Millisecond)
+
+	messages, err = sstore.Claim(ctx, "events", "workers", "bob", 10*time.Millisecond, 2)
+	is.NoError(err)
+	is.Equal(ids[:2], messageIDs(messages))
+	if is.Len(messages, 2) {
+		is.Equal(map[string]string{"n": "a"}, messages[0].Values)
+	}
+
+	pending, err := sstore.Pending(ctx, "events", "workers", 0)
+	is.NoError(err)
+	if is.Len(pending, 3) {
+		is.Equal("bob", pending[0].Consumer)
+		is.Equal(2, pending[0].Deliveries)
+		is.Equal("alice", pending[2].Consumer)
+		is.Equal(1, pending[2].Deliveries)
+	}
+
+	// Claimed messages are idle again.
+	messages, err = sstore.Claim(ctx, "events", "workers", "carol", 10*time.Millisecond, 0)
+	is.NoError(err)
+	is.Equal(ids[2:], messageIDs(messages))
+
+	// Trimmed messages cannot be claimed.
+	_, err = sstore.Trim(ctx, "events", 0)
+	is.NoError(err)
+
+	time.Sleep(20 * time.Millisecond)
+
+	messages, err = sstore.Claim(ctx, "events", "workers", "carol", 10*time.Millisecond, 0)
+	is.NoError(err)
+	is.Empty(messages)
+}
+
+func testNoGroup(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	sstore := streamStore(t, store)
+
+	_, err := sstore.ReadGroup(ctx, "events", "workers", "alice", 0, 0)
+	is.True(errors.Is(err, gokvstores.ErrNoGroup), "%v", err)
+
+	appendMessages(t, sstore, "events", 1)
+
+	_, err = sstore.ReadGroup(ctx, "events", "workers", "alice", 0, 0)
+	is.True(errors.Is(err, gokvstores.ErrNoGroup), "%v", err)
+
+	_, err = sstore.Pending(ctx, "events", "workers", 0)
+	is.True(errors.Is(err, gokvstores.ErrNoGroup), "%v", err)
+
+	_, err = sstore.Claim(ctx, "events", "workers", "alice", 0, 0)
+	is.True(errors.Is(err, gokvstores.ErrNoGroup), "%v", err)
+}
+
+func testStreamWrongType(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx := context.Background()
+	sstore := streamStore(t, store)
+
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	_, err := sstore.Append(ctx, "key", map[string]string{"a": "b"})
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	_, err = sstore.Read(ctx, "key", "0", 0)
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	err = sstore.CreateGroup(ctx, "key", "workers", "0")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+}
@@ -0,0 +1,154 @@
+package kvstoretest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+)
+
+// RunWatchConformance runs the watch conformance suite against stores
+// returned by factory, which must implement gokvstores.WatchStore.
+//
+// Stores must remove expired keys within a few seconds, e.g. a MemoryStore
+// needs a short cleanup interval.
+func RunWatchConformance(t *testing.T, factory Factory) {
+	run(t, factory, []testCase{
+		{"Set", testWatchSet},
+		{"Delete", testWatchDelete},
+		{"Pattern", testWatchPattern},
+		{"Expire", testWatchExpire},
+		{"Collections", testWatchCollections},
+		{"Cancel", testWatchCancel},
+	})
+}
+
+func watchStore(t *testing.T, store gokvstores.KVStore) gokvstores.WatchStore {
+	wstore, ok := store.(gokvstores.WatchStore)
+	require.True(t, ok, "%T does not implement WatchStore", store)
+	return wstore
+}
+
+// receiveEvent returns the next event received on events, failing the test
+// if none is received within a few seconds.
+func receiveEvent(t *testing.T, events <-chan gokvstores.Event) gokvstores.Event {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "watch closed")
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event received")
+		return gokvstores.Event{}
+	}
+}
+
+func testWatchSet(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := watchStore(t, store).Watch(ctx, "*")
+	require.NoError(t, err)
+
+	is.NoError(store.Set(ctx, "key", "value"))
+	is.Equal(gokvstores.Event{Type: gokvstores.EventSet, Key: "key"}, receiveEvent(t, events))
+
+	is.NoError(store.SetMap(ctx, "map", map[string]interface{}{"field": "value"}))
+	is.Equal(gokvstores.Event{Type: gokvstores.EventSet, Key: "map"}, receiveEvent(t, events))
+}
+
+func testWatchDelete(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	events, err := watchStore(t, store).Watch(ctx, "*")
+	require.NoError(t, err)
+
+	is.NoError(store.Delete(ctx, "missing"))
+	is.NoError(store.Delete(ctx, "key"))
+	is.Equal(gokvstores.Event{Type: gokvstores.EventDelete, Key: "key"}, receiveEvent(t, events))
+}
+
+func testWatchPattern(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := watchStore(t, store).Watch(ctx, "user:*")
+	require.NoError(t, err)
+
+	is.NoError(store.Set(ctx, "item:1", "value"))
+	is.NoError(store.Set(ctx, "user:1", "value"))
+	is.Equal(gokvstores.Event{Type: gokvstores.EventSet, Key: "user:1"}, receiveEvent(t, events))
+}
+
+func testWatchExpire(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := watchStore(t, store).Watch(ctx, "session:*")
+	require.NoError(t, err)
+
+	is.NoError(store.SetWithExpiration(ctx, "session:1", "value", 100*time.Millisecond))
+	is.Equal(gokvstores.Event{Type: gokvstores.EventSet, Key: "session:1"}, receiveEvent(t, events))
+
+	// Redis removes expired keys when they are accessed or sampled.
+	is.Eventually(func() bool {
+		exists, err := store.Exists(ctx, "session:1")
+		return err == nil && !exists
+	}, 5*time.Second, 10*time.Millisecond)
+
+	is.Equal(gokvstores.Event{Type: gokvstores.EventExpire, Key: "session:1"}, receiveEvent(t, events))
+}
+
+func testWatchCollections(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lstore, ok := store.(gokvstores.ListStore)
+	if !ok {
+		t.Skipf("%T does not implement ListStore", store)
+	}
+
+	events, err := watchStore(t, store).Watch(ctx, "queue")
+	require.NoError(t, err)
+
+	_, err = lstore.PushRight(ctx, "queue", "a")
+	is.NoError(err)
+	is.Equal(gokvstores.Event{Type: gokvstores.EventSet, Key: "queue"}, receiveEvent(t, events))
+
+	// Removing the last value deletes the key.
+	_, _, err = lstore.PopLeft(ctx, "queue")
+	is.NoError(err)
+	is.Equal(gokvstores.Event{Type: gokvstores.EventSet, Key: "queue"}, receiveEvent(t, events))
+	is.Equal(gokvstores.Event{Type: gokvstores.EventDelete, Key: "queue"}, receiveEvent(t, events))
+}
+
+func testWatchCancel(t *testing.T, store gokvstores.KVStore) {
+	is := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events, err := watchStore(t, store).Watch(ctx, "*")
+	require.NoError(t, err)
+
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		is.False(ok)
+	case <-time.After(5 * time.Second):
+		is.Fail("watch not closed")
+	}
+}
@@ -0,0 +1,50 @@
+package gokvstores
+
+import (
+	"context"
+	"time"
+)
+
+// ListStore is implemented by stores supporting lists, e.g. for FIFO work queues:
+// producers push values with PushRight and consumers pop them with PopLeft or BlockingPop.
+type ListStore interface {
+	// PushLeft inserts the given values at the head of the list stored at key,
+	// one after the other, and returns the length of the list.
+	PushLeft(ctx context.Context, key string, values ...string) (int, error)
+
+	// PushRight inserts the given values at the tail of the list stored at key
+	// and returns the length of the list.
+	PushRight(ctx context.Context, key string, values ...string) (int, error)
+
+	// PopLeft removes and returns the first value of the list stored at key.
+	// It reports false if the list is empty.
+	PopLeft(ctx context.Context, key string) (string, bool, error)
+
+	// PopRight removes and returns the last value of the list stored at key.
+	// It reports false if the list is empty.
+	PopRight(ctx context.Context, key string) (string, bool, error)
+
+	// BlockingPop removes and returns the first value of the first non-empty list
+	// among keys, along with its key. If all lists are empty, it waits for a value
+	// to be pushed until timeout elapses, or indefinitely if timeout is 0, and returns
+	// an empty key on timeout. It stops waiting and returns an error when ctx is done.
+	BlockingPop(ctx context.Context, timeout time.Duration, keys ...string) (string, string, error)
+
+	// Len returns the length of the list stored at key.
+	Len(ctx context.Context, key string) (int, error)
+
+	// Range returns the values between the start and stop indexes, both included.
+	// Negative indexes are offsets from the tail, -1 being the last value.
+	Range(ctx context.Context, key string, start int, stop int) ([]string, error)
+}
+
+// Names of the ListStore operations.
+const (
+	OpPushLeft    = "PushLeft"
+	OpPushRight   = "PushRight"
+	OpPopLeft     = "PopLeft"
+	OpPopRight    = "PopRight"
+	OpBlockingPop = "BlockingPop"
+	OpLen         = "Len"
+	OpRange       = "Range"
+)
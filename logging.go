@@ -0,0 +1,395 @@
+package gokvstores
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// KeyMode defines how LoggingStore logs keys.
+type KeyMode int
+
+const (
+	// KeyPlain logs keys as they are.
+	KeyPlain KeyMode = iota
+
+	// KeyHashed logs a short SHA-256 hash of keys, so that calls on a same key
+	// can be correlated without logging the key.
+	KeyHashed
+
+	// KeyRedacted does not log keys.
+	KeyRedacted
+)
+
+// LoggingOptions are LoggingStore options.
+type LoggingOptions struct {
+	// Logger is the logger to use. Defaults to slog.Default().
+	Logger *slog.Logger
+
+	// SlowThreshold is the duration above which successful calls are logged as slow.
+	// Zero disables the logging of slow calls.
+	SlowThreshold time.Duration
+
+	// KeyMode defines how keys are logged. Defaults to KeyPlain.
+	KeyMode KeyMode
+
+	// Backend is the backend name logged with every call.
+	// Defaults to the backend name of the wrapped store.
+	Backend string
+
+	// MaxLogsPerSecond bounds the number of logged calls per second, the others
+	// being dropped and counted in the next logged call. Zero means no limit.
+	MaxLogsPerSecond int
+}
+
+// LoggingStore is a KVStore wrapper logging failed calls, at error level,
+// and slow calls, at warning level.
+type LoggingStore struct {
+	store   KVStore
+	options LoggingOptions
+
+	mu      sync.Mutex
+	window  time.Time
+	logged  int
+	dropped int
+}
+
+// NewLoggingStore returns a LoggingStore wrapping the given store.
+func NewLoggingStore(store KVStore, options LoggingOptions) *LoggingStore {
+	if options.Logger == nil {
+		options.Logger = slog.Default()
+	}
+
+	if options.Backend == "" {
+		options.Backend = BackendName(store)
+	}
+
+	return &LoggingStore{
+		store:   store,
+		options: options,
+	}
+}
+
+// Unwrap returns the wrapped store.
+func (l *LoggingStore) Unwrap() KVStore {
+	return l.store
+}
+
+// log logs a call to op on the given keys if it failed or was slow.
+// size is the size of the written or read value, or -1 if irrelevant.
+func (l *LoggingStore) log(ctx context.Context, op string, keys []string, size int, start time.Time, err error) {
+	duration := time.Since(start)
+
+	var (
+		level slog.Level
+		msg   string
+	)
+
+	switch {
+	case err != nil:
+		level, msg = slog.LevelError, "kvstore call failed"
+	case l.options.SlowThreshold > 0 && duration >= l.options.SlowThreshold:
+		level, msg = slog.LevelWarn, "kvstore call slow"
+	default:
+		return
+	}
+
+	if !l.options.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	dropped, ok := l.sample()
+	if !ok {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 8)
+	attrs = append(attrs,
+		slog.String("op", op),
+		slog.String("backend", l.options.Backend),
+		slog.Duration("duration", duration),
+	)
+
+	if len(keys) > 0 && l.options.KeyMode != KeyRedacted {
+		attrs = append(attrs, slog.String("key", l.formatKey(keys[0])))
+	}
+
+	if len(keys) > 1 {
+		attrs = append(attrs, slog.Int("keys", len(keys)))
+	}
+
+	if size >= 0 {
+		attrs = append(attrs, slog.Int("value_size", size))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+
+	if dropped > 0 {
+		attrs = append(attrs, slog.Int("dropped", dropped))
+	}
+
+	l.options.Logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// sample reports whether a call can be logged in the current one-second window,
+// and the number of calls dropped since the last logged one.
+func (l *LoggingStore) sample() (int, bool) {
+	if l.options.MaxLogsPerSecond <= 0 {
+		return 0, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.window) >= time.Second {
+		l.window = now
+		l.logged = 0
+	}
+
+	if l.logged >= l.options.MaxLogsPerSecond {
+		l.dropped++
+		return 0, false
+	}
+
+	l.logged++
+
+	dropped := l.dropped
+	l.dropped = 0
+
+	return dropped, true
+}
+
+func (l *LoggingStore) formatKey(key string) string {
+	if l.options.KeyMode == KeyHashed {
+		sum := sha256.Sum256([]byte(key))
+		return hex.EncodeToString(sum[:8])
+	}
+	return key
+}
+
+// valueSize returns the approximate size in bytes of the given value.
+func valueSize(value interface{}) int {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case map[string]interface{}:
+		size := 0
+		for k, item := range v {
+			size += len(k) + valueSize(item)
+		}
+		return size
+	case map[string]map[string]interface{}:
+		size := 0
+		for k, item := range v {
+			size += len(k) + valueSize(item)
+		}
+		return size
+	case []interface{}:
+		size := 0
+		for _, item := range v {
+			size += valueSize(item)
+		}
+		return size
+	default:
+		return len(fmt.Sprint(v))
+	}
+}
+
+// Get returns value for the given key.
+func (l *LoggingStore) Get(ctx context.Context, key string) (interface{}, error) {
+	start := time.Now()
+	v, err := l.store.Get(ctx, key)
+	l.log(ctx, OpGet, []string{key}, valueSize(v), start, err)
+	return v, err
+}
+
+// MGet returns map of key, value for a list of keys.
+func (l *LoggingStore) MGet(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	start := time.Now()
+	v, err := l.store.MGet(ctx, keys)
+	l.log(ctx, OpMGet, keys, valueSize(v), start, err)
+	return v, err
+}
+
+// Set sets value for the given key.
+func (l *LoggingStore) Set(ctx context.Context, key string, value interface{}) error {
+	start := time.Now()
+	err := l.store.Set(ctx, key, value)
+	l.log(ctx, OpSet, []string{key}, valueSize(value), start, err)
+	return err
+}
+
+// SetWithExpiration sets the value for the given key for a specified duration.
+func (l *LoggingStore) SetWithExpiration(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	start := time.Now()
+	err := l.store.SetWithExpiration(ctx, key, value, expiration)
+	l.log(ctx, OpSetWithExpiration, []string{key}, valueSize(value), start, err)
+	return err
+}
+
+// GetMap returns map for the given key.
+func (l *LoggingStore) GetMap(ctx context.Context, key string) (map[string]interface{}, error) {
+	start := time.Now()
+	v, err := l.store.GetMap(ctx, key)
+	l.log(ctx, OpGetMap, []string{key}, valueSize(v), start, err)
+	return v, err
+}
+
+// GetMaps returns maps for the given keys.
+func (l *LoggingStore) GetMaps(ctx context.Context, keys []string) (map[string]map[string]interface{}, error) {
+	start := time.Now()
+	v, err := l.store.GetMaps(ctx, keys)
+	l.log(ctx, OpGetMaps, keys, valueSize(v), start, err)
+	return v, err
+}
+
+// SetMap sets map for the given key.
+func (l *LoggingStore) SetMap(ctx context.Context, key string, value map[string]interface{}) error {
+	start := time.Now()
+	err := l.store.SetMap(ctx, key, value)
+	l.log(ctx, OpSetMap, []string{key}, valueSize(value), start, err)
+	return err
+}
+
+// SetMaps sets the given maps.
+func (l *LoggingStore) SetMaps(ctx context.Context, maps map[string]map[string]interface{}) error {
+	start := time.Now()
+	err := l.store.SetMaps(ctx, maps)
+
+	keys := make([]string, 0, len(maps))
+	for key := range maps {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	l.log(ctx, OpSetMaps, keys, valueSize(maps), start, err)
+	return err
+}
+
+// DeleteMap removes the specified fields from the map stored at key.
+func (l *LoggingStore) DeleteMap(ctx context.Context, key string, fields ...string) error {
+	start := time.Now()
+	err := l.store.DeleteMap(ctx, key, fields...)
+	l.log(ctx, OpDeleteMap, []string{key}, -1, start, err)
+	return err
+}
+
+// GetSlice returns slice for the given key.
+func (l *LoggingStore) GetSlice(ctx context.Context, key string) ([]interface{}, error) {
+	start := time.Now()
+	v, err := l.store.GetSlice(ctx, key)
+	l.log(ctx, OpGetSlice, []string{key}, valueSize(v), start, err)
+	return v, err
+}
+
+// SetSlice sets slice for the given key.
+func (l *LoggingStore) SetSlice(ctx context.Context, key string, value []interface{}) error {
+	start := time.Now()
+	err := l.store.SetSlice(ctx, key, value)
+	l.log(ctx, OpSetSlice, []string{key}, valueSize(value), start, err)
+	return err
+}
+
+// AppendSlice appends values to an existing slice.
+// If key does not exist, creates slice.
+func (l *LoggingStore) AppendSlice(ctx context.Context, key string, values ...interface{}) error {
+	start := time.Now()
+	err := l.store.AppendSlice(ctx, key, values...)
+	l.log(ctx, OpAppendSlice, []string{key}, valueSize(values), start, err)
+	return err
+}
+
+// Exists checks if all the given keys exist.
+func (l *LoggingStore) Exists(ctx context.Context, keys ...string) (bool, error) {
+	start := time.Now()
+	v, err := l.store.Exists(ctx, keys...)
+	l.log(ctx, OpExists, keys, -1, start, err)
+	return v, err
+}
+
+// ExistsAll checks if all the given keys exist.
+func (l *LoggingStore) ExistsAll(ctx context.Context, keys ...string) (bool, error) {
+	start := time.Now()
+	v, err := l.store.ExistsAll(ctx, keys...)
+	l.log(ctx, OpExistsAll, keys, -1, start, err)
+	return v, err
+}
+
+// ExistsAny checks if at least one of the given keys exists.
+func (l *LoggingStore) ExistsAny(ctx context.Context, keys ...string) (bool, error) {
+	start := time.Now()
+	v, err := l.store.ExistsAny(ctx, keys...)
+	l.log(ctx, OpExistsAny, keys, -1, start, err)
+	return v, err
+}
+
+// CountExisting returns the number of given keys that exist.
+func (l *LoggingStore) CountExisting(ctx context.Context, keys ...string) (int, error) {
+	start := time.Now()
+	v, err := l.store.CountExisting(ctx, keys...)
+	l.log(ctx, OpCountExisting, keys, -1, start, err)
+	return v, err
+}
+
+// Delete deletes the given key.
+func (l *LoggingStore) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := l.store.Delete(ctx, key)
+	l.log(ctx, OpDelete, []string{key}, -1, start, err)
+	return err
+}
+
+// Flush flushes the store.
+func (l *LoggingStore) Flush(ctx context.Context) error {
+	start := time.Now()
+	err := l.store.Flush(ctx)
+	l.log(ctx, OpFlush, nil, -1, start, err)
+	return err
+}
+
+// Keys returns all keys matching pattern.
+func (l *LoggingStore) Keys(ctx context.Context, pattern string) ([]interface{}, error) {
+	start := time.Now()
+	v, err := l.store.Keys(ctx, pattern)
+	l.log(ctx, OpKeys, []string{pattern}, -1, start, err)
+	return v, err
+}
+
+// Close closes the wrapped store.
+func (l *LoggingStore) Close() error {
+	start := time.Now()
+	err := l.store.Close()
+	l.log(context.Background(), OpClose, nil, -1, start, err)
+	return err
+}
+
+// RunScript runs script on the wrapped store, which must implement ScriptStore.
+func (l *LoggingStore) RunScript(ctx context.Context, script *Script, keys []string, args ...interface{}) *ScriptResult {
+	start := time.Now()
+	res := RunScript(ctx, l.store, script, keys, args...)
+	l.log(ctx, OpRunScript, keys, -1, start, res.Err())
+	return res
+}
+
+// Health checks the wrapped store, which should implement HealthChecker.
+func (l *LoggingStore) Health(ctx context.Context) HealthStatus {
+	return Health(ctx, l.store)
+}
+
+var (
+	_ KVStore       = &LoggingStore{}
+	_ ScriptStore   = &LoggingStore{}
+	_ HealthChecker = &LoggingStore{}
+)
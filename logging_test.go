@@ -0,0 +1,161 @@
+package gokvstores_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+	"github.com/ulule/gokvstores/kvstoretest"
+)
+
+func newLoggingStore(t *testing.T, options gokvstores.LoggingOptions) (*gokvstores.LoggingStore, *gokvstores.ChaosStore, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	options.Logger = slog.New(slog.NewJSONHandler(buf, nil))
+
+	chaos := newChaosStore(t, gokvstores.ChaosOptions{})
+
+	return gokvstores.NewLoggingStore(chaos, options), chaos, buf
+}
+
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		entry := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestLoggingStore(t *testing.T) {
+	kvstoretest.RunConformance(t, func(t *testing.T) gokvstores.KVStore {
+		store, _, _ := newLoggingStore(t, gokvstores.LoggingOptions{})
+		return store
+	})
+}
+
+func TestLoggingStore_Failures(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store, chaos, buf := newLoggingStore(t, gokvstores.LoggingOptions{Backend: "memory"})
+
+	is.NoError(store.Set(ctx, "key", "value"))
+	is.Empty(logEntries(t, buf))
+
+	chaos.FailNext(gokvstores.OpSet, 1, nil)
+	is.Equal(gokvstores.ErrChaos, store.Set(ctx, "key", "value"))
+
+	entries := logEntries(t, buf)
+	is.Len(entries, 1)
+	is.Equal("ERROR", entries[0]["level"])
+	is.Equal("kvstore call failed", entries[0]["msg"])
+	is.Equal(gokvstores.OpSet, entries[0]["op"])
+	is.Equal("memory", entries[0]["backend"])
+	is.Equal("key", entries[0]["key"])
+	is.Equal(float64(5), entries[0]["value_size"])
+	is.Equal(gokvstores.ErrChaos.Error(), entries[0]["error"])
+
+	buf.Reset()
+
+	chaos.FailNext(gokvstores.OpMGet, 1, nil)
+	_, err := store.MGet(ctx, []string{"a", "b", "c"})
+	is.Error(err)
+
+	entries = logEntries(t, buf)
+	is.Len(entries, 1)
+	is.Equal("a", entries[0]["key"])
+	is.Equal(float64(3), entries[0]["keys"])
+}
+
+func TestLoggingStore_Slow(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	buf := &bytes.Buffer{}
+	store := gokvstores.NewLoggingStore(
+		newChaosStore(t, gokvstores.ChaosOptions{Latency: 20 * time.Millisecond}),
+		gokvstores.LoggingOptions{
+			Logger:        slog.New(slog.NewJSONHandler(buf, nil)),
+			SlowThreshold: 10 * time.Millisecond,
+		})
+
+	_, err := store.Get(ctx, "key")
+	is.NoError(err)
+
+	entries := logEntries(t, buf)
+	is.Len(entries, 1)
+	is.Equal("WARN", entries[0]["level"])
+	is.Equal("kvstore call slow", entries[0]["msg"])
+	is.Equal(gokvstores.BackendMemory, entries[0]["backend"])
+	is.NotContains(entries[0], "error")
+}
+
+func TestLoggingStore_KeyMode(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store, chaos, buf := newLoggingStore(t, gokvstores.LoggingOptions{KeyMode: gokvstores.KeyHashed})
+
+	chaos.FailNext("", 2, nil)
+	_, err := store.Get(ctx, "secret")
+	is.Error(err)
+	_, err = store.Get(ctx, "secret")
+	is.Error(err)
+
+	entries := logEntries(t, buf)
+	is.Len(entries, 2)
+	is.NotEqual("secret", entries[0]["key"])
+	is.Len(entries[0]["key"], 16)
+	is.Equal(entries[0]["key"], entries[1]["key"])
+
+	store, chaos, buf = newLoggingStore(t, gokvstores.LoggingOptions{KeyMode: gokvstores.KeyRedacted})
+
+	chaos.FailNext("", 1, nil)
+	_, err = store.Get(ctx, "secret")
+	is.Error(err)
+
+	entries = logEntries(t, buf)
+	is.Len(entries, 1)
+	is.NotContains(entries[0], "key")
+}
+
+func TestLoggingStore_Sampling(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store, chaos, buf := newLoggingStore(t, gokvstores.LoggingOptions{MaxLogsPerSecond: 2})
+
+	chaos.FailNext("", 5, nil)
+	for i := 0; i < 5; i++ {
+		_, err := store.Get(ctx, "key")
+		is.Error(err)
+	}
+
+	is.Len(logEntries(t, buf), 2)
+
+	time.Sleep(time.Second)
+	buf.Reset()
+
+	chaos.FailNext("", 1, nil)
+	_, err := store.Get(ctx, "key")
+	is.Error(err)
+
+	entries := logEntries(t, buf)
+	is.Len(entries, 1)
+	is.Equal(float64(3), entries[0]["dropped"])
+}
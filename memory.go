@@ -2,29 +2,55 @@ package gokvstores
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/patrickmn/go-cache"
+
+	"github.com/ulule/gokvstores/internal/glob"
 )
 
 // MemoryStore is the in-memory implementation of KVStore.
 type MemoryStore struct {
+	// mu serializes the operations of the store, so that read-modify-write operations
+	// and scripts are atomic.
+	mu              sync.Mutex
 	cache           *cache.Cache
 	expiration      time.Duration
 	cleanupInterval time.Duration
+
+	// pushed is broadcast, with mu held, when values are pushed to a list or appended to a stream.
+	pushed *sync.Cond
+
+	// subscriptions are the Pub/Sub subscriptions, guarded by mu.
+	subscriptions map[*subscription]struct{}
+
+	// scripts are the functions registered for scripts by SHA1 digest, guarded by mu.
+	scripts map[string]ScriptFunc
+
+	// watchMu guards watchers and deleting. It is not mu since go-cache
+	// calls OnEvicted from its janitor as well as from deletions made under mu.
+	watchMu  sync.Mutex
+	watchers map[*watcher]struct{}
+	deleting map[string]int
 }
 
 // Get returns item from the cache.
 func (c *MemoryStore) Get(ctx context.Context, key string) (interface{}, error) {
+	defer c.lock(ctx)()
+
 	item, _ := c.cache.Get(key)
 	return item, nil
 }
 
 // MGet returns map of key, value for a list of keys.
 func (c *MemoryStore) MGet(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	defer c.lock(ctx)()
+
 	results := make(map[string]interface{}, len(keys))
 	for _, key := range keys {
-		item, _ := c.Get(ctx, key)
+		item, _ := c.cache.Get(key)
 		results[key] = item
 	}
 	return results, nil
@@ -32,29 +58,50 @@ func (c *MemoryStore) MGet(ctx context.Context, keys []string) (map[string]inter
 
 // Set sets value in the cache.
 func (c *MemoryStore) Set(ctx context.Context, key string, value interface{}) error {
-	c.cache.Set(key, value, c.expiration)
+	defer c.lock(ctx)()
+
+	c.cache.Set(key, nilToEmpty(value), c.expiration)
+	c.notify(EventSet, key)
 	return nil
 }
 
 // SetWithExpiration sets the value for the given key for a specified duration.
 func (c *MemoryStore) SetWithExpiration(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	c.cache.Set(key, value, expiration)
+	defer c.lock(ctx)()
+
+	c.cache.Set(key, nilToEmpty(value), expiration)
+	c.notify(EventSet, key)
 	return nil
 }
 
+// nilToEmpty returns value, or an empty string if value is nil, since Redis stores nil
+// values as empty strings.
+func nilToEmpty(value interface{}) interface{} {
+	if value == nil {
+		return ""
+	}
+	return value
+}
+
 // GetMap returns map for the given key.
 func (c *MemoryStore) GetMap(ctx context.Context, key string) (map[string]interface{}, error) {
-	if v, found := c.cache.Get(key); found {
-		return v.(map[string]interface{}), nil
-	}
-	return nil, nil
+	defer c.lock(ctx)()
+
+	m, err := c.getMap(key)
+	return m, c.wrap(OpGetMap, key, err)
 }
 
 // GetMaps returns maps for the given keys.
 func (c *MemoryStore) GetMaps(ctx context.Context, keys []string) (map[string]map[string]interface{}, error) {
+	defer c.lock(ctx)()
+
 	values := make(map[string]map[string]interface{}, len(keys))
 	for _, v := range keys {
-		value, _ := c.GetMap(ctx, v)
+		value, err := c.getMap(v)
+		if err != nil {
+			return nil, c.wrap(OpGetMaps, v, err)
+		}
+
 		if value != nil {
 			values[v] = value
 		}
@@ -65,62 +112,158 @@ func (c *MemoryStore) GetMaps(ctx context.Context, keys []string) (map[string]ma
 
 // SetMap sets a map for the given key.
 func (c *MemoryStore) SetMap(ctx context.Context, key string, value map[string]interface{}) error {
+	defer c.lock(ctx)()
+
 	c.cache.Set(key, value, c.expiration)
+	c.notify(EventSet, key)
 	return nil
 }
 
 // SetMaps sets the given maps.
 func (c *MemoryStore) SetMaps(ctx context.Context, maps map[string]map[string]interface{}) error {
+	defer c.lock(ctx)()
+
 	for k, v := range maps {
-		c.SetMap(ctx, k, v)
+		c.cache.Set(k, v, c.expiration)
+		c.notify(EventSet, k)
 	}
 	return nil
 }
 
 // DeleteMap removes the specified fields from the map stored at key.
+// The map is copied rather than updated in place, so that maps returned by GetMap
+// are left untouched, and the key is deleted with its last field, as in Redis.
 func (c *MemoryStore) DeleteMap(ctx context.Context, key string, fields ...string) error {
-	m, err := c.GetMap(ctx, key)
+	defer c.lock(ctx)()
+
+	m, err := c.getMap(key)
 	if err != nil {
-		return err
+		return c.wrap(OpDeleteMap, key, err)
+	}
+
+	if m == nil {
+		return nil
+	}
+
+	updated := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		updated[k] = v
 	}
 
 	for _, field := range fields {
-		delete(m, field)
+		delete(updated, field)
+	}
+
+	if len(updated) == 0 {
+		c.notify(EventSet, key)
+		c.delete(key)
+		return nil
 	}
 
-	return c.SetMap(ctx, key, m)
+	c.cache.Set(key, updated, c.expiration)
+	c.notify(EventSet, key)
+
+	return nil
 }
 
 // GetSlice returns slice for the given key.
 func (c *MemoryStore) GetSlice(ctx context.Context, key string) ([]interface{}, error) {
-	if v, found := c.cache.Get(key); found {
-		return v.([]interface{}), nil
-	}
-	return nil, nil
+	defer c.lock(ctx)()
+
+	items, err := c.getSlice(key)
+	return items, c.wrap(OpGetSlice, key, err)
 }
 
 // SetSlice sets slice for the given key.
 func (c *MemoryStore) SetSlice(ctx context.Context, key string, value []interface{}) error {
+	defer c.lock(ctx)()
+
 	c.cache.Set(key, value, c.expiration)
+	c.notify(EventSet, key)
 	return nil
 }
 
 // AppendSlice appends values to the given slice.
+// The slice is copied rather than appended to in place, so that slices returned by
+// GetSlice are left untouched.
 func (c *MemoryStore) AppendSlice(ctx context.Context, key string, values ...interface{}) error {
-	items, err := c.GetSlice(ctx, key)
+	defer c.lock(ctx)()
+
+	items, err := c.getSlice(key)
 	if err != nil {
-		return err
+		return c.wrap(OpAppendSlice, key, err)
 	}
 
 	if items == nil {
-		return c.SetSlice(ctx, key, values)
+		c.cache.Set(key, values, c.expiration)
+		c.notify(EventSet, key)
+		return nil
+	}
+
+	updated := make([]interface{}, 0, len(items)+len(values))
+	updated = append(updated, items...)
+	updated = append(updated, values...)
+
+	if err := c.cache.Replace(key, updated, c.expiration); err != nil {
+		// The slice expired since it was read.
+		return c.wrap(OpAppendSlice, key, fmt.Errorf("%w: %v", ErrNotFound, err))
+	}
+
+	c.notify(EventSet, key)
+
+	return nil
+}
+
+// getMap returns the map stored at key, or ErrWrongType if key holds another type of value.
+func (c *MemoryStore) getMap(key string) (map[string]interface{}, error) {
+	v, found := c.cache.Get(key)
+	if !found {
+		return nil, nil
 	}
 
-	for _, item := range values {
-		items = append(items, item)
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, ErrWrongType
 	}
 
-	return c.cache.Replace(key, items, c.expiration)
+	return m, nil
+}
+
+// getSlice returns the slice stored at key, or ErrWrongType if key holds another type of value.
+func (c *MemoryStore) getSlice(key string) ([]interface{}, error) {
+	v, found := c.cache.Get(key)
+	if !found {
+		return nil, nil
+	}
+
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return items, nil
+}
+
+// create stores at key a new value of a capability interface, such as a list or a
+// sorted set. Unlike KVStore values, these values do not expire, as in RedisStore.
+func (c *MemoryStore) create(key string, value interface{}) {
+	c.cache.Set(key, value, cache.NoExpiration)
+}
+
+// wrap returns an OpError for the given operation, or nil if err is nil.
+func (c *MemoryStore) wrap(op string, key string, err error) error {
+	return newOpError(BackendMemory, op, key, err)
+}
+
+// lock locks mu, unless ctx is the context of a script run by this store which already
+// holds it, and returns the function unlocking it.
+func (c *MemoryStore) lock(ctx context.Context) func() {
+	if c.scripting(ctx) {
+		return func() {}
+	}
+
+	c.mu.Lock()
+	return c.mu.Unlock
 }
 
 // Close does nothing for this backend.
@@ -130,23 +273,47 @@ func (c *MemoryStore) Close() error {
 
 // Flush removes all items from the cache.
 func (c *MemoryStore) Flush(ctx context.Context) error {
+	defer c.lock(ctx)()
+
 	c.cache.Flush()
 	return nil
 }
 
 // Delete deletes the given key.
 func (c *MemoryStore) Delete(ctx context.Context, key string) error {
-	c.cache.Delete(key)
+	defer c.lock(ctx)()
+
+	c.delete(key)
 	return nil
 }
 
-// Keys returns all keys matching pattern
+// Keys returns all keys matching pattern.
+// Patterns follow the Redis glob-style syntax, e.g. "user:*" or "user:[0-9]?".
 func (c *MemoryStore) Keys(ctx context.Context, pattern string) ([]interface{}, error) {
-	return nil, nil
+	defer c.lock(ctx)()
+
+	var keys []interface{}
+	for key := range c.cache.Items() {
+		if glob.Match(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
 }
 
-// Exists checks if the given key exists.
+// Exists checks if all the given keys exist.
 func (c *MemoryStore) Exists(ctx context.Context, keys ...string) (bool, error) {
+	return c.ExistsAll(ctx, keys...)
+}
+
+// ExistsAll checks if all the given keys exist.
+func (c *MemoryStore) ExistsAll(ctx context.Context, keys ...string) (bool, error) {
+	defer c.lock(ctx)()
+
+	if len(keys) == 0 {
+		return false, nil
+	}
+
 	for i := range keys {
 		if _, exists := c.cache.Get(keys[i]); !exists {
 			return false, nil
@@ -155,13 +322,58 @@ func (c *MemoryStore) Exists(ctx context.Context, keys ...string) (bool, error)
 	return true, nil
 }
 
+// ExistsAny checks if at least one of the given keys exists.
+func (c *MemoryStore) ExistsAny(ctx context.Context, keys ...string) (bool, error) {
+	defer c.lock(ctx)()
+
+	for i := range keys {
+		if _, exists := c.cache.Get(keys[i]); exists {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CountExisting returns the number of given keys that exist.
+func (c *MemoryStore) CountExisting(ctx context.Context, keys ...string) (int, error) {
+	defer c.lock(ctx)()
+
+	count := 0
+	for i := range keys {
+		if _, exists := c.cache.Get(keys[i]); exists {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Health reports the number of items in the cache. The store is always healthy.
+func (c *MemoryStore) Health(ctx context.Context) HealthStatus {
+	return HealthStatus{
+		Healthy: true,
+		Backend: BackendMemory,
+		Details: map[string]interface{}{
+			"items": c.cache.ItemCount(),
+		},
+	}
+}
+
 // NewMemoryStore returns in-memory KVStore.
 func NewMemoryStore(expiration time.Duration, cleanupInterval time.Duration) (KVStore, error) {
-	return &MemoryStore{
+	store := &MemoryStore{
 		cache:           cache.New(expiration, cleanupInterval),
 		expiration:      time.Duration(expiration) * time.Second,
 		cleanupInterval: cleanupInterval,
-	}, nil
+	}
+	store.pushed = sync.NewCond(&store.mu)
+	store.watchers = map[*watcher]struct{}{}
+	store.deleting = map[string]int{}
+	store.cache.OnEvicted(store.evicted)
+
+	return store, nil
 }
 
-var _ KVStore = &MemoryStore{}
+var (
+	_ KVStore       = &MemoryStore{}
+	_ HealthChecker = &MemoryStore{}
+)
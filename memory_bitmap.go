@@ -0,0 +1,259 @@
+package gokvstores
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+)
+
+// bitmap is the value of bitmap keys in MemoryStore.
+// Its bytes grow as bits are set, under MemoryStore.mu.
+type bitmap struct {
+	bytes []byte
+}
+
+// bit returns the bit at offset.
+func (b *bitmap) bit(offset int64) bool {
+	if offset/8 >= int64(len(b.bytes)) {
+		return false
+	}
+
+	return b.bytes[offset/8]&(0x80>>(offset%8)) != 0
+}
+
+// setBit sets the bit at offset, growing the bitmap if needed, and returns its previous value.
+func (b *bitmap) setBit(offset int64, value bool) bool {
+	if n := offset/8 + 1; n > int64(len(b.bytes)) {
+		b.bytes = append(b.bytes, make([]byte, n-int64(len(b.bytes)))...)
+	}
+
+	previous := b.bit(offset)
+
+	mask := byte(0x80) >> (offset % 8)
+	if value {
+		b.bytes[offset/8] |= mask
+	} else {
+		b.bytes[offset/8] &^= mask
+	}
+
+	return previous
+}
+
+// byteRange converts start and end byte offsets, possibly negative, into indexes within
+// a bitmap of the given length, as Redis does. It reports false if the range is empty.
+func byteRange(start int64, end int64, length int64) (int64, int64, bool) {
+	if start < 0 {
+		start += length
+	}
+
+	if end < 0 {
+		end += length
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if end < 0 {
+		end = 0
+	}
+
+	if end >= length {
+		end = length - 1
+	}
+
+	if start > end {
+		return 0, 0, false
+	}
+
+	return start, end, true
+}
+
+// checkBitOffset returns an error if offset is not a valid bit offset.
+func checkBitOffset(offset int64) error {
+	if offset < 0 || offset > maxBitOffset {
+		return fmt.Errorf("gokvstores: bit offset %d out of range", offset)
+	}
+	return nil
+}
+
+// getBitmap returns the bitmap stored at key, or ErrWrongType if key holds another type of value.
+// If the key does not exist, it returns nil or a new bitmap when create is true.
+// It must be called with c.mu held.
+func (c *MemoryStore) getBitmap(key string, create bool) (*bitmap, error) {
+	v, found := c.cache.Get(key)
+	if !found {
+		if !create {
+			return nil, nil
+		}
+
+		b := &bitmap{}
+		c.create(key, b)
+
+		return b, nil
+	}
+
+	b, ok := v.(*bitmap)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return b, nil
+}
+
+// SetBit sets the bit at offset in the bitmap stored at key, creating it if needed,
+// and returns its previous value.
+func (c *MemoryStore) SetBit(ctx context.Context, key string, offset int64, value bool) (bool, error) {
+	if err := checkBitOffset(offset); err != nil {
+		return false, c.wrap(OpSetBit, key, err)
+	}
+
+	defer c.lock(ctx)()
+
+	b, err := c.getBitmap(key, true)
+	if err != nil {
+		return false, c.wrap(OpSetBit, key, err)
+	}
+
+	previous := b.setBit(offset, value)
+
+	c.notify(EventSet, key)
+
+	return previous, nil
+}
+
+// GetBit returns the bit at offset in the bitmap stored at key.
+func (c *MemoryStore) GetBit(ctx context.Context, key string, offset int64) (bool, error) {
+	if err := checkBitOffset(offset); err != nil {
+		return false, c.wrap(OpGetBit, key, err)
+	}
+
+	defer c.lock(ctx)()
+
+	b, err := c.getBitmap(key, false)
+	if err != nil || b == nil {
+		return false, c.wrap(OpGetBit, key, err)
+	}
+
+	return b.bit(offset), nil
+}
+
+// BitCount returns the number of bits set in the bytes between start and end
+// of the bitmap stored at key. BitCount(ctx, key, 0, -1) counts all bits.
+func (c *MemoryStore) BitCount(ctx context.Context, key string, start int64, end int64) (int64, error) {
+	defer c.lock(ctx)()
+
+	b, err := c.getBitmap(key, false)
+	if err != nil || b == nil {
+		return 0, c.wrap(OpBitCount, key, err)
+	}
+
+	start, end, ok := byteRange(start, end, int64(len(b.bytes)))
+	if !ok {
+		return 0, nil
+	}
+
+	count := 0
+	for _, v := range b.bytes[start : end+1] {
+		count += bits.OnesCount8(v)
+	}
+
+	return int64(count), nil
+}
+
+// BitOp stores at dest the result of op between the bitmaps stored at keys,
+// missing bitmaps and the end of shorter bitmaps being zeros, and returns its size
+// in bytes, which is the size of the longest bitmap. dest is deleted if all bitmaps
+// are missing.
+func (c *MemoryStore) BitOp(ctx context.Context, op BitOperation, dest string, keys ...string) (int64, error) {
+	if op != BitAnd && op != BitOr && op != BitXor {
+		return 0, c.wrap(OpBitOp, dest, fmt.Errorf("gokvstores: invalid bit operation %d", op))
+	}
+
+	defer c.lock(ctx)()
+
+	sources := make([][]byte, len(keys))
+	size := 0
+
+	for i, key := range keys {
+		b, err := c.getBitmap(key, false)
+		if err != nil {
+			return 0, c.wrap(OpBitOp, key, err)
+		}
+
+		if b != nil {
+			sources[i] = b.bytes
+			size = max(size, len(b.bytes))
+		}
+	}
+
+	if size == 0 {
+		c.delete(dest)
+		return 0, nil
+	}
+
+	result := make([]byte, size)
+	for i := range result {
+		for j, source := range sources {
+			var v byte
+			if i < len(source) {
+				v = source[i]
+			}
+
+			switch {
+			case j == 0:
+				result[i] = v
+			case op == BitAnd:
+				result[i] &= v
+			case op == BitOr:
+				result[i] |= v
+			case op == BitXor:
+				result[i] ^= v
+			}
+		}
+	}
+
+	c.create(dest, &bitmap{bytes: result})
+	c.notify(EventSet, dest)
+
+	return int64(size), nil
+}
+
+// BitPos returns the position of the first bit with the given value in the bytes between
+// start and end of the bitmap stored at key, or -1 if there is none. Missing bitmaps
+// are considered to be zeros.
+func (c *MemoryStore) BitPos(ctx context.Context, key string, value bool, start int64, end int64) (int64, error) {
+	defer c.lock(ctx)()
+
+	b, err := c.getBitmap(key, false)
+	if err != nil {
+		return 0, c.wrap(OpBitPos, key, err)
+	}
+
+	if b == nil {
+		if value {
+			return -1, nil
+		}
+		return 0, nil
+	}
+
+	start, end, ok := byteRange(start, end, int64(len(b.bytes)))
+	if !ok {
+		return -1, nil
+	}
+
+	for i := start; i <= end; i++ {
+		v := b.bytes[i]
+		if !value {
+			v = ^v
+		}
+
+		if v != 0 {
+			return i*8 + int64(bits.LeadingZeros8(v)), nil
+		}
+	}
+
+	return -1, nil
+}
+
+var _ BitmapStore = &MemoryStore{}
@@ -0,0 +1,181 @@
+package gokvstores
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ulule/gokvstores/internal/geohash"
+)
+
+// Geospatial indexes of MemoryStore are sorted sets scored by geohash, as in Redis,
+// so that they can also be read with the SortedSetStore methods.
+
+// geoPosition returns the position of member in the sorted set z.
+func geoPosition(z *sortedSet, member string) (float64, float64, bool) {
+	score, found := z.scores[member]
+	if !found {
+		return 0, 0, false
+	}
+
+	lon, lat := geohash.Decode(uint64(score))
+
+	return lon, lat, true
+}
+
+// GeoAdd adds the given members to the geospatial index stored at key, or updates their
+// position, and returns the number of added members. Latitudes must be between -85.05112878
+// and 85.05112878 degrees.
+func (c *MemoryStore) GeoAdd(ctx context.Context, key string, locations ...GeoLocation) (int, error) {
+	if err := checkGeoLocations(locations); err != nil {
+		return 0, c.wrap(OpGeoAdd, key, err)
+	}
+
+	if len(locations) == 0 {
+		return 0, nil
+	}
+
+	defer c.lock(ctx)()
+
+	z, err := c.getSortedSet(key, true)
+	if err != nil {
+		return 0, c.wrap(OpGeoAdd, key, err)
+	}
+
+	count := 0
+	for _, l := range locations {
+		if z.add(l.Member, float64(geohash.Encode(l.Longitude, l.Latitude))) {
+			count++
+		}
+	}
+
+	c.notify(EventSet, key)
+
+	return count, nil
+}
+
+// GeoPos returns the positions of the given members, nil for missing members.
+func (c *MemoryStore) GeoPos(ctx context.Context, key string, members ...string) ([]*GeoPosition, error) {
+	defer c.lock(ctx)()
+
+	z, err := c.getSortedSet(key, false)
+	if err != nil {
+		return nil, c.wrap(OpGeoPos, key, err)
+	}
+
+	positions := make([]*GeoPosition, len(members))
+	if z == nil {
+		return positions, nil
+	}
+
+	for i, member := range members {
+		if lon, lat, ok := geoPosition(z, member); ok {
+			positions[i] = &GeoPosition{Longitude: geoCoordinate(lon), Latitude: geoCoordinate(lat)}
+		}
+	}
+
+	return positions, nil
+}
+
+// GeoDist returns the distance between two members in the given unit, GeoMeters if empty.
+// It reports false if a member is missing.
+func (c *MemoryStore) GeoDist(ctx context.Context, key string, member1 string, member2 string, unit GeoUnit) (float64, bool, error) {
+	meters, err := unit.meters()
+	if err != nil {
+		return 0, false, c.wrap(OpGeoDist, key, err)
+	}
+
+	defer c.lock(ctx)()
+
+	z, err := c.getSortedSet(key, false)
+	if err != nil || z == nil {
+		return 0, false, c.wrap(OpGeoDist, key, err)
+	}
+
+	lon1, lat1, ok1 := geoPosition(z, member1)
+	lon2, lat2, ok2 := geoPosition(z, member2)
+
+	if !ok1 || !ok2 {
+		return 0, false, nil
+	}
+
+	return geoDistance(geohash.Distance(lon1, lat1, lon2, lat2) / meters), true, nil
+}
+
+// GeoSearch returns the members of the geospatial index stored at key within the area
+// of query, with their position and distance, sorted by distance. It returns ErrNotFound
+// if the center of the query is a missing member of an existing index.
+//
+// All members are scanned, while Redis only scans the members in the geohash areas
+// around the center: results are the same but searches are slower on large indexes.
+func (c *MemoryStore) GeoSearch(ctx context.Context, key string, query GeoSearchQuery) ([]GeoLocation, error) {
+	if err := checkGeoSearchQuery(query); err != nil {
+		return nil, c.wrap(OpGeoSearch, key, err)
+	}
+
+	meters, _ := query.Unit.meters()
+
+	defer c.lock(ctx)()
+
+	z, err := c.getSortedSet(key, false)
+	if err != nil || z == nil {
+		return nil, c.wrap(OpGeoSearch, key, err)
+	}
+
+	lon, lat := query.Longitude, query.Latitude
+	if query.Member != "" {
+		var ok bool
+		if lon, lat, ok = geoPosition(z, query.Member); !ok {
+			return nil, c.wrap(OpGeoSearch, key, fmt.Errorf("%w: member %q", ErrNotFound, query.Member))
+		}
+	}
+
+	var locations []GeoLocation
+	for member := range z.scores {
+		mlon, mlat, _ := geoPosition(z, member)
+
+		var (
+			distance float64
+			ok       bool
+		)
+
+		if query.Radius > 0 {
+			distance, ok = geohash.InRadius(lon, lat, query.Radius*meters, mlon, mlat)
+		} else {
+			distance, ok = geohash.InBox(lon, lat, query.Width*meters, query.Height*meters, mlon, mlat)
+		}
+
+		if ok {
+			locations = append(locations, GeoLocation{
+				Member:    member,
+				Longitude: geoCoordinate(mlon),
+				Latitude:  geoCoordinate(mlat),
+				Dist:      distance / meters,
+			})
+		}
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		a, b := locations[i], locations[j]
+		if query.Descending {
+			a, b = b, a
+		}
+
+		if a.Dist != b.Dist {
+			return a.Dist < b.Dist
+		}
+		return a.Member < b.Member
+	})
+
+	if query.Count > 0 && len(locations) > query.Count {
+		locations = locations[:query.Count]
+	}
+
+	for i := range locations {
+		locations[i].Dist = geoDistance(locations[i].Dist)
+	}
+
+	return locations, nil
+}
+
+var _ GeoStore = &MemoryStore{}
@@ -0,0 +1,270 @@
+package gokvstores
+
+import (
+	"context"
+	"time"
+)
+
+// deque is the value of list keys in MemoryStore.
+// It is a ring buffer mutated in place, under MemoryStore.mu.
+type deque struct {
+	values []string
+	head   int
+	length int
+}
+
+// at returns the value at index i, which must be lower than d.length.
+func (d *deque) at(i int) string {
+	return d.values[(d.head+i)%len(d.values)]
+}
+
+// grow makes room for one more value.
+func (d *deque) grow() {
+	if d.length < len(d.values) {
+		return
+	}
+
+	size := 2 * len(d.values)
+	if size == 0 {
+		size = 8
+	}
+
+	values := make([]string, size)
+	for i := 0; i < d.length; i++ {
+		values[i] = d.at(i)
+	}
+
+	d.values = values
+	d.head = 0
+}
+
+func (d *deque) pushFront(value string) {
+	d.grow()
+	d.head = (d.head - 1 + len(d.values)) % len(d.values)
+	d.values[d.head] = value
+	d.length++
+}
+
+func (d *deque) pushBack(value string) {
+	d.grow()
+	d.values[(d.head+d.length)%len(d.values)] = value
+	d.length++
+}
+
+// popFront removes and returns the first value, the deque must not be empty.
+func (d *deque) popFront() string {
+	value := d.values[d.head]
+	d.values[d.head] = ""
+	d.head = (d.head + 1) % len(d.values)
+	d.length--
+
+	return value
+}
+
+// popBack removes and returns the last value, the deque must not be empty.
+func (d *deque) popBack() string {
+	i := (d.head + d.length - 1) % len(d.values)
+	value := d.values[i]
+	d.values[i] = ""
+	d.length--
+
+	return value
+}
+
+// getList returns the list stored at key, or ErrWrongType if key holds another type of value.
+// If the key does not exist, it returns nil or a new list when create is true.
+// It must be called with c.mu held.
+func (c *MemoryStore) getList(key string, create bool) (*deque, error) {
+	v, found := c.cache.Get(key)
+	if !found {
+		if !create {
+			return nil, nil
+		}
+
+		d := &deque{}
+		c.create(key, d)
+
+		return d, nil
+	}
+
+	d, ok := v.(*deque)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return d, nil
+}
+
+// pop removes and returns a value of the list stored at key, deleting the key once the list is empty.
+// It must be called with c.mu held.
+func (c *MemoryStore) pop(key string, front bool) (string, bool, error) {
+	d, err := c.getList(key, false)
+	if err != nil || d == nil {
+		return "", false, err
+	}
+
+	var value string
+	if front {
+		value = d.popFront()
+	} else {
+		value = d.popBack()
+	}
+
+	c.notify(EventSet, key)
+
+	if d.length == 0 {
+		c.delete(key)
+	}
+
+	return value, true, nil
+}
+
+// wakeWaiters wakes up BlockingPop callers so that they check their context and timeout.
+func (c *MemoryStore) wakeWaiters() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pushed.Broadcast()
+}
+
+// PushLeft inserts the given values at the head of the list stored at key,
+// one after the other, and returns the length of the list.
+func (c *MemoryStore) PushLeft(ctx context.Context, key string, values ...string) (int, error) {
+	defer c.lock(ctx)()
+
+	d, err := c.getList(key, len(values) > 0)
+	if err != nil || d == nil {
+		return 0, c.wrap(OpPushLeft, key, err)
+	}
+
+	for _, value := range values {
+		d.pushFront(value)
+	}
+
+	if len(values) > 0 {
+		c.pushed.Broadcast()
+		c.notify(EventSet, key)
+	}
+
+	return d.length, nil
+}
+
+// PushRight inserts the given values at the tail of the list stored at key
+// and returns the length of the list.
+func (c *MemoryStore) PushRight(ctx context.Context, key string, values ...string) (int, error) {
+	defer c.lock(ctx)()
+
+	d, err := c.getList(key, len(values) > 0)
+	if err != nil || d == nil {
+		return 0, c.wrap(OpPushRight, key, err)
+	}
+
+	for _, value := range values {
+		d.pushBack(value)
+	}
+
+	if len(values) > 0 {
+		c.pushed.Broadcast()
+		c.notify(EventSet, key)
+	}
+
+	return d.length, nil
+}
+
+// PopLeft removes and returns the first value of the list stored at key.
+// It reports false if the list is empty.
+func (c *MemoryStore) PopLeft(ctx context.Context, key string) (string, bool, error) {
+	defer c.lock(ctx)()
+
+	value, ok, err := c.pop(key, true)
+	return value, ok, c.wrap(OpPopLeft, key, err)
+}
+
+// PopRight removes and returns the last value of the list stored at key.
+// It reports false if the list is empty.
+func (c *MemoryStore) PopRight(ctx context.Context, key string) (string, bool, error) {
+	defer c.lock(ctx)()
+
+	value, ok, err := c.pop(key, false)
+	return value, ok, c.wrap(OpPopRight, key, err)
+}
+
+// BlockingPop removes and returns the first value of the first non-empty list
+// among keys, along with its key. If all lists are empty, it waits for a value
+// to be pushed until timeout elapses, or indefinitely if timeout is 0, and returns
+// an empty key on timeout. It stops waiting and returns an error when ctx is done.
+func (c *MemoryStore) BlockingPop(ctx context.Context, timeout time.Duration, keys ...string) (string, string, error) {
+	defer c.lock(ctx)()
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+
+		timer := time.AfterFunc(timeout, c.wakeWaiters)
+		defer timer.Stop()
+	}
+
+	stop := context.AfterFunc(ctx, c.wakeWaiters)
+	defer stop()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", "", c.wrap(OpBlockingPop, firstKey(keys), err)
+		}
+
+		for _, key := range keys {
+			value, ok, err := c.pop(key, true)
+			if err != nil {
+				return "", "", c.wrap(OpBlockingPop, key, err)
+			}
+
+			if ok {
+				return key, value, nil
+			}
+		}
+
+		// Scripts run with the lock held: as in Redis, they do not block.
+		if c.scripting(ctx) || !deadline.IsZero() && !time.Now().Before(deadline) {
+			return "", "", nil
+		}
+
+		c.pushed.Wait()
+	}
+}
+
+// Len returns the length of the list stored at key.
+func (c *MemoryStore) Len(ctx context.Context, key string) (int, error) {
+	defer c.lock(ctx)()
+
+	d, err := c.getList(key, false)
+	if err != nil || d == nil {
+		return 0, c.wrap(OpLen, key, err)
+	}
+
+	return d.length, nil
+}
+
+// Range returns the values between the start and stop indexes, both included.
+// Negative indexes are offsets from the tail, -1 being the last value.
+func (c *MemoryStore) Range(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	defer c.lock(ctx)()
+
+	d, err := c.getList(key, false)
+	if err != nil || d == nil {
+		return nil, c.wrap(OpRange, key, err)
+	}
+
+	start, stop, ok := rangeIndexes(start, stop, d.length)
+	if !ok {
+		return nil, nil
+	}
+
+	values := make([]string, stop-start+1)
+	for i := range values {
+		values[i] = d.at(start + i)
+	}
+
+	return values, nil
+}
+
+var _ ListStore = &MemoryStore{}
@@ -0,0 +1,226 @@
+package gokvstores
+
+import (
+	"context"
+
+	"github.com/ulule/gokvstores/internal/hll"
+)
+
+// bloomFilter is the value of Bloom filter keys in MemoryStore.
+type bloomFilter struct {
+	m    uint32
+	k    uint32
+	bits []byte
+}
+
+func newBloomFilter(m uint32, k uint32) *bloomFilter {
+	return &bloomFilter{m: m, k: k, bits: make([]byte, (m+7)/8)}
+}
+
+// add sets the bits of item and reports whether one of them was not set.
+func (b *bloomFilter) add(item string) bool {
+	added := false
+	for _, p := range bloomPositions(item, b.m, b.k) {
+		mask := byte(0x80) >> (p % 8)
+		if b.bits[p/8]&mask == 0 {
+			b.bits[p/8] |= mask
+			added = true
+		}
+	}
+
+	return added
+}
+
+// exists reports whether all the bits of item are set.
+func (b *bloomFilter) exists(item string) bool {
+	for _, p := range bloomPositions(item, b.m, b.k) {
+		if b.bits[p/8]&(byte(0x80)>>(p%8)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getHyperLogLog returns the HyperLogLog stored at key, or ErrWrongType if key holds another
+// type of value. If the key does not exist, it returns nil or a new HyperLogLog when create is true.
+// It must be called with c.mu held.
+func (c *MemoryStore) getHyperLogLog(key string, create bool) (*hll.Sketch, error) {
+	v, found := c.cache.Get(key)
+	if !found {
+		if !create {
+			return nil, nil
+		}
+
+		s := hll.New()
+		c.create(key, s)
+
+		return s, nil
+	}
+
+	s, ok := v.(*hll.Sketch)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return s, nil
+}
+
+// getBloomFilter returns the Bloom filter stored at key, or ErrWrongType if key holds another
+// type of value, or nil if the key does not exist.
+// It must be called with c.mu held.
+func (c *MemoryStore) getBloomFilter(key string) (*bloomFilter, error) {
+	v, found := c.cache.Get(key)
+	if !found {
+		return nil, nil
+	}
+
+	b, ok := v.(*bloomFilter)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return b, nil
+}
+
+// PFAdd adds the given elements to the HyperLogLog stored at key, creating it if needed,
+// and reports whether its estimate changed.
+func (c *MemoryStore) PFAdd(ctx context.Context, key string, elements ...string) (bool, error) {
+	defer c.lock(ctx)()
+
+	_, found := c.cache.Get(key)
+
+	s, err := c.getHyperLogLog(key, true)
+	if err != nil {
+		return false, c.wrap(OpPFAdd, key, err)
+	}
+
+	// Like Redis, creating the HyperLogLog counts as a change.
+	changed := !found
+	for _, element := range elements {
+		if s.Add(element) {
+			changed = true
+		}
+	}
+
+	if changed {
+		c.notify(EventSet, key)
+	}
+
+	return changed, nil
+}
+
+// PFCount returns the estimated number of distinct elements added to the HyperLogLogs
+// stored at keys, i.e. the cardinality of their union. Missing keys are ignored.
+func (c *MemoryStore) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	defer c.lock(ctx)()
+
+	union := hll.New()
+	for _, key := range keys {
+		s, err := c.getHyperLogLog(key, false)
+		if err != nil {
+			return 0, c.wrap(OpPFCount, key, err)
+		}
+
+		if s != nil {
+			union.Merge(s)
+		}
+	}
+
+	return union.Count(), nil
+}
+
+// PFMerge stores at dest the union of the HyperLogLogs stored at dest and keys.
+func (c *MemoryStore) PFMerge(ctx context.Context, dest string, keys ...string) error {
+	defer c.lock(ctx)()
+
+	// Check all types before creating dest.
+	sources := make([]*hll.Sketch, 0, len(keys))
+	for _, key := range keys {
+		s, err := c.getHyperLogLog(key, false)
+		if err != nil {
+			return c.wrap(OpPFMerge, key, err)
+		}
+
+		if s != nil {
+			sources = append(sources, s)
+		}
+	}
+
+	d, err := c.getHyperLogLog(dest, true)
+	if err != nil {
+		return c.wrap(OpPFMerge, dest, err)
+	}
+
+	for _, s := range sources {
+		d.Merge(s)
+	}
+
+	c.notify(EventSet, dest)
+
+	return nil
+}
+
+// BFReserve creates an empty Bloom filter at key, sized for capacity items with the given
+// false positive rate between 0 and 1. It does nothing if key already holds a Bloom filter.
+func (c *MemoryStore) BFReserve(ctx context.Context, key string, capacity int, errorRate float64) error {
+	m, k, err := bloomSize(capacity, errorRate)
+	if err != nil {
+		return c.wrap(OpBFReserve, key, err)
+	}
+
+	defer c.lock(ctx)()
+
+	b, err := c.getBloomFilter(key)
+	if err != nil || b != nil {
+		return c.wrap(OpBFReserve, key, err)
+	}
+
+	c.create(key, newBloomFilter(m, k))
+	c.notify(EventSet, key)
+
+	return nil
+}
+
+// BFAdd adds item to the Bloom filter stored at key and reports whether it was added,
+// i.e. it was not probably in the filter already. Missing filters are created with
+// DefaultBloomCapacity and DefaultBloomErrorRate.
+func (c *MemoryStore) BFAdd(ctx context.Context, key string, item string) (bool, error) {
+	defer c.lock(ctx)()
+
+	b, err := c.getBloomFilter(key)
+	if err != nil {
+		return false, c.wrap(OpBFAdd, key, err)
+	}
+
+	if b == nil {
+		m, k, _ := bloomSize(DefaultBloomCapacity, DefaultBloomErrorRate)
+
+		b = newBloomFilter(m, k)
+		c.create(key, b)
+	}
+
+	added := b.add(item)
+	if added {
+		c.notify(EventSet, key)
+	}
+
+	return added, nil
+}
+
+// BFExists reports whether item was probably added to the Bloom filter stored at key.
+func (c *MemoryStore) BFExists(ctx context.Context, key string, item string) (bool, error) {
+	defer c.lock(ctx)()
+
+	b, err := c.getBloomFilter(key)
+	if err != nil || b == nil {
+		return false, c.wrap(OpBFExists, key, err)
+	}
+
+	return b.exists(item), nil
+}
+
+var (
+	_ HyperLogLogStore = &MemoryStore{}
+	_ BloomFilterStore = &MemoryStore{}
+)
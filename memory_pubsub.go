@@ -0,0 +1,114 @@
+package gokvstores
+
+import (
+	"context"
+
+	"github.com/ulule/gokvstores/internal/glob"
+)
+
+// subscription is a Subscribe or PSubscribe subscription to MemoryStore.
+type subscription struct {
+	// names are the subscribed channels, or patterns if pattern is true.
+	names    []string
+	pattern  bool
+	messages chan Message
+}
+
+// Publish publishes message on channel and returns the number of subscriptions
+// which received it.
+//
+// Messages are not delivered to subscriptions whose channel buffer is full,
+// as Redis disconnects subscribers which do not keep up.
+func (c *MemoryStore) Publish(ctx context.Context, channel string, message string) (int, error) {
+	defer c.lock(ctx)()
+
+	count := 0
+	for s := range c.subscriptions {
+		for _, name := range s.names {
+			msg := Message{Channel: channel, Payload: message}
+
+			if s.pattern {
+				if !glob.Match(name, channel) {
+					continue
+				}
+				msg.Pattern = name
+			} else if name != channel {
+				continue
+			}
+
+			select {
+			case s.messages <- msg:
+				count++
+			default:
+			}
+		}
+	}
+
+	return count, nil
+}
+
+// Subscribe subscribes to the given channels and returns the channel receiving
+// their messages, which is closed once ctx is done.
+func (c *MemoryStore) Subscribe(ctx context.Context, channels ...string) (<-chan Message, error) {
+	return c.subscribe(ctx, OpSubscribe, channels, false)
+}
+
+// PSubscribe subscribes to the channels matching the given glob-style patterns,
+// as KVStore.Keys, and returns the channel receiving their messages, which is
+// closed once ctx is done.
+func (c *MemoryStore) PSubscribe(ctx context.Context, patterns ...string) (<-chan Message, error) {
+	return c.subscribe(ctx, OpPSubscribe, patterns, true)
+}
+
+// subscribe registers a subscription until ctx is done.
+func (c *MemoryStore) subscribe(ctx context.Context, op string, names []string, pattern bool) (<-chan Message, error) {
+	if len(names) == 0 {
+		return nil, c.wrap(op, "", errNoChannels)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, c.wrap(op, firstKey(names), err)
+	}
+
+	s := &subscription{
+		names:    dedupe(names),
+		pattern:  pattern,
+		messages: make(chan Message, messageBufferSize),
+	}
+
+	unlock := c.lock(ctx)
+	if c.subscriptions == nil {
+		c.subscriptions = map[*subscription]struct{}{}
+	}
+	c.subscriptions[s] = struct{}{}
+	unlock()
+
+	context.AfterFunc(ctx, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		delete(c.subscriptions, s)
+		close(s.messages)
+	})
+
+	return s.messages, nil
+}
+
+// dedupe returns the given names without duplicates, as Redis subscribes once to each channel.
+func dedupe(names []string) []string {
+	seen := make(map[string]struct{}, len(names))
+
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, found := seen[name]; found {
+			continue
+		}
+
+		seen[name] = struct{}{}
+		unique = append(unique, name)
+	}
+
+	return unique
+}
+
+var _ PubSubStore = &MemoryStore{}
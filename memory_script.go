@@ -0,0 +1,60 @@
+package gokvstores
+
+import (
+	"context"
+	"fmt"
+)
+
+// scriptKey is the context key of the store running a script.
+type scriptKey struct{}
+
+// scripting reports whether ctx is the context of a script run by this store.
+func (c *MemoryStore) scripting(ctx context.Context) bool {
+	store, _ := ctx.Value(scriptKey{}).(*MemoryStore)
+	return store == c
+}
+
+// RegisterScript registers fn as the Go equivalent of script, so that the same logical
+// operation can be run by RunScript, e.g. in tests of code running scripts on a RedisStore.
+func (c *MemoryStore) RegisterScript(script *Script, fn ScriptFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.scripts == nil {
+		c.scripts = map[string]ScriptFunc{}
+	}
+	c.scripts[script.hash] = fn
+}
+
+// RunScript runs the function registered for script with the given keys and arguments,
+// with the store locked, and returns its result. Operations of other goroutines wait for
+// the end of the script.
+func (c *MemoryStore) RunScript(ctx context.Context, script *Script, keys []string, args ...interface{}) *ScriptResult {
+	key := firstKey(keys)
+
+	strs, err := scriptArgs(args)
+	if err != nil {
+		return &ScriptResult{err: c.wrap(OpRunScript, key, err)}
+	}
+
+	defer c.lock(ctx)()
+
+	fn, found := c.scripts[script.hash]
+	if !found {
+		return &ScriptResult{err: c.wrap(OpRunScript, key, fmt.Errorf("gokvstores: script %s is not registered", script.hash))}
+	}
+
+	val, err := fn(context.WithValue(ctx, scriptKey{}, c), c, keys, strs)
+	if err != nil {
+		return &ScriptResult{err: c.wrap(OpRunScript, key, err)}
+	}
+
+	val, err = scriptValue(val)
+	if err != nil {
+		return &ScriptResult{err: c.wrap(OpRunScript, key, err)}
+	}
+
+	return &ScriptResult{val: val}
+}
+
+var _ ScriptStore = &MemoryStore{}
@@ -0,0 +1,236 @@
+package gokvstores
+
+import (
+	"context"
+
+	"github.com/ulule/gokvstores/internal/skiplist"
+)
+
+// sortedSet is the value of sorted set keys in MemoryStore.
+// It is mutated in place, under MemoryStore.mu.
+type sortedSet struct {
+	scores map[string]float64
+	list   *skiplist.List
+}
+
+func newSortedSet() *sortedSet {
+	return &sortedSet{
+		scores: map[string]float64{},
+		list:   skiplist.New(),
+	}
+}
+
+// add adds member or updates its score, and reports whether it was added.
+func (z *sortedSet) add(member string, score float64) bool {
+	old, found := z.scores[member]
+	if found {
+		if old == score {
+			return false
+		}
+		z.list.Delete(old, member)
+	}
+
+	z.scores[member] = score
+	z.list.Insert(score, member)
+
+	return !found
+}
+
+// rem removes member and reports whether it was a member.
+func (z *sortedSet) rem(member string) bool {
+	score, found := z.scores[member]
+	if !found {
+		return false
+	}
+
+	delete(z.scores, member)
+	z.list.Delete(score, member)
+
+	return true
+}
+
+// getSortedSet returns the sorted set stored at key, or ErrWrongType if key holds another type of value.
+// If the key does not exist, it returns nil or a new sorted set when create is true.
+// It must be called with c.mu held.
+func (c *MemoryStore) getSortedSet(key string, create bool) (*sortedSet, error) {
+	v, found := c.cache.Get(key)
+	if !found {
+		if !create {
+			return nil, nil
+		}
+
+		z := newSortedSet()
+		c.create(key, z)
+
+		return z, nil
+	}
+
+	z, ok := v.(*sortedSet)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return z, nil
+}
+
+// ZAdd adds the given members to the sorted set stored at key, or updates their score,
+// and returns the number of added members.
+func (c *MemoryStore) ZAdd(ctx context.Context, key string, members ...Z) (int, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	defer c.lock(ctx)()
+
+	z, err := c.getSortedSet(key, true)
+	if err != nil {
+		return 0, c.wrap(OpZAdd, key, err)
+	}
+
+	count := 0
+	for _, m := range members {
+		if z.add(m.Member, m.Score) {
+			count++
+		}
+	}
+
+	c.notify(EventSet, key)
+
+	return count, nil
+}
+
+// ZIncrBy increments the score of member in the sorted set stored at key,
+// adding it if needed, and returns its new score.
+func (c *MemoryStore) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	defer c.lock(ctx)()
+
+	z, err := c.getSortedSet(key, true)
+	if err != nil {
+		return 0, c.wrap(OpZIncrBy, key, err)
+	}
+
+	score := z.scores[member] + increment
+	z.add(member, score)
+
+	c.notify(EventSet, key)
+
+	return score, nil
+}
+
+// ZRangeByScore returns the members with a score between min and max, both included,
+// by ascending score. It skips offset members and returns at most count members,
+// or all of them if count <= 0.
+func (c *MemoryStore) ZRangeByScore(ctx context.Context, key string, min float64, max float64, offset int, count int) ([]Z, error) {
+	defer c.lock(ctx)()
+
+	z, err := c.getSortedSet(key, false)
+	if err != nil || z == nil {
+		return nil, c.wrap(OpZRangeByScore, key, err)
+	}
+
+	var values []Z
+	for e := z.list.First(min); e != nil && e.Score <= max; e = e.Next() {
+		if offset > 0 {
+			offset--
+			continue
+		}
+
+		if count > 0 && len(values) == count {
+			break
+		}
+
+		values = append(values, Z{Member: e.Member, Score: e.Score})
+	}
+
+	return values, nil
+}
+
+// ZRevRange returns the members between the start and stop ranks, both included,
+// by descending score. Negative ranks are offsets from the lowest score, -1 being the last member.
+func (c *MemoryStore) ZRevRange(ctx context.Context, key string, start int, stop int) ([]Z, error) {
+	defer c.lock(ctx)()
+
+	z, err := c.getSortedSet(key, false)
+	if err != nil || z == nil {
+		return nil, c.wrap(OpZRevRange, key, err)
+	}
+
+	length := z.list.Len()
+
+	start, stop, ok := rangeIndexes(start, stop, length)
+	if !ok {
+		return nil, nil
+	}
+
+	// Ranks are reversed, so that the range is walked forward from its lowest score.
+	values := make([]Z, stop-start+1)
+	e := z.list.ByRank(length - 1 - stop)
+	for i := len(values) - 1; i >= 0; i-- {
+		values[i] = Z{Member: e.Member, Score: e.Score}
+		e = e.Next()
+	}
+
+	return values, nil
+}
+
+// ZRank returns the 0-based rank of member by ascending score, or -1 if it is not a member.
+func (c *MemoryStore) ZRank(ctx context.Context, key string, member string) (int, error) {
+	defer c.lock(ctx)()
+
+	z, err := c.getSortedSet(key, false)
+	if err != nil {
+		return 0, c.wrap(OpZRank, key, err)
+	}
+
+	if z == nil {
+		return -1, nil
+	}
+
+	score, found := z.scores[member]
+	if !found {
+		return -1, nil
+	}
+
+	return z.list.Rank(score, member), nil
+}
+
+// ZRem removes the given members and returns the number of removed members.
+func (c *MemoryStore) ZRem(ctx context.Context, key string, members ...string) (int, error) {
+	defer c.lock(ctx)()
+
+	z, err := c.getSortedSet(key, false)
+	if err != nil || z == nil {
+		return 0, c.wrap(OpZRem, key, err)
+	}
+
+	count := 0
+	for _, member := range members {
+		if z.rem(member) {
+			count++
+		}
+	}
+
+	if count > 0 {
+		c.notify(EventSet, key)
+	}
+
+	if z.list.Len() == 0 {
+		c.delete(key)
+	}
+
+	return count, nil
+}
+
+// ZCard returns the number of members of the sorted set stored at key.
+func (c *MemoryStore) ZCard(ctx context.Context, key string) (int, error) {
+	defer c.lock(ctx)()
+
+	z, err := c.getSortedSet(key, false)
+	if err != nil || z == nil {
+		return 0, c.wrap(OpZCard, key, err)
+	}
+
+	return z.list.Len(), nil
+}
+
+var _ SortedSetStore = &MemoryStore{}
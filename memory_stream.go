@@ -0,0 +1,438 @@
+package gokvstores
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errInvalidStreamID is returned when a stream ID cannot be parsed.
+var errInvalidStreamID = errors.New("gokvstores: invalid stream ID")
+
+// streamID is a stream message ID.
+type streamID struct {
+	ms  uint64
+	seq uint64
+}
+
+// parseStreamID parses an ID formatted as "<milliseconds>-<sequence>" or "<milliseconds>".
+func parseStreamID(s string) (streamID, error) {
+	ms, seq, found := strings.Cut(s, "-")
+
+	var (
+		id  streamID
+		err error
+	)
+
+	if id.ms, err = strconv.ParseUint(ms, 10, 64); err != nil {
+		return streamID{}, fmt.Errorf("%w: %q", errInvalidStreamID, s)
+	}
+
+	if found {
+		if id.seq, err = strconv.ParseUint(seq, 10, 64); err != nil {
+			return streamID{}, fmt.Errorf("%w: %q", errInvalidStreamID, s)
+		}
+	}
+
+	return id, nil
+}
+
+func (id streamID) String() string {
+	return strconv.FormatUint(id.ms, 10) + "-" + strconv.FormatUint(id.seq, 10)
+}
+
+func (id streamID) less(other streamID) bool {
+	return id.ms < other.ms || (id.ms == other.ms && id.seq < other.seq)
+}
+
+// streamEntry is a message of a stream in MemoryStore.
+type streamEntry struct {
+	id     streamID
+	values map[string]string
+}
+
+// pendingEntry is a message delivered to a consumer of a group but not acknowledged yet.
+type pendingEntry struct {
+	consumer   string
+	delivered  time.Time
+	deliveries int
+}
+
+// streamGroup is a consumer group of a stream in MemoryStore.
+type streamGroup struct {
+	lastID  streamID
+	pending map[streamID]*pendingEntry
+}
+
+// pendingIDs returns the IDs of the pending messages by ascending ID.
+func (g *streamGroup) pendingIDs() []streamID {
+	ids := make([]streamID, 0, len(g.pending))
+	for id := range g.pending {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].less(ids[j])
+	})
+
+	return ids
+}
+
+// stream is the value of stream keys in MemoryStore.
+// It is mutated in place, under MemoryStore.mu.
+type stream struct {
+	entries []streamEntry
+	lastID  streamID
+	groups  map[string]*streamGroup
+}
+
+// after returns the index of the first entry with an ID greater than id.
+func (s *stream) after(id streamID) int {
+	return sort.Search(len(s.entries), func(i int) bool {
+		return id.less(s.entries[i].id)
+	})
+}
+
+// entry returns the entry with the given ID, or nil if it was removed.
+func (s *stream) entry(id streamID) *streamEntry {
+	i := sort.Search(len(s.entries), func(i int) bool {
+		return !s.entries[i].id.less(id)
+	})
+
+	if i < len(s.entries) && s.entries[i].id == id {
+		return &s.entries[i]
+	}
+
+	return nil
+}
+
+// message returns a copy of the given entry, so that callers cannot mutate the stream.
+func (e *streamEntry) message() StreamMessage {
+	values := make(map[string]string, len(e.values))
+	for field, value := range e.values {
+		values[field] = value
+	}
+
+	return StreamMessage{ID: e.id.String(), Values: values}
+}
+
+// getStream returns the stream stored at key, or ErrWrongType if key holds another type of value.
+// If the key does not exist, it returns nil or a new stream when create is true.
+// It must be called with c.mu held.
+func (c *MemoryStore) getStream(key string, create bool) (*stream, error) {
+	v, found := c.cache.Get(key)
+	if !found {
+		if !create {
+			return nil, nil
+		}
+
+		s := &stream{groups: map[string]*streamGroup{}}
+		c.create(key, s)
+
+		return s, nil
+	}
+
+	s, ok := v.(*stream)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return s, nil
+}
+
+// getGroup returns the given consumer group of the stream stored at key, or ErrNoGroup.
+// It must be called with c.mu held.
+func (c *MemoryStore) getGroup(key string, group string) (*stream, *streamGroup, error) {
+	s, err := c.getStream(key, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if s == nil {
+		return nil, nil, ErrNoGroup
+	}
+
+	g, found := s.groups[group]
+	if !found {
+		return nil, nil, ErrNoGroup
+	}
+
+	return s, g, nil
+}
+
+// Append adds a message with the given values to the stream stored at key,
+// creating the stream if needed, and returns its ID.
+func (c *MemoryStore) Append(ctx context.Context, key string, values map[string]string) (string, error) {
+	if len(values) == 0 {
+		return "", c.wrap(OpAppend, key, errNoStreamValues)
+	}
+
+	defer c.lock(ctx)()
+
+	s, err := c.getStream(key, true)
+	if err != nil {
+		return "", c.wrap(OpAppend, key, err)
+	}
+
+	id := streamID{ms: uint64(time.Now().UnixMilli())}
+	if !s.lastID.less(id) {
+		id = streamID{ms: s.lastID.ms, seq: s.lastID.seq + 1}
+	}
+
+	entry := streamEntry{id: id, values: make(map[string]string, len(values))}
+	for field, value := range values {
+		entry.values[field] = value
+	}
+
+	s.entries = append(s.entries, entry)
+	s.lastID = id
+
+	c.pushed.Broadcast()
+	c.notify(EventSet, key)
+
+	return id.String(), nil
+}
+
+// Read returns the messages with an ID greater than id, "0" reading the stream from its start.
+// It returns at most count messages, or all of them if count <= 0.
+func (c *MemoryStore) Read(ctx context.Context, key string, id string, count int) ([]StreamMessage, error) {
+	after, err := parseStreamID(id)
+	if err != nil {
+		return nil, c.wrap(OpRead, key, err)
+	}
+
+	defer c.lock(ctx)()
+
+	s, err := c.getStream(key, false)
+	if err != nil || s == nil {
+		return nil, c.wrap(OpRead, key, err)
+	}
+
+	var messages []StreamMessage
+	for i := s.after(after); i < len(s.entries); i++ {
+		if count > 0 && len(messages) == count {
+			break
+		}
+
+		messages = append(messages, s.entries[i].message())
+	}
+
+	return messages, nil
+}
+
+// CreateGroup creates a consumer group which delivers the messages with an ID greater than id,
+// "0" delivering the whole stream and "$" only new messages. The stream is created if needed.
+// It does nothing if the group already exists.
+func (c *MemoryStore) CreateGroup(ctx context.Context, key string, group string, id string) error {
+	defer c.lock(ctx)()
+
+	s, err := c.getStream(key, true)
+	if err != nil {
+		return c.wrap(OpCreateGroup, key, err)
+	}
+
+	if _, found := s.groups[group]; found {
+		return nil
+	}
+
+	lastID := s.lastID
+	if id != "$" {
+		if lastID, err = parseStreamID(id); err != nil {
+			return c.wrap(OpCreateGroup, key, err)
+		}
+	}
+
+	s.groups[group] = &streamGroup{
+		lastID:  lastID,
+		pending: map[streamID]*pendingEntry{},
+	}
+
+	c.notify(EventSet, key)
+
+	return nil
+}
+
+// ReadGroup delivers to consumer at most count messages, or all of them if count <= 0,
+// never delivered to group. If there are none, it waits for new messages up to block,
+// or returns immediately if block <= 0. It returns ErrNoGroup if the group does not exist.
+func (c *MemoryStore) ReadGroup(ctx context.Context, key string, group string, consumer string, count int, block time.Duration) ([]StreamMessage, error) {
+	defer c.lock(ctx)()
+
+	var deadline time.Time
+	if block > 0 {
+		deadline = time.Now().Add(block)
+
+		timer := time.AfterFunc(block, c.wakeWaiters)
+		defer timer.Stop()
+
+		stop := context.AfterFunc(ctx, c.wakeWaiters)
+		defer stop()
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, c.wrap(OpReadGroup, key, err)
+		}
+
+		s, g, err := c.getGroup(key, group)
+		if err != nil {
+			return nil, c.wrap(OpReadGroup, key, err)
+		}
+
+		now := time.Now()
+
+		var messages []StreamMessage
+		for i := s.after(g.lastID); i < len(s.entries); i++ {
+			if count > 0 && len(messages) == count {
+				break
+			}
+
+			entry := &s.entries[i]
+			g.lastID = entry.id
+			g.pending[entry.id] = &pendingEntry{consumer: consumer, delivered: now, deliveries: 1}
+
+			messages = append(messages, entry.message())
+		}
+
+		// Scripts run with the lock held: as in Redis, they do not block.
+		if len(messages) > 0 || deadline.IsZero() || !now.Before(deadline) || c.scripting(ctx) {
+			return messages, nil
+		}
+
+		c.pushed.Wait()
+	}
+}
+
+// Ack acknowledges the given pending messages of group and returns the number of acknowledged messages.
+func (c *MemoryStore) Ack(ctx context.Context, key string, group string, ids ...string) (int, error) {
+	defer c.lock(ctx)()
+
+	_, g, err := c.getGroup(key, group)
+	if errors.Is(err, ErrNoGroup) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, c.wrap(OpAck, key, err)
+	}
+
+	count := 0
+	for _, s := range ids {
+		id, err := parseStreamID(s)
+		if err != nil {
+			return 0, c.wrap(OpAck, key, err)
+		}
+
+		if _, found := g.pending[id]; found {
+			delete(g.pending, id)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Pending returns the pending messages of group by ascending ID.
+// It returns at most count messages, or all of them if count <= 0.
+func (c *MemoryStore) Pending(ctx context.Context, key string, group string, count int) ([]PendingMessage, error) {
+	defer c.lock(ctx)()
+
+	_, g, err := c.getGroup(key, group)
+	if err != nil {
+		return nil, c.wrap(OpPending, key, err)
+	}
+
+	now := time.Now()
+
+	var pending []PendingMessage
+	for _, id := range g.pendingIDs() {
+		if count > 0 && len(pending) == count {
+			break
+		}
+
+		p := g.pending[id]
+		pending = append(pending, PendingMessage{
+			ID:         id.String(),
+			Consumer:   p.consumer,
+			Idle:       now.Sub(p.delivered),
+			Deliveries: p.deliveries,
+		})
+	}
+
+	return pending, nil
+}
+
+// Claim transfers to consumer at most count messages, or all of them if count <= 0,
+// pending in group for at least minIdle, and returns them. Pending messages which were
+// removed from the stream are not returned.
+//
+// As with Redis 7, pending messages which were removed from the stream are also
+// removed from the pending messages of the group.
+func (c *MemoryStore) Claim(ctx context.Context, key string, group string, consumer string, minIdle time.Duration, count int) ([]StreamMessage, error) {
+	defer c.lock(ctx)()
+
+	s, g, err := c.getGroup(key, group)
+	if err != nil {
+		return nil, c.wrap(OpClaim, key, err)
+	}
+
+	now := time.Now()
+
+	var messages []StreamMessage
+	for _, id := range g.pendingIDs() {
+		if count > 0 && len(messages) == count {
+			break
+		}
+
+		p := g.pending[id]
+		if now.Sub(p.delivered) < minIdle {
+			continue
+		}
+
+		entry := s.entry(id)
+		if entry == nil {
+			delete(g.pending, id)
+			continue
+		}
+
+		p.consumer = consumer
+		p.delivered = now
+		p.deliveries++
+
+		messages = append(messages, entry.message())
+	}
+
+	return messages, nil
+}
+
+// Trim removes the oldest messages of the stream stored at key so that it holds
+// at most maxLen messages, and returns the number of removed messages.
+func (c *MemoryStore) Trim(ctx context.Context, key string, maxLen int) (int, error) {
+	defer c.lock(ctx)()
+
+	s, err := c.getStream(key, false)
+	if err != nil || s == nil {
+		return 0, c.wrap(OpTrim, key, err)
+	}
+
+	if maxLen < 0 {
+		maxLen = 0
+	}
+
+	count := len(s.entries) - maxLen
+	if count <= 0 {
+		return 0, nil
+	}
+
+	s.entries = append([]streamEntry(nil), s.entries[count:]...)
+
+	c.notify(EventSet, key)
+
+	return count, nil
+}
+
+var _ StreamStore = &MemoryStore{}
@@ -1,15 +1,170 @@
-package gokvstores
+package gokvstores_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+	"github.com/ulule/gokvstores/kvstoretest"
 )
 
+// newMemoryStore returns a MemoryStore running the scripts of kvstoretest.
+func newMemoryStore(t *testing.T) gokvstores.KVStore {
+	store, err := gokvstores.NewMemoryStore(time.Second*10, time.Millisecond*10)
+	require.NoError(t, err)
+
+	for script, fn := range kvstoretest.ScriptFuncs {
+		store.(*gokvstores.MemoryStore).RegisterScript(script, fn)
+	}
+
+	return store
+}
+
 func TestMemoryStore(t *testing.T) {
-	store, err := NewMemoryStore(time.Second*10, time.Second*10)
-	assert.Nil(t, err)
+	kvstoretest.RunConformance(t, newMemoryStore)
+}
+
+func TestMemoryStore_Capabilities(t *testing.T) {
+	runCapabilityConformance(t, newMemoryStore)
+}
+
+func TestMemoryStore_RunScript(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store := newMemoryStore(t).(*gokvstores.MemoryStore)
+
+	// Store methods run under the lock held by the script.
+	script := gokvstores.NewScript("-- push and pop")
+	store.RegisterScript(script, func(ctx context.Context, kvstore gokvstores.KVStore, keys []string, args []string) (interface{}, error) {
+		lstore := kvstore.(gokvstores.ListStore)
+
+		if _, err := lstore.PushRight(ctx, keys[0], args...); err != nil {
+			return nil, err
+		}
+
+		// Blocking operations do not block.
+		_, value, err := lstore.BlockingPop(ctx, 0, keys[1], keys[0])
+		if err != nil {
+			return nil, err
+		}
+
+		_, empty, err := lstore.BlockingPop(ctx, 0, keys[1])
+		if err != nil {
+			return nil, err
+		}
+
+		length, err := lstore.Len(ctx, keys[0])
+		return []interface{}{value, empty, length}, err
+	})
+
+	values, err := store.RunScript(ctx, script, []string{"list", "empty"}, "a", "b").Slice()
+	is.NoError(err)
+	is.Equal([]interface{}{"a", "", int64(1)}, values)
+
+	err = store.RunScript(ctx, gokvstores.NewScript("return 1"), []string{"key"}).Err()
+	is.Error(err)
+
+	var opErr *gokvstores.OpError
+	is.True(errors.As(err, &opErr))
+	is.Equal(gokvstores.OpRunScript, opErr.Op)
+	is.Equal("key", opErr.Key)
+
+	// Errors of scripts are returned.
+	script = gokvstores.NewScript("-- fail")
+	store.RegisterScript(script, func(ctx context.Context, kvstore gokvstores.KVStore, keys []string, args []string) (interface{}, error) {
+		return kvstore.(gokvstores.ListStore).Len(ctx, keys[0])
+	})
+
+	is.NoError(store.Set(ctx, "key", "value"))
+	is.True(errors.Is(store.RunScript(ctx, script, []string{"key"}).Err(), gokvstores.ErrWrongType))
+
+	// Set and Delete calls of other goroutines wait for the end of the script.
+	started := make(chan struct{})
+	script = gokvstores.NewScript("-- get twice")
+	store.RegisterScript(script, func(ctx context.Context, kvstore gokvstores.KVStore, keys []string, args []string) (interface{}, error) {
+		before, err := kvstore.Get(ctx, keys[0])
+		if err != nil {
+			return nil, err
+		}
+
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+
+		after, err := kvstore.Get(ctx, keys[0])
+		return before == after, err
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		<-started
+		assert.NoError(t, store.Set(ctx, "key", "other"))
+		assert.NoError(t, store.Delete(ctx, "key"))
+	}()
+
+	unchanged, err := store.RunScript(ctx, script, []string{"key"}).Bool()
+	is.NoError(err)
+	is.True(unchanged)
+
+	<-done
+
+	v, err := store.Get(ctx, "key")
+	is.NoError(err)
+	is.Nil(v)
+}
+
+func TestMemoryStore_CapabilityExpiration(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	// The expiration is given in seconds.
+	store, err := gokvstores.NewMemoryStore(time.Nanosecond, time.Millisecond*10)
+	is.NoError(err)
+
+	// The store expiration only applies to KVStore values, as in RedisStore.
+	keys := createCapabilityKeys(t, store)
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	is.Eventually(func() bool {
+		exists, err := store.Exists(ctx, "key")
+		return err == nil && !exists
+	}, 5*time.Second, 10*time.Millisecond)
+
+	count, err := store.CountExisting(ctx, keys...)
+	is.NoError(err)
+	is.Equal(len(keys), count)
+}
+
+func TestMemoryStore_WrongType(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store := newMemoryStore(t)
+
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	_, err := store.GetMap(ctx, "key")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	var opErr *gokvstores.OpError
+	is.True(errors.As(err, &opErr))
+	is.Equal(gokvstores.OpGetMap, opErr.Op)
+	is.Equal("key", opErr.Key)
+	is.Equal(gokvstores.BackendMemory, opErr.Backend)
+
+	_, err = store.GetSlice(ctx, "key")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	is.True(errors.Is(store.AppendSlice(ctx, "key", "a"), gokvstores.ErrWrongType))
+	is.True(errors.Is(store.DeleteMap(ctx, "key", "field"), gokvstores.ErrWrongType))
 
-	testStore(t, store)
+	_, err = store.GetMaps(ctx, []string{"key"})
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
 }
@@ -0,0 +1,93 @@
+package gokvstores
+
+import (
+	"context"
+
+	"github.com/ulule/gokvstores/internal/glob"
+)
+
+// watcher is a Watch registration on MemoryStore.
+type watcher struct {
+	pattern string
+	events  chan Event
+}
+
+// Watch returns the channel receiving the events of the keys matching the given
+// glob-style pattern, as KVStore.Keys, which is closed once ctx is done.
+//
+// Expire events are sent when go-cache removes expired keys, every cleanup interval,
+// and never if the store has no cleanup interval. MemoryStore has no memory limit,
+// so it never sends EventEvict.
+func (c *MemoryStore) Watch(ctx context.Context, pattern string) (<-chan Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, c.wrap(OpWatch, pattern, err)
+	}
+
+	w := &watcher{
+		pattern: pattern,
+		events:  make(chan Event, messageBufferSize),
+	}
+
+	c.watchMu.Lock()
+	c.watchers[w] = struct{}{}
+	c.watchMu.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		c.watchMu.Lock()
+		defer c.watchMu.Unlock()
+
+		delete(c.watchers, w)
+		close(w.events)
+	})
+
+	return w.events, nil
+}
+
+// notify sends an event to the watchers of key.
+// Events are not sent to watchers whose channel buffer is full.
+func (c *MemoryStore) notify(typ EventType, key string) {
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+
+	for w := range c.watchers {
+		if !glob.Match(w.pattern, key) {
+			continue
+		}
+
+		select {
+		case w.events <- Event{Type: typ, Key: key}:
+		default:
+		}
+	}
+}
+
+// delete deletes key. The deletion is notified by evicted.
+func (c *MemoryStore) delete(key string) {
+	c.watchMu.Lock()
+	c.deleting[key]++
+	c.watchMu.Unlock()
+
+	c.cache.Delete(key)
+
+	c.watchMu.Lock()
+	if c.deleting[key]--; c.deleting[key] == 0 {
+		delete(c.deleting, key)
+	}
+	c.watchMu.Unlock()
+}
+
+// evicted is the go-cache OnEvicted hook, called when keys are deleted or expire.
+// Keys deleted by delete are notified as deleted, other keys as expired.
+func (c *MemoryStore) evicted(key string, value interface{}) {
+	c.watchMu.Lock()
+	_, deleting := c.deleting[key]
+	c.watchMu.Unlock()
+
+	if deleting {
+		c.notify(EventDelete, key)
+	} else {
+		c.notify(EventExpire, key)
+	}
+}
+
+var _ WatchStore = &MemoryStore{}
@@ -0,0 +1,105 @@
+package gokvstores
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/ulule/gokvstores/internal/murmur"
+)
+
+// HyperLogLogStore is implemented by stores supporting HyperLogLogs, which estimate the number
+// of distinct elements of large sets, e.g. unique visitors, in 12 KB with a standard error of 0.81%.
+// MemoryStore uses the hash function, registers and estimator of Redis, so that it is meant
+// to give the same estimates as Redis for the same elements.
+type HyperLogLogStore interface {
+	// PFAdd adds the given elements to the HyperLogLog stored at key, creating it if needed,
+	// and reports whether its estimate changed.
+	PFAdd(ctx context.Context, key string, elements ...string) (bool, error)
+
+	// PFCount returns the estimated number of distinct elements added to the HyperLogLogs
+	// stored at keys, i.e. the cardinality of their union. Missing keys are ignored.
+	PFCount(ctx context.Context, keys ...string) (int64, error)
+
+	// PFMerge stores at dest the union of the HyperLogLogs stored at dest and keys.
+	PFMerge(ctx context.Context, dest string, keys ...string) error
+}
+
+// BloomFilterStore is implemented by stores supporting Bloom filters, which tell whether an item
+// was probably added or definitely not added to a set, e.g. to skip lookups of unknown keys.
+// Filters have a fixed size computed from their expected number of items and false positive rate,
+// which is exceeded as more items are added. As the keys of the other capability interfaces,
+// filters do not expire: the store expiration only applies to the values set with KVStore.
+type BloomFilterStore interface {
+	// BFReserve creates an empty Bloom filter at key, sized for capacity items with the given
+	// false positive rate between 0 and 1. It does nothing if key already holds a Bloom filter.
+	BFReserve(ctx context.Context, key string, capacity int, errorRate float64) error
+
+	// BFAdd adds item to the Bloom filter stored at key and reports whether it was added,
+	// i.e. it was not probably in the filter already. Missing filters are created with
+	// DefaultBloomCapacity and DefaultBloomErrorRate.
+	BFAdd(ctx context.Context, key string, item string) (bool, error)
+
+	// BFExists reports whether item was probably added to the Bloom filter stored at key.
+	BFExists(ctx context.Context, key string, item string) (bool, error)
+}
+
+// Sizing of the Bloom filters created by BFAdd.
+const (
+	DefaultBloomCapacity  = 100
+	DefaultBloomErrorRate = 0.01
+)
+
+// Names of the HyperLogLogStore and BloomFilterStore operations.
+const (
+	OpPFAdd     = "PFAdd"
+	OpPFCount   = "PFCount"
+	OpPFMerge   = "PFMerge"
+	OpBFReserve = "BFReserve"
+	OpBFAdd     = "BFAdd"
+	OpBFExists  = "BFExists"
+)
+
+// maxBloomBits is the maximum size of a Bloom filter, a Redis string holding at most 512 MB.
+const maxBloomBits = 1<<32 - bloomHeaderSize*8
+
+// Seeds of the two hashes of Bloom filter items.
+const (
+	bloomSeed1 = 0x5bd1e995
+	bloomSeed2 = 0x1b873593
+)
+
+// bloomSize returns the number of bits and of hash functions of a Bloom filter holding
+// capacity items with the given false positive rate.
+func bloomSize(capacity int, errorRate float64) (uint32, uint32, error) {
+	if capacity <= 0 {
+		return 0, 0, fmt.Errorf("gokvstores: invalid Bloom filter capacity %d", capacity)
+	}
+
+	if !(errorRate > 0 && errorRate < 1) {
+		return 0, 0, fmt.Errorf("gokvstores: invalid Bloom filter error rate %v", errorRate)
+	}
+
+	bits := math.Ceil(-float64(capacity) * math.Log(errorRate) / (math.Ln2 * math.Ln2))
+	if bits > float64(maxBloomBits) {
+		return 0, 0, fmt.Errorf("gokvstores: Bloom filter of %d items with error rate %v is too large", capacity, errorRate)
+	}
+
+	hashes := math.Max(math.Round(bits/float64(capacity)*math.Ln2), 1)
+
+	return uint32(bits), uint32(hashes), nil
+}
+
+// bloomPositions returns the positions of the bits of item in a Bloom filter of m bits
+// and k hash functions, using double hashing.
+func bloomPositions(item string, m uint32, k uint32) []uint32 {
+	h1 := murmur.Hash64A([]byte(item), bloomSeed1)
+	h2 := murmur.Hash64A([]byte(item), bloomSeed2)
+
+	positions := make([]uint32, k)
+	for i := range positions {
+		positions[i] = uint32((h1 + uint64(i)*h2) % uint64(m))
+	}
+
+	return positions
+}
@@ -0,0 +1,51 @@
+package gokvstores
+
+import (
+	"context"
+	"errors"
+)
+
+// Message is a message received from a Pub/Sub subscription.
+type Message struct {
+	// Channel is the channel the message was published on.
+	Channel string
+
+	// Pattern is the pattern matching Channel for PSubscribe subscriptions.
+	Pattern string
+
+	// Payload is the published message.
+	Payload string
+}
+
+// PubSubStore is implemented by stores supporting Pub/Sub messaging, e.g. to broadcast
+// cache invalidations.
+//
+// Messages are delivered at most once: they are not persisted and subscribers not
+// connected when a message is published never receive it.
+type PubSubStore interface {
+	// Publish publishes message on channel and returns the number of subscriptions
+	// which received it.
+	Publish(ctx context.Context, channel string, message string) (int, error)
+
+	// Subscribe subscribes to the given channels and returns the channel receiving
+	// their messages, which is closed once ctx is done.
+	Subscribe(ctx context.Context, channels ...string) (<-chan Message, error)
+
+	// PSubscribe subscribes to the channels matching the given glob-style patterns,
+	// as KVStore.Keys, and returns the channel receiving their messages, which is
+	// closed once ctx is done.
+	PSubscribe(ctx context.Context, patterns ...string) (<-chan Message, error)
+}
+
+// Names of the PubSubStore operations.
+const (
+	OpPublish    = "Publish"
+	OpSubscribe  = "Subscribe"
+	OpPSubscribe = "PSubscribe"
+)
+
+// messageBufferSize is the size of the channels returned by Subscribe and PSubscribe.
+const messageBufferSize = 100
+
+// errNoChannels is returned when subscribing without channels or patterns.
+var errNoChannels = errors.New("gokvstores: no channel to subscribe to")
@@ -0,0 +1,258 @@
+package ratelimit
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ulule/gokvstores"
+)
+
+// params are the parameters of a limiter call, times being in microseconds.
+// They are the arguments of the Lua scripts, in this order.
+type params struct {
+	now    float64
+	cost   float64
+	rate   float64
+	period float64
+	burst  float64
+}
+
+// result is the result of a limiter call, times being in microseconds.
+// It is the reply of the Lua scripts, in this order.
+type result struct {
+	allowed    bool
+	remaining  float64
+	retryAfter float64
+	resetAfter float64
+}
+
+// algorithm is the implementation of an Algorithm.
+//
+// The Go and Lua implementations compute the same floating-point operations in the
+// same order, so that they give identical results. Go expressions which could be
+// fused into multiply-add instructions are converted explicitly to prevent it.
+type algorithm struct {
+	// step returns the result of a call given the stored state, which is nil if there
+	// is none, along with the state to store, or nil to keep it, and its time to live.
+	step func(state []float64, p params) ([]float64, result, float64)
+
+	// script is the Lua implementation of step, run by RedisStore. Its Go equivalent,
+	// run, is registered on MemoryStore.
+	script *gokvstores.Script
+}
+
+var algorithms = map[Algorithm]algorithm{
+	FixedWindow:          {fixedWindow, gokvstores.NewScript(fixedWindowSource)},
+	SlidingWindowLog:     {slidingWindowLog, gokvstores.NewScript(slidingWindowLogSource)},
+	SlidingWindowCounter: {slidingWindowCounter, gokvstores.NewScript(slidingWindowCounterSource)},
+	TokenBucket:          {tokenBucket, gokvstores.NewScript(tokenBucketSource)},
+	GCRA:                 {gcra, gokvstores.NewScript(gcraSource)},
+}
+
+// fixedWindow stores the window index and its count.
+func fixedWindow(state []float64, p params) ([]float64, result, float64) {
+	window := math.Floor(p.now / p.period)
+
+	count := 0.0
+	if len(state) == 2 && state[0] == window {
+		count = state[1]
+	}
+
+	reset := float64((window+1)*p.period) - p.now
+
+	if count+p.cost > p.rate {
+		retryAfter := reset
+		if p.cost > p.rate {
+			retryAfter = -1
+		}
+
+		if count == 0 {
+			reset = 0
+		}
+
+		return nil, result{false, p.rate - count, retryAfter, reset}, 0
+	}
+
+	count += p.cost
+
+	var next []float64
+	if p.cost > 0 {
+		next = []float64{window, count}
+	}
+
+	if count == 0 {
+		reset = 0
+	}
+
+	return next, result{true, p.rate - count, 0, reset}, reset
+}
+
+// slidingWindowLog stores the sorted times of the allowed events.
+func slidingWindowLog(state []float64, p params) ([]float64, result, float64) {
+	var times []float64
+	for _, t := range state {
+		if t > p.now-p.period {
+			times = append(times, t)
+		}
+	}
+
+	count := float64(len(times))
+
+	reset := 0.0
+	if len(times) > 0 {
+		reset = times[len(times)-1] + p.period - p.now
+	}
+
+	if count+p.cost > p.rate {
+		retryAfter := -1.0
+		if p.cost <= p.rate {
+			// The events are allowed once enough of the oldest events leave the window.
+			retryAfter = times[int(count+p.cost-p.rate)-1] + p.period - p.now
+		}
+
+		return nil, result{false, p.rate - count, retryAfter, reset}, 0
+	}
+
+	if p.cost == 0 {
+		return nil, result{true, p.rate - count, 0, reset}, 0
+	}
+
+	for i := 0; i < int(p.cost); i++ {
+		times = append(times, p.now)
+	}
+
+	sort.Float64s(times)
+
+	reset = math.Max(reset, p.period)
+
+	return times, result{true, p.rate - count - p.cost, 0, reset}, p.period
+}
+
+// slidingWindowCounter stores the window index, its count and the count of the previous window.
+func slidingWindowCounter(state []float64, p params) ([]float64, result, float64) {
+	window := math.Floor(p.now / p.period)
+
+	var count, previous float64
+	if len(state) == 3 {
+		switch state[0] {
+		case window:
+			count, previous = state[1], state[2]
+		case window - 1:
+			previous = state[1]
+		}
+	}
+
+	elapsed := p.now - float64(window*p.period)
+	used := count + math.Floor(previous*(p.period-elapsed)/p.period)
+
+	// wait returns the elapsed time in a window from which the events are allowed,
+	// given the counts of the window and of the previous one.
+	wait := func(previous float64, count float64) float64 {
+		allowance := p.rate - count - p.cost
+		if previous <= allowance {
+			return 0
+		}
+
+		return math.Floor(p.period-(allowance+1)*p.period/previous) + 1
+	}
+
+	resetAfter := func(count float64) float64 {
+		switch {
+		case count > 0:
+			return float64(2*p.period) - elapsed
+		case previous > 0:
+			return p.period - elapsed
+		default:
+			return 0
+		}
+	}
+
+	if used+p.cost > p.rate {
+		retryAfter := -1.0
+		if p.cost <= p.rate {
+			if count+p.cost <= p.rate {
+				retryAfter = wait(previous, count) - elapsed
+			} else {
+				retryAfter = p.period - elapsed + wait(count, 0)
+			}
+
+			retryAfter = math.Max(retryAfter, 1)
+		}
+
+		return nil, result{false, math.Max(p.rate-used, 0), retryAfter, resetAfter(count)}, 0
+	}
+
+	count += p.cost
+
+	var next []float64
+	if p.cost > 0 {
+		next = []float64{window, count, previous}
+	}
+
+	return next, result{true, math.Max(p.rate-used-p.cost, 0), 0, resetAfter(count)}, float64(2*p.period) - elapsed
+}
+
+// tokenBucket stores the number of tokens and the time they were counted.
+func tokenBucket(state []float64, p params) ([]float64, result, float64) {
+	tokens, last := p.burst, p.now
+	if len(state) == 2 {
+		tokens, last = state[0], state[1]
+	}
+
+	if p.now > last {
+		tokens = math.Min(p.burst, tokens+(p.now-last)*p.rate/p.period)
+	}
+
+	if tokens < p.cost {
+		retryAfter := -1.0
+		if p.cost <= p.burst {
+			retryAfter = math.Ceil((p.cost - tokens) * p.period / p.rate)
+		}
+
+		return nil, result{false, math.Floor(tokens), retryAfter, math.Ceil((p.burst - tokens) * p.period / p.rate)}, 0
+	}
+
+	tokens -= p.cost
+	reset := math.Ceil((p.burst - tokens) * p.period / p.rate)
+
+	var next []float64
+	if p.cost > 0 {
+		next = []float64{tokens, math.Max(p.now, last)}
+	}
+
+	return next, result{true, math.Floor(tokens), 0, reset}, reset
+}
+
+// gcra stores the theoretical arrival time of the next event.
+func gcra(state []float64, p params) ([]float64, result, float64) {
+	emission := p.period / p.rate
+	tolerance := emission * p.burst
+
+	tat := p.now
+	if len(state) == 1 && state[0] > p.now {
+		tat = state[0]
+	}
+
+	newTAT := tat + float64(emission*p.cost)
+	allowAt := newTAT - tolerance
+
+	if p.now < allowAt {
+		retryAfter := -1.0
+		if p.cost <= p.burst {
+			retryAfter = math.Ceil(allowAt - p.now)
+		}
+
+		remaining := math.Max(math.Floor((p.now-(tat-tolerance))/emission), 0)
+
+		return nil, result{false, remaining, retryAfter, math.Ceil(tat - p.now)}, 0
+	}
+
+	var next []float64
+	if p.cost > 0 {
+		next = []float64{newTAT}
+	}
+
+	reset := math.Ceil(newTAT - p.now)
+
+	return next, result{true, math.Floor((p.now - allowAt) / emission), 0, reset}, reset
+}
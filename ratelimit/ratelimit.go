@@ -0,0 +1,261 @@
+// Package ratelimit provides rate limiters storing their state in a gokvstores.KVStore.
+//
+//	limiter, err := ratelimit.New(store, ratelimit.Options{
+//		Algorithm: ratelimit.GCRA,
+//		Rate:      10,
+//		Period:    time.Second,
+//		Burst:     20,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//
+//	result, err := limiter.Allow(ctx, "user:"+userID)
+//	if err != nil {
+//		return err
+//	}
+//
+//	if !result.Allowed {
+//		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+//	}
+//
+// Every call runs a script of the store, so that calls are atomic whatever the number
+// of limiters and processes sharing the store. The store must implement
+// gokvstores.ScriptStore, as RedisStore, MemoryStore and the wrapper stores of gokvstores
+// do, the wrapper stores forwarding the scripts to the store they wrap. On a RedisStore,
+// the scripts are Lua scripts. On a MemoryStore, New registers their Go equivalents,
+// which run under the store lock.
+//
+// Times are computed with microsecond precision from the clock of the calling process,
+// so the clocks of processes sharing a Redis server must be synchronized.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/ulule/gokvstores"
+)
+
+// Algorithm is a rate limiting algorithm.
+type Algorithm int
+
+const (
+	// FixedWindow allows Rate events per window of Period, windows being aligned on
+	// multiples of Period. It allows bursts of up to twice Rate around window boundaries.
+	FixedWindow Algorithm = iota
+
+	// SlidingWindowLog allows Rate events over any Period, by storing the time of every
+	// allowed event. It is exact but stores up to Rate timestamps per key.
+	SlidingWindowLog
+
+	// SlidingWindowCounter allows about Rate events over any Period, by weighting
+	// the count of the previous window by its overlap with the sliding window.
+	SlidingWindowCounter
+
+	// TokenBucket allows events while the bucket holds tokens, the bucket holding up
+	// to Burst tokens and being refilled with Rate tokens per Period.
+	TokenBucket
+
+	// GCRA is the generic cell rate algorithm, which allows events spaced by Period / Rate
+	// with bursts of up to Burst events. It behaves as TokenBucket but stores a single timestamp.
+	GCRA
+)
+
+// String returns the name of the algorithm.
+func (a Algorithm) String() string {
+	switch a {
+	case FixedWindow:
+		return "fixed-window"
+	case SlidingWindowLog:
+		return "sliding-window-log"
+	case SlidingWindowCounter:
+		return "sliding-window-counter"
+	case TokenBucket:
+		return "token-bucket"
+	case GCRA:
+		return "gcra"
+	default:
+		return "unknown"
+	}
+}
+
+// OpAllow is the operation of the errors returned by Limiter calls.
+const OpAllow = "Allow"
+
+var (
+	errInvalidAlgorithm = errors.New("ratelimit: invalid algorithm")
+	errInvalidLimit     = errors.New("ratelimit: rate and period must be positive")
+	errInvalidCost      = errors.New("ratelimit: cost must not be negative")
+	errNoScripts        = errors.New("ratelimit: store does not run scripts")
+)
+
+// Options are Limiter options.
+type Options struct {
+	// Algorithm is the rate limiting algorithm. Defaults to FixedWindow.
+	Algorithm Algorithm
+
+	// Rate is the number of events allowed per Period.
+	Rate int
+
+	// Period is the duration over which Rate events are allowed, with microsecond precision.
+	Period time.Duration
+
+	// Burst is the maximum number of events allowed at once by TokenBucket and GCRA.
+	// Defaults to Rate. It is ignored by the other algorithms, which allow bursts of Rate events.
+	Burst int
+
+	// KeyPrefix prefixes the keys of the limiter state. Defaults to "ratelimit:".
+	// Limiters using different algorithms must not share keys.
+	KeyPrefix string
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Result is the result of a Limiter call.
+type Result struct {
+	// Allowed reports whether the events are allowed.
+	Allowed bool
+
+	// Limit is the maximum number of events allowed at once, Burst for TokenBucket
+	// and GCRA and Rate for the other algorithms.
+	Limit int
+
+	// Remaining is the number of events which would be allowed right after this call.
+	Remaining int
+
+	// RetryAfter is the time after which the events would be allowed, if they are not.
+	// It is 0 if they are allowed, and -1 if they never will be because they exceed Limit.
+	RetryAfter time.Duration
+
+	// ResetAfter is the time after which the limiter is back to its initial state,
+	// if no more events are allowed.
+	ResetAfter time.Duration
+}
+
+// Limiter limits the rate of events per key.
+type Limiter struct {
+	store     gokvstores.KVStore
+	scripts   gokvstores.ScriptStore
+	algorithm algorithm
+	options   Options
+}
+
+// New returns a Limiter storing its state in store, which must run scripts:
+// see the package documentation.
+func New(store gokvstores.KVStore, options Options) (*Limiter, error) {
+	algorithm, ok := algorithms[options.Algorithm]
+	if !ok {
+		return nil, errInvalidAlgorithm
+	}
+
+	if options.Rate <= 0 || options.Period < time.Microsecond {
+		return nil, errInvalidLimit
+	}
+
+	if options.Burst <= 0 {
+		options.Burst = options.Rate
+	}
+
+	if options.KeyPrefix == "" {
+		options.KeyPrefix = "ratelimit:"
+	}
+
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	// Wrapper stores only run scripts if the store at the bottom of the chain does.
+	scripts, ok := store.(gokvstores.ScriptStore)
+	if !ok {
+		return nil, errNoScripts
+	}
+
+	switch base := gokvstores.BaseStore(store).(type) {
+	case *gokvstores.MemoryStore:
+		base.RegisterScript(algorithm.script, algorithm.run)
+	case gokvstores.ScriptStore:
+	default:
+		return nil, errNoScripts
+	}
+
+	return &Limiter{
+		store:     store,
+		scripts:   scripts,
+		algorithm: algorithm,
+		options:   options,
+	}, nil
+}
+
+// Allow reports whether an event of key is allowed, and records it if so.
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN reports whether n events of key are allowed at once, and records them if so.
+// With n = 0, it returns the state of the limiter without changing it.
+func (l *Limiter) AllowN(ctx context.Context, key string, n int) (Result, error) {
+	if n < 0 {
+		return Result{}, errInvalidCost
+	}
+
+	p := params{
+		now:    float64(l.options.Now().UnixMicro()),
+		cost:   float64(n),
+		rate:   float64(l.options.Rate),
+		period: float64(l.options.Period.Microseconds()),
+		burst:  float64(l.options.Burst),
+	}
+
+	r, err := l.allow(ctx, l.options.KeyPrefix+key, p)
+	if err != nil {
+		return Result{}, err
+	}
+
+	limit := l.options.Rate
+	if l.options.Algorithm == TokenBucket || l.options.Algorithm == GCRA {
+		limit = l.options.Burst
+	}
+
+	res := Result{
+		Allowed:    r.allowed,
+		Limit:      limit,
+		Remaining:  int(r.remaining),
+		RetryAfter: microseconds(r.retryAfter),
+		ResetAfter: microseconds(r.resetAfter),
+	}
+
+	if r.retryAfter < 0 {
+		res.RetryAfter = -1
+	}
+
+	return res, nil
+}
+
+// allow runs the script of the limiter algorithm.
+func (l *Limiter) allow(ctx context.Context, key string, p params) (result, error) {
+	values, err := l.scripts.RunScript(ctx, l.algorithm.script, []string{key}, scriptArgs(p)...).Slice()
+	if err != nil {
+		return result{}, &gokvstores.OpError{Op: OpAllow, Key: key, Backend: gokvstores.BackendName(l.store), Err: err}
+	}
+
+	r, err := parseResult(values)
+	if err != nil {
+		return result{}, &gokvstores.OpError{Op: OpAllow, Key: key, Backend: gokvstores.BackendName(l.store), Err: err}
+	}
+
+	return r, nil
+}
+
+// Reset removes the state of key, allowing its events again.
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.store.Delete(ctx, l.options.KeyPrefix+key)
+}
+
+// microseconds returns the duration of the given number of microseconds.
+func microseconds(us float64) time.Duration {
+	return time.Duration(math.Max(us, 0)) * time.Microsecond
+}
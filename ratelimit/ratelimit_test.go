@@ -0,0 +1,559 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+	"github.com/ulule/gokvstores/redistest"
+)
+
+// newRedisStore returns a RedisStore on the server at REDIS_ADDR, or else on an in-process
+// server. Since it cannot run Lua, the in-process server runs emulations of the scripts
+// computing their results with the Go algorithms: without REDIS_ADDR, the Lua scripts are
+// not run at all, and the Redis rows of the tests only check the commands and key types.
+func newRedisStore(t *testing.T) gokvstores.KVStore {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		server, err := redistest.NewServer()
+		require.NoError(t, err)
+		t.Cleanup(func() { server.Close() })
+
+		for algorithm, a := range algorithms {
+			server.RegisterScript(a.script.Source(), emulations[algorithm])
+		}
+
+		addr = server.Addr()
+	}
+
+	store, err := gokvstores.NewRedisClientStore(context.Background(), &gokvstores.RedisClientOptions{Addr: addr}, time.Minute)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	return store
+}
+
+// emulations are the emulations of the scripts for redistest. They issue the same commands
+// on the same key types as the scripts, but compute the results with the Go algorithms.
+var emulations = map[Algorithm]redistest.ScriptFunc{
+	FixedWindow:          emulateHash(FixedWindow, "window", "count"),
+	SlidingWindowLog:     emulateSlidingWindowLog,
+	SlidingWindowCounter: emulateHash(SlidingWindowCounter, "window", "count", "previous"),
+	TokenBucket:          emulateHash(TokenBucket, "tokens", "last"),
+	GCRA:                 emulateGCRA,
+}
+
+// emulateHash returns the emulation of the script of an algorithm storing its state
+// in the given hash fields.
+func emulateHash(algorithm Algorithm, fields ...string) redistest.ScriptFunc {
+	return func(call func(args ...string) (interface{}, error), keys []string, args []string) (interface{}, error) {
+		p, err := parseParams(args)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := call(append([]string{"HMGET", keys[0]}, fields...)...)
+		if err != nil {
+			return nil, err
+		}
+
+		next, r, ttl := algorithms[algorithm].step(luaNumbers(values.([]interface{})), p)
+		if next != nil {
+			hset := []string{"HSET", keys[0]}
+			for i, field := range fields {
+				hset = append(hset, field, luaNumber(next[i]))
+			}
+
+			if _, err := call(hset...); err != nil {
+				return nil, err
+			}
+
+			if _, err := call("PEXPIRE", keys[0], luaTTL(ttl)); err != nil {
+				return nil, err
+			}
+		}
+
+		return reply(r), nil
+	}
+}
+
+// emulateSlidingWindowLog emulates the script of SlidingWindowLog, storing the times of
+// the allowed events as the scores of a sorted set.
+func emulateSlidingWindowLog(call func(args ...string) (interface{}, error), keys []string, args []string) (interface{}, error) {
+	p, err := parseParams(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := call("ZREMRANGEBYSCORE", keys[0], "-inf", luaNumber(p.now-p.period)); err != nil {
+		return nil, err
+	}
+
+	values, err := call("ZRANGE", keys[0], "0", "-1", "WITHSCORES")
+	if err != nil {
+		return nil, err
+	}
+
+	var scores []interface{}
+	for i, v := range values.([]interface{}) {
+		if i%2 == 1 {
+			scores = append(scores, v)
+		}
+	}
+
+	next, r, ttl := slidingWindowLog(luaNumbers(scores), p)
+	if next != nil {
+		for i := 1; i <= int(p.cost); i++ {
+			if _, err := call("ZADD", keys[0], luaNumber(p.now), args[0]+":"+strconv.Itoa(len(scores)+i)); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err := call("PEXPIRE", keys[0], luaTTL(ttl)); err != nil {
+			return nil, err
+		}
+	}
+
+	return reply(r), nil
+}
+
+// emulateGCRA emulates the script of GCRA, storing the theoretical arrival time as a string.
+func emulateGCRA(call func(args ...string) (interface{}, error), keys []string, args []string) (interface{}, error) {
+	p, err := parseParams(args)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := call("GET", keys[0])
+	if err != nil {
+		return nil, err
+	}
+
+	next, r, ttl := gcra(luaNumbers([]interface{}{value}), p)
+	if next != nil {
+		if _, err := call("SET", keys[0], luaNumber(next[0]), "PX", luaTTL(ttl)); err != nil {
+			return nil, err
+		}
+	}
+
+	return reply(r), nil
+}
+
+// luaNumbers parses the given replies as tonumber does, returning nil if one is nil
+// since the scripts then use their initial state.
+func luaNumbers(values []interface{}) []float64 {
+	numbers := make([]float64, len(values))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil
+		}
+
+		numbers[i], _ = strconv.ParseFloat(s, 64)
+	}
+
+	return numbers
+}
+
+// luaNumber formats v as Redis formats the Lua numbers passed to redis.call.
+func luaNumber(v float64) string {
+	return strconv.FormatFloat(v, 'g', 17, 64)
+}
+
+// luaTTL formats a time to live in microseconds as the milliseconds passed to PEXPIRE by the scripts.
+func luaTTL(ttl float64) string {
+	return luaNumber(math.Max(math.Ceil(ttl/1000), 1))
+}
+
+func newMemoryStore(t *testing.T) gokvstores.KVStore {
+	store, err := gokvstores.NewMemoryStore(time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	return store
+}
+
+var stores = []struct {
+	name string
+	new  func(t *testing.T) gokvstores.KVStore
+}{
+	{"Memory", newMemoryStore},
+	{redisName(), newRedisStore},
+}
+
+// redisName is the name of the rows of newRedisStore, which only run the Lua scripts
+// with REDIS_ADDR set.
+func redisName() string {
+	if os.Getenv("REDIS_ADDR") == "" {
+		return "RedisEmulation"
+	}
+	return "Redis"
+}
+
+// clock is a settable clock, starting on a minute boundary.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock {
+	return &clock{now: time.Unix(1700000040, 0)}
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *clock) Set(start time.Time, offset time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = start.Add(offset)
+}
+
+// step is a call at the given offset from the clock start.
+type step struct {
+	at   time.Duration
+	n    int
+	want Result
+}
+
+func allowed(limit int, remaining int, reset time.Duration) Result {
+	return Result{Allowed: true, Limit: limit, Remaining: remaining, ResetAfter: reset}
+}
+
+func denied(limit int, remaining int, retry time.Duration, reset time.Duration) Result {
+	return Result{Limit: limit, Remaining: remaining, RetryAfter: retry, ResetAfter: reset}
+}
+
+// matrix holds the expected results of every algorithm allowing 6 events per minute.
+var matrix = map[Algorithm][]step{
+	FixedWindow: {
+		{0, 1, allowed(6, 5, 60*time.Second)},
+		{10 * time.Second, 5, allowed(6, 0, 50*time.Second)},
+		{20 * time.Second, 1, denied(6, 0, 40*time.Second, 40*time.Second)},
+		{20 * time.Second, 7, denied(6, 0, -1, 40*time.Second)},
+		{60 * time.Second, 1, allowed(6, 5, 60*time.Second)},
+		{60 * time.Second, 0, allowed(6, 5, 60*time.Second)},
+	},
+	SlidingWindowLog: {
+		{0, 2, allowed(6, 4, 60*time.Second)},
+		{30 * time.Second, 4, allowed(6, 0, 60*time.Second)},
+		{40 * time.Second, 1, denied(6, 0, 20*time.Second, 50*time.Second)},
+		{40 * time.Second, 3, denied(6, 0, 50*time.Second, 50*time.Second)},
+		{60 * time.Second, 2, allowed(6, 0, 60*time.Second)},
+		{60 * time.Second, 7, denied(6, 0, -1, 60*time.Second)},
+	},
+	SlidingWindowCounter: {
+		{0, 4, allowed(6, 2, 120*time.Second)},
+		{60 * time.Second, 2, allowed(6, 0, 120*time.Second)},
+		{70 * time.Second, 2, denied(6, 1, 5*time.Second+time.Microsecond, 110*time.Second)},
+		{70 * time.Second, 5, denied(6, 1, 50*time.Second+time.Microsecond, 110*time.Second)},
+		{70 * time.Second, 7, denied(6, 1, -1, 110*time.Second)},
+		{180 * time.Second, 6, allowed(6, 0, 120*time.Second)},
+	},
+	TokenBucket: {
+		{0, 6, allowed(6, 0, 60*time.Second)},
+		{15 * time.Second, 1, allowed(6, 0, 55*time.Second)},
+		{15 * time.Second, 1, denied(6, 0, 5*time.Second, 55*time.Second)},
+		{15 * time.Second, 7, denied(6, 0, -1, 55*time.Second)},
+		{40 * time.Second, 3, allowed(6, 0, 60*time.Second)},
+		{40 * time.Second, 0, allowed(6, 0, 60*time.Second)},
+	},
+	GCRA: {
+		{0, 6, allowed(6, 0, 60*time.Second)},
+		{15 * time.Second, 1, allowed(6, 0, 55*time.Second)},
+		{15 * time.Second, 1, denied(6, 0, 5*time.Second, 55*time.Second)},
+		{15 * time.Second, 7, denied(6, 0, -1, 55*time.Second)},
+		{40 * time.Second, 3, allowed(6, 0, 60*time.Second)},
+		{40 * time.Second, 0, allowed(6, 0, 60*time.Second)},
+	},
+}
+
+func TestLimiter(t *testing.T) {
+	for _, s := range stores {
+		t.Run(s.name, func(t *testing.T) {
+			store := s.new(t)
+
+			for algorithm, steps := range matrix {
+				t.Run(algorithm.String(), func(t *testing.T) {
+					is := assert.New(t)
+					ctx := context.Background()
+					clock := newClock()
+					start := clock.Now()
+
+					limiter, err := New(store, Options{
+						Algorithm: algorithm,
+						Rate:      6,
+						Period:    time.Minute,
+						Now:       clock.Now,
+					})
+					require.NoError(t, err)
+
+					key := t.Name()
+					defer limiter.Reset(ctx, key)
+
+					for i, step := range steps {
+						clock.Set(start, step.at)
+
+						result, err := limiter.AllowN(ctx, key, step.n)
+						is.NoError(err)
+						is.Equal(step.want, result, "step %d", i)
+					}
+
+					is.NoError(limiter.Reset(ctx, key))
+
+					result, err := limiter.AllowN(ctx, key, 6)
+					is.NoError(err)
+					is.True(result.Allowed)
+				})
+			}
+		})
+	}
+}
+
+// TestLimiter_Stores checks that all stores give identical results for random calls.
+// It only compares the Lua scripts with the Go algorithms with REDIS_ADDR set: otherwise
+// it compares the Go algorithms with themselves.
+func TestLimiter_Stores(t *testing.T) {
+	ctx := context.Background()
+
+	for algorithm := range matrix {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			is := assert.New(t)
+			clock := newClock()
+			start := clock.Now()
+
+			limiters := make([]*Limiter, len(stores))
+			for i, s := range stores {
+				limiter, err := New(s.new(t), Options{
+					Algorithm: algorithm,
+					Rate:      7,
+					Period:    3 * time.Second,
+					Burst:     10,
+					Now:       clock.Now,
+				})
+				require.NoError(t, err)
+
+				limiters[i] = limiter
+			}
+
+			key := t.Name()
+			for _, limiter := range limiters {
+				defer limiter.Reset(ctx, key)
+			}
+
+			rnd := rand.New(rand.NewSource(1))
+
+			var offset time.Duration
+			for i := 0; i < 200; i++ {
+				offset += time.Duration(rnd.Int63n(int64(time.Second))) / time.Microsecond * time.Microsecond
+				clock.Set(start, offset)
+
+				n := rnd.Intn(4)
+
+				want, err := limiters[0].AllowN(ctx, key, n)
+				require.NoError(t, err)
+
+				for j, limiter := range limiters[1:] {
+					result, err := limiter.AllowN(ctx, key, n)
+					is.NoError(err)
+					is.Equal(want, result, "call %d on %s", i, stores[j+1].name)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiter_Concurrency(t *testing.T) {
+	for _, s := range stores {
+		t.Run(s.name, func(t *testing.T) {
+			is := assert.New(t)
+			ctx := context.Background()
+
+			limiter, err := New(s.new(t), Options{
+				Algorithm: FixedWindow,
+				Rate:      50,
+				Period:    time.Hour,
+			})
+			require.NoError(t, err)
+
+			key := t.Name()
+			defer limiter.Reset(ctx, key)
+
+			var (
+				wg    sync.WaitGroup
+				mu    sync.Mutex
+				count int
+			)
+
+			for i := 0; i < 100; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					result, err := limiter.Allow(ctx, key)
+					is.NoError(err)
+
+					if result.Allowed {
+						mu.Lock()
+						count++
+						mu.Unlock()
+					}
+				}()
+			}
+
+			wg.Wait()
+
+			is.Equal(50, count)
+		})
+	}
+}
+
+// TestLimiter_RedisState checks the type and expiration of the keys written by the scripts.
+func TestLimiter_RedisState(t *testing.T) {
+	store := newRedisStore(t).(*gokvstores.RedisStore)
+
+	for algorithm, typ := range map[Algorithm]string{
+		FixedWindow:          "hash",
+		SlidingWindowLog:     "zset",
+		SlidingWindowCounter: "hash",
+		TokenBucket:          "hash",
+		GCRA:                 "string",
+	} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			is := assert.New(t)
+			ctx := context.Background()
+
+			limiter, err := New(store, Options{Algorithm: algorithm, Rate: 6, Period: time.Minute})
+			require.NoError(t, err)
+
+			key := t.Name()
+			defer limiter.Reset(ctx, key)
+
+			result, err := limiter.AllowN(ctx, key, 2)
+			is.NoError(err)
+			is.True(result.Allowed)
+
+			is.Equal(typ, store.Client().Type(ctx, "ratelimit:"+key).Val())
+
+			// The sliding window counter keeps the count of the previous window.
+			ttl := store.Client().PTTL(ctx, "ratelimit:"+key).Val()
+			is.True(ttl > 0 && ttl <= 2*time.Minute, ttl)
+		})
+	}
+}
+
+// TestLimiter_WrappedRedisStore checks that the scripts run on wrapped Redis stores.
+func TestLimiter_WrappedRedisStore(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store := newRedisStore(t).(*gokvstores.RedisStore)
+	wrapped := gokvstores.NewRetryStore(gokvstores.NewLoggingStore(store, gokvstores.LoggingOptions{}), gokvstores.RetryPolicy{})
+
+	limiter, err := New(wrapped, Options{Rate: 6, Period: time.Minute})
+	require.NoError(t, err)
+
+	key := t.Name()
+	defer limiter.Reset(ctx, key)
+
+	result, err := limiter.Allow(ctx, key)
+	is.NoError(err)
+	is.True(result.Allowed)
+
+	is.Equal("hash", store.Client().Type(ctx, "ratelimit:"+key).Val())
+}
+
+// TestLimiter_SharedMemoryStore checks that limiters sharing a MemoryStore, directly or
+// through a wrapper store, share their state atomically.
+func TestLimiter_SharedMemoryStore(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store := newMemoryStore(t)
+	wrapped := gokvstores.NewLoggingStore(store, gokvstores.LoggingOptions{})
+
+	var limiters []*Limiter
+	for _, s := range []gokvstores.KVStore{store, wrapped} {
+		limiter, err := New(s, Options{Rate: 50, Period: time.Hour})
+		require.NoError(t, err)
+
+		limiters = append(limiters, limiter)
+	}
+
+	key := t.Name()
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		count int
+	)
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func(limiter *Limiter) {
+			defer wg.Done()
+
+			result, err := limiter.Allow(ctx, key)
+			is.NoError(err)
+
+			if result.Allowed {
+				mu.Lock()
+				count++
+				mu.Unlock()
+			}
+		}(limiters[i%len(limiters)])
+	}
+
+	wg.Wait()
+
+	is.Equal(50, count)
+}
+
+func TestNew(t *testing.T) {
+	is := assert.New(t)
+	store := newMemoryStore(t)
+
+	_, err := New(store, Options{Rate: 0, Period: time.Second})
+	is.Error(err)
+
+	_, err = New(store, Options{Rate: 1, Period: time.Nanosecond})
+	is.Error(err)
+
+	_, err = New(store, Options{Algorithm: GCRA + 1, Rate: 1, Period: time.Second})
+	is.Error(err)
+
+	// Stores which do not run scripts, even behind wrapper stores running them, are refused.
+	for _, s := range []gokvstores.KVStore{
+		gokvstores.DummyStore{},
+		gokvstores.NewRecordingStore(store),
+		gokvstores.NewRetryStore(gokvstores.NewRecordingStore(newRedisStore(t)), gokvstores.RetryPolicy{}),
+	} {
+		_, err = New(s, Options{Rate: 1, Period: time.Second})
+		is.Equal(errNoScripts, err, "%T", s)
+	}
+
+	limiter, err := New(store, Options{Algorithm: TokenBucket, Rate: 1, Period: time.Second})
+	is.NoError(err)
+
+	result, err := limiter.Allow(context.Background(), "key")
+	is.NoError(err)
+	is.Equal(allowed(1, 0, time.Second), result)
+
+	_, err = limiter.AllowN(context.Background(), "key", -1)
+	is.Error(err)
+}
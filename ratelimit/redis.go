@@ -0,0 +1,207 @@
+package ratelimit
+
+// scriptParams declares the script parameters, passed as ARGV in the order of params.
+const scriptParams = `
+local now, cost, rate, period, burst = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3]), tonumber(ARGV[4]), tonumber(ARGV[5])
+`
+
+// The scripts below implement the Go functions of the same name: see them for the details.
+// Numbers passed to redis.call are formatted by Redis without loss of precision.
+//
+// The scripts are only tested against a Redis server, by running the tests with
+// REDIS_ADDR set (make test-redis): the default test run does not execute them.
+
+const fixedWindowSource = scriptParams + `
+local window = math.floor(now / period)
+
+local state = redis.call('HMGET', KEYS[1], 'window', 'count')
+local count = 0
+if tonumber(state[1]) == window then
+	count = tonumber(state[2])
+end
+
+local reset = (window + 1) * period - now
+
+if count + cost > rate then
+	local retry_after = reset
+	if cost > rate then
+		retry_after = -1
+	end
+
+	if count == 0 then
+		reset = 0
+	end
+
+	return {0, rate - count, retry_after, reset}
+end
+
+count = count + cost
+
+if cost > 0 then
+	redis.call('HSET', KEYS[1], 'window', window, 'count', count)
+	redis.call('PEXPIRE', KEYS[1], math.max(math.ceil(reset / 1000), 1))
+end
+
+if count == 0 then
+	reset = 0
+end
+
+return {1, rate - count, 0, reset}
+`
+
+const slidingWindowLogSource = scriptParams + `
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - period)
+
+local count = redis.call('ZCARD', KEYS[1])
+
+local reset = 0
+local last = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+if #last > 0 then
+	reset = tonumber(last[2]) + period - now
+end
+
+if count + cost > rate then
+	local retry_after = -1
+	if cost <= rate then
+		local index = count + cost - rate - 1
+		local oldest = redis.call('ZRANGE', KEYS[1], index, index, 'WITHSCORES')
+		retry_after = tonumber(oldest[2]) + period - now
+	end
+
+	return {0, rate - count, retry_after, reset}
+end
+
+if cost == 0 then
+	return {1, rate - count, 0, reset}
+end
+
+-- Members are unique since the count only grows for a given time.
+for i = 1, cost do
+	redis.call('ZADD', KEYS[1], now, ARGV[1] .. ':' .. (count + i))
+end
+redis.call('PEXPIRE', KEYS[1], math.max(math.ceil(period / 1000), 1))
+
+return {1, rate - count - cost, 0, math.max(reset, period)}
+`
+
+const slidingWindowCounterSource = scriptParams + `
+local window = math.floor(now / period)
+
+local state = redis.call('HMGET', KEYS[1], 'window', 'count', 'previous')
+local count, previous = 0, 0
+local stored = tonumber(state[1])
+if stored == window then
+	count, previous = tonumber(state[2]), tonumber(state[3])
+elseif stored == window - 1 then
+	previous = tonumber(state[2])
+end
+
+local elapsed = now - window * period
+local used = count + math.floor(previous * (period - elapsed) / period)
+
+local function wait(previous, count)
+	local allowance = rate - count - cost
+	if previous <= allowance then
+		return 0
+	end
+
+	return math.floor(period - (allowance + 1) * period / previous) + 1
+end
+
+local function reset_after(count)
+	if count > 0 then
+		return 2 * period - elapsed
+	elseif previous > 0 then
+		return period - elapsed
+	end
+
+	return 0
+end
+
+if used + cost > rate then
+	local retry_after = -1
+	if cost <= rate then
+		if count + cost <= rate then
+			retry_after = wait(previous, count) - elapsed
+		else
+			retry_after = period - elapsed + wait(count, 0)
+		end
+
+		retry_after = math.max(retry_after, 1)
+	end
+
+	return {0, math.max(rate - used, 0), retry_after, reset_after(count)}
+end
+
+count = count + cost
+
+if cost > 0 then
+	redis.call('HSET', KEYS[1], 'window', window, 'count', count, 'previous', previous)
+	redis.call('PEXPIRE', KEYS[1], math.max(math.ceil((2 * period - elapsed) / 1000), 1))
+end
+
+return {1, math.max(rate - used - cost, 0), 0, reset_after(count)}
+`
+
+const tokenBucketSource = scriptParams + `
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens, last = burst, now
+if state[1] then
+	tokens, last = tonumber(state[1]), tonumber(state[2])
+end
+
+if now > last then
+	tokens = math.min(burst, tokens + (now - last) * rate / period)
+end
+
+if tokens < cost then
+	local retry_after = -1
+	if cost <= burst then
+		retry_after = math.ceil((cost - tokens) * period / rate)
+	end
+
+	return {0, math.floor(tokens), retry_after, math.ceil((burst - tokens) * period / rate)}
+end
+
+tokens = tokens - cost
+local reset = math.ceil((burst - tokens) * period / rate)
+
+if cost > 0 then
+	redis.call('HSET', KEYS[1], 'tokens', tokens, 'last', math.max(now, last))
+	redis.call('PEXPIRE', KEYS[1], math.max(math.ceil(reset / 1000), 1))
+end
+
+return {1, math.floor(tokens), 0, reset}
+`
+
+const gcraSource = scriptParams + `
+local emission = period / rate
+local tolerance = emission * burst
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local new_tat = tat + emission * cost
+local allow_at = new_tat - tolerance
+
+if now < allow_at then
+	local retry_after = -1
+	if cost <= burst then
+		retry_after = math.ceil(allow_at - now)
+	end
+
+	local remaining = math.max(math.floor((now - (tat - tolerance)) / emission), 0)
+
+	return {0, remaining, retry_after, math.ceil(tat - now)}
+end
+
+local reset = math.ceil(new_tat - now)
+
+if cost > 0 then
+	redis.call('SET', KEYS[1], new_tat, 'PX', math.max(math.ceil(reset / 1000), 1))
+end
+
+return {1, math.floor((now - allow_at) / emission), 0, reset}
+`
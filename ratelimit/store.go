@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ulule/gokvstores"
+)
+
+// run is the Go equivalent of the script of the algorithm, registered on MemoryStore so
+// that it runs under the store lock, atomically as the script on Redis. The state is
+// stored as a string.
+func (a algorithm) run(ctx context.Context, store gokvstores.KVStore, keys []string, args []string) (interface{}, error) {
+	p, err := parseParams(args)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := store.Get(ctx, keys[0])
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := decodeState(value)
+	if err != nil {
+		return nil, err
+	}
+
+	next, r, ttl := a.step(state, p)
+	if next != nil {
+		expiration := time.Duration(math.Max(math.Ceil(ttl/1000), 1)) * time.Millisecond
+
+		if err := store.SetWithExpiration(ctx, keys[0], encodeState(next), expiration); err != nil {
+			return nil, err
+		}
+	}
+
+	return reply(r), nil
+}
+
+// scriptArgs returns the arguments of a script for the given parameters.
+func scriptArgs(p params) []interface{} {
+	return []interface{}{
+		strconv.FormatFloat(p.now, 'f', -1, 64),
+		strconv.FormatFloat(p.cost, 'f', -1, 64),
+		strconv.FormatFloat(p.rate, 'f', -1, 64),
+		strconv.FormatFloat(p.period, 'f', -1, 64),
+		strconv.FormatFloat(p.burst, 'f', -1, 64),
+	}
+}
+
+// parseParams parses the arguments of a script.
+func parseParams(args []string) (params, error) {
+	if len(args) != 5 {
+		return params{}, fmt.Errorf("ratelimit: unexpected script arguments %q", args)
+	}
+
+	var numbers [5]float64
+	for i := range numbers {
+		n, err := strconv.ParseFloat(args[i], 64)
+		if err != nil {
+			return params{}, fmt.Errorf("ratelimit: unexpected script arguments %q", args)
+		}
+
+		numbers[i] = n
+	}
+
+	return params{numbers[0], numbers[1], numbers[2], numbers[3], numbers[4]}, nil
+}
+
+// reply returns the reply of a script for the given result, numbers being truncated
+// as Redis converts Lua numbers.
+func reply(r result) []interface{} {
+	allowed := int64(0)
+	if r.allowed {
+		allowed = 1
+	}
+
+	return []interface{}{allowed, int64(r.remaining), int64(r.retryAfter), int64(r.resetAfter)}
+}
+
+// parseResult parses the reply of a script.
+func parseResult(values []interface{}) (result, error) {
+	if len(values) != 4 {
+		return result{}, fmt.Errorf("ratelimit: unexpected script reply %v", values)
+	}
+
+	var numbers [4]float64
+	for i, v := range values {
+		n, ok := v.(int64)
+		if !ok {
+			return result{}, fmt.Errorf("ratelimit: unexpected script reply %v", values)
+		}
+
+		numbers[i] = float64(n)
+	}
+
+	return result{
+		allowed:    numbers[0] == 1,
+		remaining:  numbers[1],
+		retryAfter: numbers[2],
+		resetAfter: numbers[3],
+	}, nil
+}
+
+// encodeState formats the given state as space-separated numbers.
+func encodeState(state []float64) string {
+	values := make([]string, len(state))
+	for i, v := range state {
+		values[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	return strings.Join(values, " ")
+}
+
+// decodeState parses a state formatted by encodeState, or returns nil if value is nil.
+func decodeState(value interface{}) ([]float64, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("ratelimit: invalid state %v", value)
+	}
+
+	fields := strings.Fields(s)
+
+	state := make([]float64, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("ratelimit: invalid state %q", s)
+		}
+
+		state[i] = v
+	}
+
+	return state, nil
+}
@@ -0,0 +1,656 @@
+package gokvstores
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// AnyArg matches any argument in expectations and call assertions.
+var AnyArg = anyArg{}
+
+type anyArg struct{}
+
+// TestingT is the subset of testing.TB used by RecordingStore assertions.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// Call is a KVStore call recorded by RecordingStore.
+type Call struct {
+	// Op is the name of the called method (see the Op constants).
+	Op string
+
+	// Args are the method arguments, context excluded.
+	// Variadic arguments are recorded as a single slice.
+	Args []interface{}
+
+	// Results are the method results, error excluded.
+	Results []interface{}
+
+	// Err is the error returned by the method.
+	Err error
+
+	// Start is the time the call started.
+	Start time.Time
+
+	// Duration is the duration of the call.
+	Duration time.Duration
+}
+
+// Expectation is an expected call set up on a RecordingStore.
+type Expectation struct {
+	op      string
+	args    []interface{}
+	results []interface{}
+	err     error
+	stubbed bool
+	times   int
+	calls   int
+}
+
+// Return sets the results returned by matching calls, the error included last.
+// It panics if a result is not nil nor of the type returned by the operation.
+//
+//	store.ExpectGet("key").Return("value", nil)
+//	store.ExpectSet("key", "value").Return(errors.New("read only"))
+func (e *Expectation) Return(values ...interface{}) *Expectation {
+	types, ok := opResults[e.op]
+	if !ok {
+		panic(fmt.Sprintf("gokvstores: unknown operation %q", e.op))
+	}
+
+	count := len(types)
+	if len(values) != count+1 {
+		panic(fmt.Sprintf("gokvstores: %s returns %d values, got %d", e.op, count+1, len(values)))
+	}
+
+	// Nil results are returned as zero values.
+	for i, typ := range types {
+		if v := values[i]; v != nil && !reflect.TypeOf(v).AssignableTo(typ) {
+			panic(fmt.Sprintf("gokvstores: value %d returned by %s must be a %s, got %T", i+1, e.op, typ, v))
+		}
+	}
+
+	if last := values[count]; last != nil {
+		err, ok := last.(error)
+		if !ok {
+			panic(fmt.Sprintf("gokvstores: last value returned by %s must be an error, got %T", e.op, last))
+		}
+		e.err = err
+	}
+
+	e.results = values[:count]
+	e.stubbed = true
+
+	return e
+}
+
+// Times limits the expectation to the given number of calls.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// Once limits the expectation to a single call.
+func (e *Expectation) Once() *Expectation {
+	return e.Times(1)
+}
+
+// exhausted reports whether the expectation cannot match more calls.
+func (e *Expectation) exhausted() bool {
+	return e.times > 0 && e.calls >= e.times
+}
+
+// Result types of the operations.
+var (
+	anyType   = reflect.TypeOf((*interface{})(nil)).Elem()
+	boolType  = reflect.TypeOf(false)
+	intType   = reflect.TypeOf(0)
+	sliceType = reflect.TypeOf([]interface{}(nil))
+	mapType   = reflect.TypeOf(map[string]interface{}(nil))
+	mapsType  = reflect.TypeOf(map[string]map[string]interface{}(nil))
+)
+
+// opResults are the types of the results of each operation, error excluded.
+var opResults = map[string][]reflect.Type{
+	OpGet:               {anyType},
+	OpMGet:              {mapType},
+	OpSet:               {},
+	OpSetWithExpiration: {},
+	OpGetMap:            {mapType},
+	OpGetMaps:           {mapsType},
+	OpSetMap:            {},
+	OpSetMaps:           {},
+	OpDeleteMap:         {},
+	OpGetSlice:          {sliceType},
+	OpSetSlice:          {},
+	OpAppendSlice:       {},
+	OpExists:            {boolType},
+	OpExistsAll:         {boolType},
+	OpExistsAny:         {boolType},
+	OpCountExisting:     {intType},
+	OpDelete:            {},
+	OpFlush:             {},
+	OpKeys:              {sliceType},
+	OpClose:             {},
+}
+
+// RecordingStore is a KVStore recording every call, to assert in tests which keys
+// a component reads or writes.
+//
+// Calls matching an expectation return the expectation results. Other calls are
+// forwarded to the wrapped store, or return zero values when there is none.
+type RecordingStore struct {
+	store KVStore
+
+	mu           sync.Mutex
+	calls        []Call
+	expectations []*Expectation
+}
+
+// NewRecordingStore returns a RecordingStore wrapping the given store.
+// The store may be nil to use the RecordingStore standalone.
+func NewRecordingStore(store KVStore) *RecordingStore {
+	return &RecordingStore{store: store}
+}
+
+// Calls returns the recorded calls.
+func (r *RecordingStore) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	calls := make([]Call, len(r.calls))
+	copy(calls, r.calls)
+
+	return calls
+}
+
+// Reset removes recorded calls and expectations.
+func (r *RecordingStore) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = nil
+	r.expectations = nil
+}
+
+// Expect sets up an expected call to the given operation with the given arguments.
+// Use AnyArg to match any value of an argument.
+func (r *RecordingStore) Expect(op string, args ...interface{}) *Expectation {
+	if _, ok := opResults[op]; !ok {
+		panic(fmt.Sprintf("gokvstores: unknown operation %q", op))
+	}
+
+	e := &Expectation{op: op, args: args}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expectations = append(r.expectations, e)
+
+	return e
+}
+
+// ExpectGet sets up an expected Get call.
+func (r *RecordingStore) ExpectGet(key interface{}) *Expectation {
+	return r.Expect(OpGet, key)
+}
+
+// ExpectMGet sets up an expected MGet call.
+func (r *RecordingStore) ExpectMGet(keys interface{}) *Expectation {
+	return r.Expect(OpMGet, keys)
+}
+
+// ExpectSet sets up an expected Set call.
+func (r *RecordingStore) ExpectSet(key interface{}, value interface{}) *Expectation {
+	return r.Expect(OpSet, key, value)
+}
+
+// ExpectSetWithExpiration sets up an expected SetWithExpiration call.
+func (r *RecordingStore) ExpectSetWithExpiration(key interface{}, value interface{}, expiration interface{}) *Expectation {
+	return r.Expect(OpSetWithExpiration, key, value, expiration)
+}
+
+// ExpectGetMap sets up an expected GetMap call.
+func (r *RecordingStore) ExpectGetMap(key interface{}) *Expectation {
+	return r.Expect(OpGetMap, key)
+}
+
+// ExpectGetMaps sets up an expected GetMaps call.
+func (r *RecordingStore) ExpectGetMaps(keys interface{}) *Expectation {
+	return r.Expect(OpGetMaps, keys)
+}
+
+// ExpectSetMap sets up an expected SetMap call.
+func (r *RecordingStore) ExpectSetMap(key interface{}, value interface{}) *Expectation {
+	return r.Expect(OpSetMap, key, value)
+}
+
+// ExpectSetMaps sets up an expected SetMaps call.
+func (r *RecordingStore) ExpectSetMaps(maps interface{}) *Expectation {
+	return r.Expect(OpSetMaps, maps)
+}
+
+// ExpectDeleteMap sets up an expected DeleteMap call.
+func (r *RecordingStore) ExpectDeleteMap(key interface{}, fields interface{}) *Expectation {
+	return r.Expect(OpDeleteMap, key, fields)
+}
+
+// ExpectGetSlice sets up an expected GetSlice call.
+func (r *RecordingStore) ExpectGetSlice(key interface{}) *Expectation {
+	return r.Expect(OpGetSlice, key)
+}
+
+// ExpectSetSlice sets up an expected SetSlice call.
+func (r *RecordingStore) ExpectSetSlice(key interface{}, value interface{}) *Expectation {
+	return r.Expect(OpSetSlice, key, value)
+}
+
+// ExpectAppendSlice sets up an expected AppendSlice call.
+func (r *RecordingStore) ExpectAppendSlice(key interface{}, values interface{}) *Expectation {
+	return r.Expect(OpAppendSlice, key, values)
+}
+
+// ExpectExists sets up an expected Exists call.
+func (r *RecordingStore) ExpectExists(keys interface{}) *Expectation {
+	return r.Expect(OpExists, keys)
+}
+
+// ExpectExistsAll sets up an expected ExistsAll call.
+func (r *RecordingStore) ExpectExistsAll(keys interface{}) *Expectation {
+	return r.Expect(OpExistsAll, keys)
+}
+
+// ExpectExistsAny sets up an expected ExistsAny call.
+func (r *RecordingStore) ExpectExistsAny(keys interface{}) *Expectation {
+	return r.Expect(OpExistsAny, keys)
+}
+
+// ExpectCountExisting sets up an expected CountExisting call.
+func (r *RecordingStore) ExpectCountExisting(keys interface{}) *Expectation {
+	return r.Expect(OpCountExisting, keys)
+}
+
+// ExpectDelete sets up an expected Delete call.
+func (r *RecordingStore) ExpectDelete(key interface{}) *Expectation {
+	return r.Expect(OpDelete, key)
+}
+
+// ExpectFlush sets up an expected Flush call.
+func (r *RecordingStore) ExpectFlush() *Expectation {
+	return r.Expect(OpFlush)
+}
+
+// ExpectKeys sets up an expected Keys call.
+func (r *RecordingStore) ExpectKeys(pattern interface{}) *Expectation {
+	return r.Expect(OpKeys, pattern)
+}
+
+// ExpectClose sets up an expected Close call.
+func (r *RecordingStore) ExpectClose() *Expectation {
+	return r.Expect(OpClose)
+}
+
+// AssertCalled asserts that the given operation was called with the given arguments.
+// Without arguments, any call to the operation matches.
+func (r *RecordingStore) AssertCalled(t TestingT, op string, args ...interface{}) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	if len(r.matchingCalls(op, args)) == 0 {
+		t.Errorf("expected %s to be called with %v, calls were: %v", op, args, r.describeCalls(op))
+		return false
+	}
+
+	return true
+}
+
+// AssertNotCalled asserts that the given operation was not called with the given arguments.
+// Without arguments, no call to the operation must have been made.
+func (r *RecordingStore) AssertNotCalled(t TestingT, op string, args ...interface{}) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	if calls := r.matchingCalls(op, args); len(calls) > 0 {
+		t.Errorf("expected %s not to be called with %v, calls were: %v", op, args, r.describeCalls(op))
+		return false
+	}
+
+	return true
+}
+
+// AssertNumberOfCalls asserts that the given operation was called n times.
+func (r *RecordingStore) AssertNumberOfCalls(t TestingT, op string, n int) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	if count := len(r.matchingCalls(op, nil)); count != n {
+		t.Errorf("expected %s to be called %d times, got %d", op, n, count)
+		return false
+	}
+
+	return true
+}
+
+// AssertExpectations asserts that every expectation was met: expectations limited with
+// Times must have been called exactly that number of times, others at least once.
+func (r *RecordingStore) AssertExpectations(t TestingT) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ok := true
+
+	for _, e := range r.expectations {
+		switch {
+		case e.times > 0 && e.calls != e.times:
+			t.Errorf("expected %s with %v to be called %d times, got %d", e.op, e.args, e.times, e.calls)
+			ok = false
+		case e.times == 0 && e.calls == 0:
+			t.Errorf("expected %s with %v to be called", e.op, e.args)
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+func (r *RecordingStore) matchingCalls(op string, args []interface{}) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var calls []Call
+	for _, c := range r.calls {
+		if c.Op == op && (len(args) == 0 || argsMatch(args, c.Args)) {
+			calls = append(calls, c)
+		}
+	}
+
+	return calls
+}
+
+func (r *RecordingStore) describeCalls(op string) []string {
+	calls := r.matchingCalls(op, nil)
+
+	descriptions := make([]string, len(calls))
+	for i, c := range calls {
+		descriptions[i] = fmt.Sprintf("%s%v", c.Op, c.Args)
+	}
+
+	return descriptions
+}
+
+// record records a call to op, whose results come from a matching expectation,
+// from fn when a store is wrapped, or are zero values.
+func (r *RecordingStore) record(op string, args []interface{}, fn func() ([]interface{}, error)) ([]interface{}, error) {
+	start := time.Now()
+
+	var (
+		results []interface{}
+		err     error
+	)
+
+	if e := r.expectation(op, args); e != nil && e.stubbed {
+		results, err = e.results, e.err
+	} else if r.store != nil {
+		results, err = fn()
+	} else {
+		results = make([]interface{}, len(opResults[op]))
+	}
+
+	r.mu.Lock()
+	r.calls = append(r.calls, Call{
+		Op:       op,
+		Args:     args,
+		Results:  results,
+		Err:      err,
+		Start:    start,
+		Duration: time.Since(start),
+	})
+	r.mu.Unlock()
+
+	return results, err
+}
+
+// expectation returns the first expectation matching the call and counts the call.
+func (r *RecordingStore) expectation(op string, args []interface{}) *Expectation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.expectations {
+		if e.op == op && !e.exhausted() && argsMatch(e.args, args) {
+			e.calls++
+			return e
+		}
+	}
+
+	return nil
+}
+
+// argsMatch reports whether actual arguments match expected ones.
+func argsMatch(expected []interface{}, actual []interface{}) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+
+	for i := range expected {
+		if expected[i] == AnyArg {
+			continue
+		}
+
+		if !valuesEqual(expected[i], actual[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// valuesEqual compares values deeply, empty slices and maps being equal to nil ones.
+func valuesEqual(a interface{}, b interface{}) bool {
+	if isEmpty(a) && isEmpty(b) {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func isEmpty(v interface{}) bool {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	default:
+		return false
+	}
+}
+
+// Get returns value for the given key.
+func (r *RecordingStore) Get(ctx context.Context, key string) (interface{}, error) {
+	results, err := r.record(OpGet, []interface{}{key}, func() ([]interface{}, error) {
+		v, err := r.store.Get(ctx, key)
+		return []interface{}{v}, err
+	})
+	return results[0], err
+}
+
+// MGet returns map of key, value for a list of keys.
+func (r *RecordingStore) MGet(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	results, err := r.record(OpMGet, []interface{}{keys}, func() ([]interface{}, error) {
+		v, err := r.store.MGet(ctx, keys)
+		return []interface{}{v}, err
+	})
+	v, _ := results[0].(map[string]interface{})
+	return v, err
+}
+
+// Set sets value for the given key.
+func (r *RecordingStore) Set(ctx context.Context, key string, value interface{}) error {
+	_, err := r.record(OpSet, []interface{}{key, value}, func() ([]interface{}, error) {
+		return nil, r.store.Set(ctx, key, value)
+	})
+	return err
+}
+
+// SetWithExpiration sets the value for the given key for a specified duration.
+func (r *RecordingStore) SetWithExpiration(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	_, err := r.record(OpSetWithExpiration, []interface{}{key, value, expiration}, func() ([]interface{}, error) {
+		return nil, r.store.SetWithExpiration(ctx, key, value, expiration)
+	})
+	return err
+}
+
+// GetMap returns map for the given key.
+func (r *RecordingStore) GetMap(ctx context.Context, key string) (map[string]interface{}, error) {
+	results, err := r.record(OpGetMap, []interface{}{key}, func() ([]interface{}, error) {
+		v, err := r.store.GetMap(ctx, key)
+		return []interface{}{v}, err
+	})
+	v, _ := results[0].(map[string]interface{})
+	return v, err
+}
+
+// GetMaps returns maps for the given keys.
+func (r *RecordingStore) GetMaps(ctx context.Context, keys []string) (map[string]map[string]interface{}, error) {
+	results, err := r.record(OpGetMaps, []interface{}{keys}, func() ([]interface{}, error) {
+		v, err := r.store.GetMaps(ctx, keys)
+		return []interface{}{v}, err
+	})
+	v, _ := results[0].(map[string]map[string]interface{})
+	return v, err
+}
+
+// SetMap sets map for the given key.
+func (r *RecordingStore) SetMap(ctx context.Context, key string, value map[string]interface{}) error {
+	_, err := r.record(OpSetMap, []interface{}{key, value}, func() ([]interface{}, error) {
+		return nil, r.store.SetMap(ctx, key, value)
+	})
+	return err
+}
+
+// SetMaps sets the given maps.
+func (r *RecordingStore) SetMaps(ctx context.Context, maps map[string]map[string]interface{}) error {
+	_, err := r.record(OpSetMaps, []interface{}{maps}, func() ([]interface{}, error) {
+		return nil, r.store.SetMaps(ctx, maps)
+	})
+	return err
+}
+
+// DeleteMap removes the specified fields from the map stored at key.
+func (r *RecordingStore) DeleteMap(ctx context.Context, key string, fields ...string) error {
+	_, err := r.record(OpDeleteMap, []interface{}{key, fields}, func() ([]interface{}, error) {
+		return nil, r.store.DeleteMap(ctx, key, fields...)
+	})
+	return err
+}
+
+// GetSlice returns slice for the given key.
+func (r *RecordingStore) GetSlice(ctx context.Context, key string) ([]interface{}, error) {
+	results, err := r.record(OpGetSlice, []interface{}{key}, func() ([]interface{}, error) {
+		v, err := r.store.GetSlice(ctx, key)
+		return []interface{}{v}, err
+	})
+	v, _ := results[0].([]interface{})
+	return v, err
+}
+
+// SetSlice sets slice for the given key.
+func (r *RecordingStore) SetSlice(ctx context.Context, key string, value []interface{}) error {
+	_, err := r.record(OpSetSlice, []interface{}{key, value}, func() ([]interface{}, error) {
+		return nil, r.store.SetSlice(ctx, key, value)
+	})
+	return err
+}
+
+// AppendSlice appends values to an existing slice.
+// If key does not exist, creates slice.
+func (r *RecordingStore) AppendSlice(ctx context.Context, key string, values ...interface{}) error {
+	_, err := r.record(OpAppendSlice, []interface{}{key, values}, func() ([]interface{}, error) {
+		return nil, r.store.AppendSlice(ctx, key, values...)
+	})
+	return err
+}
+
+// Exists checks if all the given keys exist.
+func (r *RecordingStore) Exists(ctx context.Context, keys ...string) (bool, error) {
+	results, err := r.record(OpExists, []interface{}{keys}, func() ([]interface{}, error) {
+		v, err := r.store.Exists(ctx, keys...)
+		return []interface{}{v}, err
+	})
+	v, _ := results[0].(bool)
+	return v, err
+}
+
+// ExistsAll checks if all the given keys exist.
+func (r *RecordingStore) ExistsAll(ctx context.Context, keys ...string) (bool, error) {
+	results, err := r.record(OpExistsAll, []interface{}{keys}, func() ([]interface{}, error) {
+		v, err := r.store.ExistsAll(ctx, keys...)
+		return []interface{}{v}, err
+	})
+	v, _ := results[0].(bool)
+	return v, err
+}
+
+// ExistsAny checks if at least one of the given keys exists.
+func (r *RecordingStore) ExistsAny(ctx context.Context, keys ...string) (bool, error) {
+	results, err := r.record(OpExistsAny, []interface{}{keys}, func() ([]interface{}, error) {
+		v, err := r.store.ExistsAny(ctx, keys...)
+		return []interface{}{v}, err
+	})
+	v, _ := results[0].(bool)
+	return v, err
+}
+
+// CountExisting returns the number of given keys that exist.
+func (r *RecordingStore) CountExisting(ctx context.Context, keys ...string) (int, error) {
+	results, err := r.record(OpCountExisting, []interface{}{keys}, func() ([]interface{}, error) {
+		v, err := r.store.CountExisting(ctx, keys...)
+		return []interface{}{v}, err
+	})
+	v, _ := results[0].(int)
+	return v, err
+}
+
+// Delete deletes the given key.
+func (r *RecordingStore) Delete(ctx context.Context, key string) error {
+	_, err := r.record(OpDelete, []interface{}{key}, func() ([]interface{}, error) {
+		return nil, r.store.Delete(ctx, key)
+	})
+	return err
+}
+
+// Flush flushes the store.
+func (r *RecordingStore) Flush(ctx context.Context) error {
+	_, err := r.record(OpFlush, []interface{}{}, func() ([]interface{}, error) {
+		return nil, r.store.Flush(ctx)
+	})
+	return err
+}
+
+// Keys returns all keys matching pattern.
+func (r *RecordingStore) Keys(ctx context.Context, pattern string) ([]interface{}, error) {
+	results, err := r.record(OpKeys, []interface{}{pattern}, func() ([]interface{}, error) {
+		v, err := r.store.Keys(ctx, pattern)
+		return []interface{}{v}, err
+	})
+	v, _ := results[0].([]interface{})
+	return v, err
+}
+
+// Close closes the wrapped store.
+func (r *RecordingStore) Close() error {
+	_, err := r.record(OpClose, []interface{}{}, func() ([]interface{}, error) {
+		return nil, r.store.Close()
+	})
+	return err
+}
+
+var _ KVStore = &RecordingStore{}
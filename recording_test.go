@@ -0,0 +1,150 @@
+package gokvstores_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+	"github.com/ulule/gokvstores/kvstoretest"
+)
+
+// fakeT records assertion failures.
+type fakeT struct {
+	errors []string
+}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecordingStore(t *testing.T) {
+	kvstoretest.RunConformance(t, func(t *testing.T) gokvstores.KVStore {
+		store, err := gokvstores.NewMemoryStore(time.Minute, time.Minute)
+		require.NoError(t, err)
+
+		return gokvstores.NewRecordingStore(store)
+	})
+}
+
+func TestRecordingStore_Calls(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	memory, err := gokvstores.NewMemoryStore(time.Minute, time.Minute)
+	is.NoError(err)
+
+	store := gokvstores.NewRecordingStore(memory)
+
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	v, err := store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("value", v)
+
+	is.NoError(store.DeleteMap(ctx, "map", "a", "b"))
+
+	calls := store.Calls()
+	is.Len(calls, 3)
+	is.Equal(gokvstores.OpSet, calls[0].Op)
+	is.Equal([]interface{}{"key", "value"}, calls[0].Args)
+	is.Equal(gokvstores.OpGet, calls[1].Op)
+	is.Equal([]interface{}{"value"}, calls[1].Results)
+	is.False(calls[1].Start.IsZero())
+	is.Equal([]interface{}{"map", []string{"a", "b"}}, calls[2].Args)
+
+	is.True(store.AssertCalled(t, gokvstores.OpGet, "key"))
+	is.True(store.AssertCalled(t, gokvstores.OpSet, "key", gokvstores.AnyArg))
+	is.True(store.AssertCalled(t, gokvstores.OpDeleteMap))
+	is.True(store.AssertNotCalled(t, gokvstores.OpGet, "other"))
+	is.True(store.AssertNotCalled(t, gokvstores.OpFlush))
+	is.True(store.AssertNumberOfCalls(t, gokvstores.OpGet, 1))
+
+	failing := &fakeT{}
+	is.False(store.AssertCalled(failing, gokvstores.OpGet, "other"))
+	is.False(store.AssertNotCalled(failing, gokvstores.OpGet))
+	is.False(store.AssertNumberOfCalls(failing, gokvstores.OpSet, 2))
+	is.Len(failing.errors, 3)
+
+	store.Reset()
+	is.Len(store.Calls(), 0)
+}
+
+func TestRecordingStore_Expectations(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	errReadOnly := errors.New("read only")
+
+	store := gokvstores.NewRecordingStore(nil)
+	store.ExpectGet("key").Return("value", nil).Once()
+	store.ExpectGet(gokvstores.AnyArg).Return(nil, nil)
+	store.ExpectSet("key", gokvstores.AnyArg).Return(errReadOnly)
+	store.ExpectMGet([]string{"a", "b"}).Return(map[string]interface{}{"a": "1", "b": nil}, nil)
+	store.ExpectExists([]string{"a"}).Return(true, nil)
+	store.ExpectCountExisting(gokvstores.AnyArg).Return(2, nil)
+
+	v, err := store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("value", v)
+
+	v, err = store.Get(ctx, "key")
+	is.NoError(err)
+	is.Nil(v)
+
+	is.Equal(errReadOnly, store.Set(ctx, "key", "value"))
+
+	values, err := store.MGet(ctx, []string{"a", "b"})
+	is.NoError(err)
+	is.Equal(map[string]interface{}{"a": "1", "b": nil}, values)
+
+	exists, err := store.Exists(ctx, "a")
+	is.NoError(err)
+	is.True(exists)
+
+	count, err := store.CountExisting(ctx, "a", "b")
+	is.NoError(err)
+	is.Equal(2, count)
+
+	is.True(store.AssertExpectations(t))
+
+	// Standalone calls without expectations return zero values.
+
+	m, err := store.GetMap(ctx, "map")
+	is.NoError(err)
+	is.Nil(m)
+
+	exists, err = store.ExistsAny(ctx, "a")
+	is.NoError(err)
+	is.False(exists)
+
+	is.NoError(store.Close())
+
+	store.ExpectDelete("key").Times(2)
+	is.NoError(store.Delete(ctx, "key"))
+
+	failing := &fakeT{}
+	is.False(store.AssertExpectations(failing))
+	is.Len(failing.errors, 1)
+
+	is.Panics(func() {
+		store.ExpectGet("key").Return("value")
+	})
+
+	is.PanicsWithValue("gokvstores: value 1 returned by GetMap must be a map[string]interface {}, got map[string]string", func() {
+		store.ExpectGetMap("key").Return(map[string]string{}, nil)
+	})
+
+	is.Panics(func() {
+		store.ExpectCountExisting(gokvstores.AnyArg).Return(int64(2), nil)
+	})
+
+	is.NotPanics(func() {
+		store.ExpectGetMap("key").Return(nil, nil)
+	})
+}
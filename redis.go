@@ -2,8 +2,11 @@ package gokvstores
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
 	"time"
 
 	redis "github.com/go-redis/redis/v8"
@@ -14,64 +17,196 @@ import (
 // ----------------------------------------------------------------------------
 
 // RedisClient is an interface thats allows to use Redis cluster or a redis single client seamlessly.
+// It is implemented by *redis.Client, *redis.ClusterClient and RedisPipeline.
 type RedisClient interface {
-	Ping(ctx context.Context) *redis.StatusCmd
-	Exists(ctx context.Context, keys ...string) *redis.IntCmd
-	Del(ctx context.Context, keys ...string) *redis.IntCmd
-	FlushDB(ctx context.Context) *redis.StatusCmd
+	redis.Cmdable
 	Close() error
 	Process(ctx context.Context, cmd redis.Cmder) error
-	Get(ctx context.Context, key string) *redis.StringCmd
-	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
-	MGet(ctx context.Context, keys ...string) *redis.SliceCmd
-	HDel(ctx context.Context, key string, fields ...string) *redis.IntCmd
-	HGetAll(ctx context.Context, key string) *redis.StringStringMapCmd
-	HMSet(ctx context.Context, key string, values ...interface{}) *redis.BoolCmd
-	SMembers(ctx context.Context, key string) *redis.StringSliceCmd
-	SAdd(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
-	Keys(ctx context.Context, pattern string) *redis.StringSliceCmd
-	Pipeline() redis.Pipeliner
 }
 
 // RedisPipeline is a struct which contains an opend redis pipeline transaction
 type RedisPipeline struct {
-	pipeline redis.Pipeliner
+	redis.Pipeliner
 }
 
 // RedisClientOptions are Redis client options.
+// Fields are those of redis.Options, see its documentation for their defaults.
 type RedisClientOptions struct {
 	Network            string
 	Addr               string
 	Dialer             func(ctx context.Context, network string, addr string) (net.Conn, error)
+	OnConnect          func(ctx context.Context, cn *redis.Conn) error
+	Username           string
 	Password           string
 	DB                 int
 	MaxRetries         int
+	MinRetryBackoff    time.Duration
+	MaxRetryBackoff    time.Duration
 	DialTimeout        time.Duration
 	ReadTimeout        time.Duration
 	WriteTimeout       time.Duration
+	PoolFIFO           bool
 	PoolSize           int
+	MinIdleConns       int
+	MaxConnAge         time.Duration
 	PoolTimeout        time.Duration
 	IdleTimeout        time.Duration
 	IdleCheckFrequency time.Duration
-	ReadOnly           bool
+	TLSConfig          *tls.Config
+	Limiter            redis.Limiter
+
+	// ReadOnly enables read queries on a cluster replica node, by sending READONLY
+	// on every new connection.
+	ReadOnly bool
+
+	// Raw, if not nil, is passed as is to go-redis, and the fields above are ignored.
+	Raw *redis.Options
+
+	Connect           ConnectMode
+	ConnectMinBackoff time.Duration
+	ConnectMaxBackoff time.Duration
+}
+
+// redisOptions returns the go-redis options.
+func (o *RedisClientOptions) redisOptions() *redis.Options {
+	if o.Raw != nil {
+		return o.Raw
+	}
+
+	opts := &redis.Options{
+		Network:            o.Network,
+		Addr:               o.Addr,
+		Dialer:             o.Dialer,
+		OnConnect:          o.OnConnect,
+		Username:           o.Username,
+		Password:           o.Password,
+		DB:                 o.DB,
+		MaxRetries:         o.MaxRetries,
+		MinRetryBackoff:    o.MinRetryBackoff,
+		MaxRetryBackoff:    o.MaxRetryBackoff,
+		DialTimeout:        o.DialTimeout,
+		ReadTimeout:        o.ReadTimeout,
+		WriteTimeout:       o.WriteTimeout,
+		PoolFIFO:           o.PoolFIFO,
+		PoolSize:           o.PoolSize,
+		MinIdleConns:       o.MinIdleConns,
+		MaxConnAge:         o.MaxConnAge,
+		PoolTimeout:        o.PoolTimeout,
+		IdleTimeout:        o.IdleTimeout,
+		IdleCheckFrequency: o.IdleCheckFrequency,
+		TLSConfig:          o.TLSConfig,
+		Limiter:            o.Limiter,
+	}
+
+	if o.ReadOnly {
+		// go-redis only sends READONLY for cluster clients.
+		onConnect := o.OnConnect
+		opts.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+			if err := cn.ReadOnly(ctx).Err(); err != nil {
+				return err
+			}
+
+			if onConnect != nil {
+				return onConnect(ctx, cn)
+			}
+
+			return nil
+		}
+	}
+
+	return opts
 }
 
 // RedisClusterOptions are Redis cluster options.
+// Fields are those of redis.ClusterOptions, see its documentation for their defaults.
 type RedisClusterOptions struct {
 	Addrs              []string
+	NewClient          func(opt *redis.Options) *redis.Client
 	MaxRedirects       int
 	ReadOnly           bool
 	RouteByLatency     bool
+	RouteRandomly      bool
+	ClusterSlots       func(ctx context.Context) ([]redis.ClusterSlot, error)
+	Dialer             func(ctx context.Context, network string, addr string) (net.Conn, error)
+	OnConnect          func(ctx context.Context, cn *redis.Conn) error
+	Username           string
 	Password           string
+	MaxRetries         int
+	MinRetryBackoff    time.Duration
+	MaxRetryBackoff    time.Duration
 	DialTimeout        time.Duration
 	ReadTimeout        time.Duration
 	WriteTimeout       time.Duration
+	PoolFIFO           bool
 	PoolSize           int
+	MinIdleConns       int
+	MaxConnAge         time.Duration
 	PoolTimeout        time.Duration
 	IdleTimeout        time.Duration
 	IdleCheckFrequency time.Duration
+	TLSConfig          *tls.Config
+
+	// Raw, if not nil, is passed as is to go-redis, and the fields above are ignored.
+	Raw *redis.ClusterOptions
+
+	Connect           ConnectMode
+	ConnectMinBackoff time.Duration
+	ConnectMaxBackoff time.Duration
 }
 
+// redisOptions returns the go-redis options.
+func (o *RedisClusterOptions) redisOptions() *redis.ClusterOptions {
+	if o.Raw != nil {
+		return o.Raw
+	}
+
+	return &redis.ClusterOptions{
+		Addrs:              o.Addrs,
+		NewClient:          o.NewClient,
+		MaxRedirects:       o.MaxRedirects,
+		ReadOnly:           o.ReadOnly,
+		RouteByLatency:     o.RouteByLatency,
+		RouteRandomly:      o.RouteRandomly,
+		ClusterSlots:       o.ClusterSlots,
+		Dialer:             o.Dialer,
+		OnConnect:          o.OnConnect,
+		Username:           o.Username,
+		Password:           o.Password,
+		MaxRetries:         o.MaxRetries,
+		MinRetryBackoff:    o.MinRetryBackoff,
+		MaxRetryBackoff:    o.MaxRetryBackoff,
+		DialTimeout:        o.DialTimeout,
+		ReadTimeout:        o.ReadTimeout,
+		WriteTimeout:       o.WriteTimeout,
+		PoolFIFO:           o.PoolFIFO,
+		PoolSize:           o.PoolSize,
+		MinIdleConns:       o.MinIdleConns,
+		MaxConnAge:         o.MaxConnAge,
+		PoolTimeout:        o.PoolTimeout,
+		IdleTimeout:        o.IdleTimeout,
+		IdleCheckFrequency: o.IdleCheckFrequency,
+		TLSConfig:          o.TLSConfig,
+	}
+}
+
+// ConnectMode defines how Redis stores connect at construction.
+type ConnectMode int
+
+const (
+	// ConnectEager pings Redis at construction, which fails if Redis is unavailable.
+	ConnectEager ConnectMode = iota
+
+	// ConnectSkip does not ping Redis at construction: connections are established
+	// by the first calls, which fail if Redis is unavailable.
+	ConnectSkip
+
+	// ConnectBackground pings Redis in the background until it succeeds, with an exponential
+	// backoff between ConnectMinBackoff (100 milliseconds by default) and ConnectMaxBackoff
+	// (10 seconds by default). Until then, calls fail fast with ErrUnavailable.
+	// The store Ready channel is closed once it is connected.
+	ConnectBackground
+)
+
 // ----------------------------------------------------------------------------
 // Store
 // ----------------------------------------------------------------------------
@@ -80,10 +215,20 @@ type RedisClusterOptions struct {
 type RedisStore struct {
 	client     RedisClient
 	expiration time.Duration
+	db         int
+	cluster    bool
+
+	// ready is closed once the store is connected, nil if it was connected at construction.
+	ready chan struct{}
+	stop  context.CancelFunc
 }
 
 // Get returns value for the given key.
 func (r *RedisStore) Get(ctx context.Context, key string) (interface{}, error) {
+	if err := r.checkConnected(OpGet, key); err != nil {
+		return nil, err
+	}
+
 	cmd := redis.NewCmd(ctx, "get", key)
 
 	if err := r.client.Process(ctx, cmd); err != nil {
@@ -91,44 +236,63 @@ func (r *RedisStore) Get(ctx context.Context, key string) (interface{}, error) {
 			return nil, nil
 		}
 
-		return nil, err
+		return nil, r.wrap(OpGet, key, err)
 	}
 
-	return cmd.Val(), cmd.Err()
+	return cmd.Val(), nil
 }
 
 // MGet returns map of key, value for a list of keys.
 func (r *RedisStore) MGet(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	if err := r.checkConnected(OpMGet, firstKey(keys)); err != nil {
+		return nil, err
+	}
+
+	// MGET requires at least one key.
+	if len(keys) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
 	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, r.wrap(OpMGet, firstKey(keys), err)
+	}
 
 	newValues := make(map[string]interface{}, len(keys))
 
 	for k, v := range keys {
-		value := values[k]
-		if err != nil {
-			return nil, err
-		}
-
-		newValues[v] = value
+		newValues[v] = values[k]
 	}
 	return newValues, nil
 }
 
 // Set sets the value for the given key.
 func (r *RedisStore) Set(ctx context.Context, key string, value interface{}) error {
-	return r.client.Set(ctx, key, value, r.expiration).Err()
+	if err := r.checkConnected(OpSet, key); err != nil {
+		return err
+	}
+
+	return r.wrap(OpSet, key, r.client.Set(ctx, key, value, r.expiration).Err())
 }
 
 // SetWithExpiration sets the value for the given key.
 func (r *RedisStore) SetWithExpiration(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	return r.client.Set(ctx, key, value, expiration).Err()
+	if err := r.checkConnected(OpSetWithExpiration, key); err != nil {
+		return err
+	}
+
+	return r.wrap(OpSetWithExpiration, key, r.client.Set(ctx, key, value, expiration).Err())
 }
 
 // GetMap returns map for the given key.
 func (r *RedisStore) GetMap(ctx context.Context, key string) (map[string]interface{}, error) {
+	if err := r.checkConnected(OpGetMap, key); err != nil {
+		return nil, err
+	}
+
 	values, err := r.client.HGetAll(ctx, key).Result()
 	if err != nil {
-		return nil, err
+		return nil, r.wrap(OpGetMap, key, err)
 	}
 
 	if len(values) == 0 {
@@ -145,6 +309,15 @@ func (r *RedisStore) GetMap(ctx context.Context, key string) (map[string]interfa
 
 // SetMap sets map for the given key.
 func (r *RedisStore) SetMap(ctx context.Context, key string, values map[string]interface{}) error {
+	if err := r.checkConnected(OpSetMap, key); err != nil {
+		return err
+	}
+
+	// HMSET requires at least one field.
+	if len(values) == 0 {
+		return nil
+	}
+
 	newValues := make(map[string]string, len(values))
 
 	for k, v := range values {
@@ -156,19 +329,27 @@ func (r *RedisStore) SetMap(ctx context.Context, key string, values map[string]i
 		}
 	}
 
-	return r.client.HMSet(ctx, key, newValues).Err()
+	return r.wrap(OpSetMap, key, r.client.HMSet(ctx, key, newValues).Err())
 }
 
 // DeleteMap removes the specified fields from the map stored at key.
 func (r *RedisStore) DeleteMap(ctx context.Context, key string, fields ...string) error {
-	return r.client.HDel(ctx, key, fields...).Err()
+	if err := r.checkConnected(OpDeleteMap, key); err != nil {
+		return err
+	}
+
+	return r.wrap(OpDeleteMap, key, r.client.HDel(ctx, key, fields...).Err())
 }
 
 // GetSlice returns slice for the given key.
 func (r *RedisStore) GetSlice(ctx context.Context, key string) ([]interface{}, error) {
+	if err := r.checkConnected(OpGetSlice, key); err != nil {
+		return nil, err
+	}
+
 	values, err := r.client.SMembers(ctx, key).Result()
 	if err != nil {
-		return nil, err
+		return nil, r.wrap(OpGetSlice, key, err)
 	}
 
 	if len(values) == 0 {
@@ -185,6 +366,24 @@ func (r *RedisStore) GetSlice(ctx context.Context, key string) ([]interface{}, e
 
 // SetSlice sets map for the given key.
 func (r *RedisStore) SetSlice(ctx context.Context, key string, values []interface{}) error {
+	if err := r.checkConnected(OpSetSlice, key); err != nil {
+		return err
+	}
+
+	return r.wrap(OpSetSlice, key, r.addSlice(ctx, key, values))
+}
+
+// AppendSlice appends values to the given slice.
+func (r *RedisStore) AppendSlice(ctx context.Context, key string, values ...interface{}) error {
+	if err := r.checkConnected(OpAppendSlice, key); err != nil {
+		return err
+	}
+
+	return r.wrap(OpAppendSlice, key, r.addSlice(ctx, key, values))
+}
+
+// addSlice adds the given values to the set stored at key.
+func (r *RedisStore) addSlice(ctx context.Context, key string, values []interface{}) error {
 	for _, v := range values {
 		if v != nil {
 			if err := r.client.SAdd(ctx, key, v).Err(); err != nil {
@@ -196,28 +395,90 @@ func (r *RedisStore) SetSlice(ctx context.Context, key string, values []interfac
 	return nil
 }
 
-// AppendSlice appends values to the given slice.
-func (r *RedisStore) AppendSlice(ctx context.Context, key string, values ...interface{}) error {
-	return r.SetSlice(ctx, key, values)
+// Exists checks if all the given keys exist.
+func (r *RedisStore) Exists(ctx context.Context, keys ...string) (bool, error) {
+	if err := r.checkConnected(OpExists, firstKey(keys)); err != nil {
+		return false, err
+	}
+
+	count, err := r.countExisting(ctx, keys)
+	if err != nil {
+		return false, r.wrap(OpExists, firstKey(keys), err)
+	}
+
+	return len(keys) > 0 && count == len(keys), nil
 }
 
-// Exists checks key existence.
-func (r *RedisStore) Exists(ctx context.Context, keys ...string) (bool, error) {
+// ExistsAll checks if all the given keys exist.
+func (r *RedisStore) ExistsAll(ctx context.Context, keys ...string) (bool, error) {
+	if err := r.checkConnected(OpExistsAll, firstKey(keys)); err != nil {
+		return false, err
+	}
+
+	count, err := r.countExisting(ctx, keys)
+	if err != nil {
+		return false, r.wrap(OpExistsAll, firstKey(keys), err)
+	}
+
+	return len(keys) > 0 && count == len(keys), nil
+}
+
+// ExistsAny checks if at least one of the given keys exists.
+func (r *RedisStore) ExistsAny(ctx context.Context, keys ...string) (bool, error) {
+	if err := r.checkConnected(OpExistsAny, firstKey(keys)); err != nil {
+		return false, err
+	}
+
+	count, err := r.countExisting(ctx, keys)
+	if err != nil {
+		return false, r.wrap(OpExistsAny, firstKey(keys), err)
+	}
+
+	return count > 0, nil
+}
+
+// CountExisting returns the number of given keys that exist.
+// Redis counts a key given multiple times multiple times.
+func (r *RedisStore) CountExisting(ctx context.Context, keys ...string) (int, error) {
+	if err := r.checkConnected(OpCountExisting, firstKey(keys)); err != nil {
+		return 0, err
+	}
+
+	count, err := r.countExisting(ctx, keys)
+	return count, r.wrap(OpCountExisting, firstKey(keys), err)
+}
+
+func (r *RedisStore) countExisting(ctx context.Context, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
 	cmd := r.client.Exists(ctx, keys...)
-	return cmd.Val() > 0, cmd.Err()
+	return int(cmd.Val()), cmd.Err()
 }
 
 // Delete deletes key.
 func (r *RedisStore) Delete(ctx context.Context, key string) error {
-	return r.client.Del(ctx, key).Err()
+	if err := r.checkConnected(OpDelete, key); err != nil {
+		return err
+	}
+
+	return r.wrap(OpDelete, key, r.client.Del(ctx, key).Err())
 }
 
 // Keys returns all keys matching pattern.
 func (r *RedisStore) Keys(ctx context.Context, pattern string) ([]interface{}, error) {
+	if err := r.checkConnected(OpKeys, pattern); err != nil {
+		return nil, err
+	}
+
 	values, err := r.client.Keys(ctx, pattern).Result()
+	if err != nil {
+		return nil, r.wrap(OpKeys, pattern, err)
+	}
 
 	if len(values) == 0 {
-		return nil, err
+		return nil, nil
 	}
 
 	newValues := make([]interface{}, len(values))
@@ -226,76 +487,305 @@ func (r *RedisStore) Keys(ctx context.Context, pattern string) ([]interface{}, e
 		newValues[k] = v
 	}
 
-	return newValues, err
+	return newValues, nil
 }
 
 // Flush flushes the current database.
 func (r *RedisStore) Flush(ctx context.Context) error {
-	return r.client.FlushDB(ctx).Err()
+	if err := r.checkConnected(OpFlush, ""); err != nil {
+		return err
+	}
+
+	return r.wrap(OpFlush, "", r.client.FlushDB(ctx).Err())
 }
 
 // Close closes the client connection.
 func (r *RedisStore) Close() error {
-	return r.client.Close()
+	if r.stop != nil {
+		r.stop()
+	}
+
+	return r.wrap(OpClose, "", r.client.Close())
+}
+
+// Ready returns a channel which is closed once the store is connected.
+// It is closed at construction, unless the store connects with ConnectBackground.
+func (r *RedisStore) Ready() <-chan struct{} {
+	if r.ready == nil {
+		return closedChan
+	}
+	return r.ready
+}
+
+// closedChan is the channel returned by Ready for connected stores.
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// errConnecting is returned by calls to stores which are not connected yet.
+var errConnecting = fmt.Errorf("%w: connecting", ErrUnavailable)
+
+// checkConnected returns an OpError wrapping ErrUnavailable if the store is not connected yet.
+func (r *RedisStore) checkConnected(op string, key string) error {
+	if r.ready == nil {
+		return nil
+	}
+
+	select {
+	case <-r.ready:
+		return nil
+	default:
+		return r.wrap(op, key, errConnecting)
+	}
+}
+
+// connect checks the connection to Redis according to the given mode.
+func (r *RedisStore) connect(ctx context.Context, mode ConnectMode, minBackoff time.Duration, maxBackoff time.Duration) error {
+	switch mode {
+	case ConnectSkip:
+		return nil
+	case ConnectBackground:
+		if minBackoff <= 0 {
+			minBackoff = 100 * time.Millisecond
+		}
+
+		if maxBackoff <= 0 {
+			maxBackoff = 10 * time.Second
+		}
+
+		if maxBackoff < minBackoff {
+			maxBackoff = minBackoff
+		}
+
+		// The constructor context is not used, since it may be done before the store connects.
+		ctx, cancel := context.WithCancel(context.Background())
+
+		r.ready = make(chan struct{})
+		r.stop = cancel
+
+		go r.connectLoop(ctx, minBackoff, maxBackoff)
+
+		return nil
+	default:
+		if err := r.client.Ping(ctx).Err(); err != nil {
+			r.client.Close()
+			return newOpError(BackendRedis, opPing, "", err)
+		}
+		return nil
+	}
+}
+
+// connectLoop pings Redis until it succeeds, with an exponential backoff, and then closes r.ready.
+func (r *RedisStore) connectLoop(ctx context.Context, minBackoff time.Duration, maxBackoff time.Duration) {
+	backoff := minBackoff
+
+	for {
+		if err := r.client.Ping(ctx).Err(); err == nil {
+			close(r.ready)
+			return
+		}
+
+		if sleep(ctx, backoff) != nil {
+			return
+		}
+
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+const (
+	// opPing is the operation of the connection check done by constructors.
+	opPing = "Ping"
+
+	// opHealth is the operation of health checks.
+	opHealth = "Health"
+)
+
+// wrap returns an OpError for the given operation, or nil if err is nil.
+func (r *RedisStore) wrap(op string, key string, err error) error {
+	return newOpError(BackendRedis, op, key, err)
 }
 
 // NewRedisClientStore returns Redis client instance of KVStore.
 func NewRedisClientStore(ctx context.Context, options *RedisClientOptions, expiration time.Duration) (KVStore, error) {
-	opts := &redis.Options{
-		Network:            options.Network,
-		Addr:               options.Addr,
-		Dialer:             options.Dialer,
-		Password:           options.Password,
-		DB:                 options.DB,
-		MaxRetries:         options.MaxRetries,
-		DialTimeout:        options.DialTimeout,
-		ReadTimeout:        options.ReadTimeout,
-		WriteTimeout:       options.WriteTimeout,
-		PoolSize:           options.PoolSize,
-		PoolTimeout:        options.PoolTimeout,
-		IdleTimeout:        options.IdleTimeout,
-		IdleCheckFrequency: options.IdleCheckFrequency,
-	}
-
-	client := redis.NewClient(opts)
-
-	if err := client.Ping(ctx).Err(); err != nil {
+	opts := options.redisOptions()
+
+	store := &RedisStore{
+		client:     redis.NewClient(opts),
+		expiration: expiration,
+		db:         opts.DB,
+	}
+
+	if err := store.connect(ctx, options.Connect, options.ConnectMinBackoff, options.ConnectMaxBackoff); err != nil {
 		return nil, err
 	}
 
-	return &RedisStore{
-		client:     client,
-		expiration: expiration,
-	}, nil
+	return store, nil
 }
 
 // NewRedisClusterStore returns Redis cluster client instance of KVStore.
 func NewRedisClusterStore(ctx context.Context, options *RedisClusterOptions, expiration time.Duration) (KVStore, error) {
-	opts := &redis.ClusterOptions{
-		Addrs:              options.Addrs,
-		MaxRedirects:       options.MaxRedirects,
-		ReadOnly:           options.ReadOnly,
-		RouteByLatency:     options.RouteByLatency,
-		Password:           options.Password,
-		DialTimeout:        options.DialTimeout,
-		ReadTimeout:        options.ReadTimeout,
-		WriteTimeout:       options.WriteTimeout,
-		PoolSize:           options.PoolSize,
-		PoolTimeout:        options.PoolTimeout,
-		IdleTimeout:        options.IdleTimeout,
-		IdleCheckFrequency: options.IdleCheckFrequency,
-	}
-
-	client := redis.NewClusterClient(opts)
-
-	if err := client.Ping(ctx).Err(); err != nil {
+	store := &RedisStore{
+		client:     redis.NewClusterClient(options.redisOptions()),
+		expiration: expiration,
+		cluster:    true,
+	}
+
+	if err := store.connect(ctx, options.Connect, options.ConnectMinBackoff, options.ConnectMaxBackoff); err != nil {
 		return nil, err
 	}
 
-	return &RedisStore{
+	return store, nil
+}
+
+// NewRedisStoreFromClient returns a RedisStore using the given client, e.g. a *redis.Client
+// or a *redis.ClusterClient shared with the rest of an application.
+// The client is not pinged, and closing the store closes it.
+func NewRedisStoreFromClient(client RedisClient, expiration time.Duration) *RedisStore {
+	store := &RedisStore{
 		client:     client,
 		expiration: expiration,
-	}, nil
+	}
+
+	switch c := client.(type) {
+	case *redis.ClusterClient:
+		store.cluster = true
+	case interface{ Options() *redis.Options }:
+		store.db = c.Options().DB
+	}
+
+	return store
+}
+
+// Client returns the underlying client, to run commands which are not part of KVStore
+// on the store connection pool. Within Pipeline, it returns the RedisPipeline.
+func (r *RedisStore) Client() RedisClient {
+	return r.client
+}
+
+// DB returns the database index of the store. It is always 0 for cluster stores.
+func (r *RedisStore) DB() int {
+	return r.db
+}
+
+// Cluster reports whether the store is backed by a Redis cluster.
+func (r *RedisStore) Cluster() bool {
+	return r.cluster
+}
+
+// AddHook adds a go-redis hook to the underlying client, to instrument Redis commands
+// and pipelines. It does nothing for clients which do not support hooks.
+func (r *RedisStore) AddHook(hook redis.Hook) {
+	if client, ok := r.client.(interface{ AddHook(redis.Hook) }); ok {
+		client.AddHook(hook)
+	}
+}
+
+// PoolStats returns the connection pool statistics of the underlying client,
+// or nil for clients which do not have a pool.
+func (r *RedisStore) PoolStats() *redis.PoolStats {
+	if client, ok := r.client.(interface{ PoolStats() *redis.PoolStats }); ok {
+		return client.PoolStats()
+	}
+	return nil
+}
+
+// Health pings Redis and reports the ping latency, the replication role (for single
+// clients), the cluster state (for clusters) and the connection pool usage.
+// Stores connecting with ConnectBackground are unhealthy until they are ready,
+// since their calls fail with ErrUnavailable until then.
+func (r *RedisStore) Health(ctx context.Context) HealthStatus {
+	status := HealthStatus{
+		Backend: BackendRedis,
+		Details: map[string]interface{}{},
+	}
+
+	if err := r.checkConnected(opHealth, ""); err != nil {
+		status.Err = err
+		return status
+	}
+
+	start := time.Now()
+	err := r.client.Ping(ctx).Err()
+	status.Latency = time.Since(start)
+
+	if err != nil {
+		status.Err = r.wrap(opHealth, "", err)
+		return status
+	}
+
+	status.Healthy = true
+
+	// Cluster clients have a pool per node, and their PoolStats are the sums of the pools.
+	poolSize := 0
+
+	if r.cluster {
+		info := redis.NewStringCmd(ctx, "cluster", "info")
+		if err := r.client.Process(ctx, info); err != nil {
+			status.Healthy = false
+			status.Err = r.wrap(opHealth, "", err)
+			return status
+		}
+
+		state := infoField(info.Val(), "cluster_state")
+		status.Details["cluster_state"] = state
+
+		if state != "ok" {
+			status.Healthy = false
+			status.Err = r.wrap(opHealth, "", fmt.Errorf("%w: cluster state is %q", ErrUnavailable, state))
+		}
+
+		nodes, _ := strconv.Atoi(infoField(info.Val(), "cluster_known_nodes"))
+		status.Details["cluster_known_nodes"] = nodes
+
+		if client, ok := r.client.(*redis.ClusterClient); ok {
+			poolSize = client.Options().PoolSize * nodes
+		}
+	} else {
+		role := redis.NewSliceCmd(ctx, "role")
+		if err := r.client.Process(ctx, role); err == nil && len(role.Val()) > 0 {
+			status.Details["role"] = role.Val()[0]
+		}
+
+		if client, ok := r.client.(interface{ Options() *redis.Options }); ok {
+			poolSize = client.Options().PoolSize
+		}
+	}
+
+	if stats := r.PoolStats(); stats != nil {
+		status.Details["pool_hits"] = stats.Hits
+		status.Details["pool_misses"] = stats.Misses
+		status.Details["pool_timeouts"] = stats.Timeouts
+		status.Details["pool_total_conns"] = stats.TotalConns
+		status.Details["pool_idle_conns"] = stats.IdleConns
+		status.Details["pool_stale_conns"] = stats.StaleConns
+
+		if poolSize > 0 {
+			inUse := 0
+			if stats.TotalConns > stats.IdleConns {
+				inUse = int(stats.TotalConns - stats.IdleConns)
+			}
+
+			status.Details["pool_size"] = poolSize
+			status.Details["pool_saturation"] = float64(inUse) / float64(poolSize)
+		}
+	}
+
+	return status
+}
+
+// infoField returns the value of the given field of an INFO-like reply.
+func infoField(info string, field string) string {
+	for _, line := range strings.Split(info, "\n") {
+		if value := strings.TrimPrefix(line, field+":"); value != line {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
 }
 
 // Pipeline uses pipeline as a Redis client to execute multiple calls at once
@@ -303,12 +793,14 @@ func (r *RedisStore) Pipeline(ctx context.Context, f func(r *RedisStore) error)
 	pipe := r.client.Pipeline()
 
 	redisPipeline := RedisPipeline{
-		pipeline: pipe,
+		Pipeliner: pipe,
 	}
 
 	store := &RedisStore{
 		client:     redisPipeline,
 		expiration: r.expiration,
+		db:         r.db,
+		cluster:    r.cluster,
 	}
 
 	err := f(store)
@@ -322,6 +814,10 @@ func (r *RedisStore) Pipeline(ctx context.Context, f func(r *RedisStore) error)
 
 // GetMaps returns maps for the given keys.
 func (r *RedisStore) GetMaps(ctx context.Context, keys []string) (map[string]map[string]interface{}, error) {
+	if err := r.checkConnected(OpGetMaps, firstKey(keys)); err != nil {
+		return nil, err
+	}
+
 	commands, err := r.Pipeline(ctx, func(r *RedisStore) error {
 		for _, key := range keys {
 			r.client.HGetAll(ctx, key)
@@ -330,7 +826,7 @@ func (r *RedisStore) GetMaps(ctx context.Context, keys []string) (map[string]map
 
 	})
 	if err != nil {
-		return nil, err
+		return nil, r.wrap(OpGetMaps, firstKey(keys), err)
 	}
 
 	newValues := make(map[string]map[string]interface{}, len(keys))
@@ -355,94 +851,29 @@ func (r *RedisStore) GetMaps(ctx context.Context, keys []string) (map[string]map
 
 // SetMaps sets the given maps.
 func (r *RedisStore) SetMaps(ctx context.Context, maps map[string]map[string]interface{}) error {
+	if err := r.checkConnected(OpSetMaps, ""); err != nil {
+		return err
+	}
+
+	var key string
+
 	_, err := r.Pipeline(ctx, func(r *RedisStore) error {
 		for k, v := range maps {
+			if key == "" {
+				key = k
+			}
 			r.SetMap(ctx, k, v)
 		}
 		return nil
 
 	})
-	return err
+	return r.wrap(OpSetMaps, key, err)
 }
 
-// Pipeline returns Redis pipeline
-func (r RedisPipeline) Pipeline() redis.Pipeliner {
-	return r.pipeline
-}
-
-// Ping implements RedisClient Ping for pipeline
-func (r RedisPipeline) Ping(ctx context.Context) *redis.StatusCmd {
-	return r.pipeline.Ping(ctx)
-}
-
-// Exists implements RedisClient Exists for pipeline
-func (r RedisPipeline) Exists(ctx context.Context, keys ...string) *redis.IntCmd {
-	return r.pipeline.Exists(ctx, keys...)
-}
-
-// Del implements RedisClient Del for pipeline
-func (r RedisPipeline) Del(ctx context.Context, keys ...string) *redis.IntCmd {
-	return r.pipeline.Del(ctx, keys...)
-}
-
-// FlushDb implements RedisClient FlushDb for pipeline
-func (r RedisPipeline) FlushDB(ctx context.Context) *redis.StatusCmd {
-	return r.pipeline.FlushDB(ctx)
-}
-
-// Close implements RedisClient Close for pipeline
-func (r RedisPipeline) Close() error {
-	return r.pipeline.Close()
-}
-
-// Process implements RedisClient Process for pipeline
-func (r RedisPipeline) Process(ctx context.Context, cmd redis.Cmder) error {
-	return r.pipeline.Process(ctx, cmd)
-}
-
-// Get implements RedisClient Get for pipeline
-func (r RedisPipeline) Get(ctx context.Context, key string) *redis.StringCmd {
-	return r.pipeline.Get(ctx, key)
-}
-
-// MGet implements RedisClient MGet for pipeline
-func (r RedisPipeline) MGet(ctx context.Context, keys ...string) *redis.SliceCmd {
-	return r.pipeline.MGet(ctx, keys...)
-}
-
-// Set implements RedisClient Set for pipeline
-func (r RedisPipeline) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
-	return r.pipeline.Set(ctx, key, value, expiration)
-}
-
-// HDel implements RedisClient HDel for pipeline
-func (r RedisPipeline) HDel(ctx context.Context, key string, fields ...string) *redis.IntCmd {
-	return r.pipeline.HDel(ctx, key, fields...)
-}
-
-// HGetAll implements RedisClient HGetAll for pipeline
-func (r RedisPipeline) HGetAll(ctx context.Context, key string) *redis.StringStringMapCmd {
-	return r.pipeline.HGetAll(ctx, key)
-}
-
-// HMSet implements RedisClient HMSet for pipeline
-func (r RedisPipeline) HMSet(ctx context.Context, key string, values ...interface{}) *redis.BoolCmd {
-	return r.pipeline.HMSet(ctx, key, values...)
-}
-
-// SMembers implements RedisClient SMembers for pipeline
-func (r RedisPipeline) SMembers(ctx context.Context, key string) *redis.StringSliceCmd {
-	return r.pipeline.SMembers(ctx, key)
-}
-
-// SAdd implements RedisClient SAdd for pipeline
-func (r RedisPipeline) SAdd(ctx context.Context, key string, members ...interface{}) *redis.IntCmd {
-	return r.pipeline.SAdd(ctx, key, members...)
-}
-
-// Keys implements RedisClient Keys for pipeline
-func (r RedisPipeline) Keys(ctx context.Context, pattern string) *redis.StringSliceCmd {
-	return r.pipeline.Keys(ctx, pattern)
-}
-
-var _ KVStore = &RedisStore{}
+var (
+	_ KVStore       = &RedisStore{}
+	_ HealthChecker = &RedisStore{}
+	_ RedisClient   = &redis.Client{}
+	_ RedisClient   = &redis.ClusterClient{}
+	_ RedisClient   = RedisPipeline{}
+)
@@ -0,0 +1,110 @@
+package gokvstores
+
+import (
+	"context"
+	"fmt"
+
+	redis "github.com/go-redis/redis/v8"
+)
+
+// SetBit sets the bit at offset in the bitmap stored at key, creating it if needed,
+// and returns its previous value.
+func (r *RedisStore) SetBit(ctx context.Context, key string, offset int64, value bool) (bool, error) {
+	if err := r.checkConnected(OpSetBit, key); err != nil {
+		return false, err
+	}
+
+	bit := 0
+	if value {
+		bit = 1
+	}
+
+	previous, err := r.client.SetBit(ctx, key, offset, bit).Result()
+	if err != nil {
+		return false, r.wrap(OpSetBit, key, err)
+	}
+
+	return previous == 1, nil
+}
+
+// GetBit returns the bit at offset in the bitmap stored at key.
+func (r *RedisStore) GetBit(ctx context.Context, key string, offset int64) (bool, error) {
+	if err := r.checkConnected(OpGetBit, key); err != nil {
+		return false, err
+	}
+
+	bit, err := r.client.GetBit(ctx, key, offset).Result()
+	if err != nil {
+		return false, r.wrap(OpGetBit, key, err)
+	}
+
+	return bit == 1, nil
+}
+
+// BitCount returns the number of bits set in the bytes between start and end
+// of the bitmap stored at key. BitCount(ctx, key, 0, -1) counts all bits.
+func (r *RedisStore) BitCount(ctx context.Context, key string, start int64, end int64) (int64, error) {
+	if err := r.checkConnected(OpBitCount, key); err != nil {
+		return 0, err
+	}
+
+	count, err := r.client.BitCount(ctx, key, &redis.BitCount{Start: start, End: end}).Result()
+	if err != nil {
+		return 0, r.wrap(OpBitCount, key, err)
+	}
+
+	return count, nil
+}
+
+// BitOp stores at dest the result of op between the bitmaps stored at keys,
+// missing bitmaps and the end of shorter bitmaps being zeros, and returns its size
+// in bytes, which is the size of the longest bitmap. dest is deleted if all bitmaps
+// are missing. With a cluster, all keys must hash to the same slot.
+func (r *RedisStore) BitOp(ctx context.Context, op BitOperation, dest string, keys ...string) (int64, error) {
+	if err := r.checkConnected(OpBitOp, dest); err != nil {
+		return 0, err
+	}
+
+	var cmd *redis.IntCmd
+
+	switch op {
+	case BitAnd:
+		cmd = r.client.BitOpAnd(ctx, dest, keys...)
+	case BitOr:
+		cmd = r.client.BitOpOr(ctx, dest, keys...)
+	case BitXor:
+		cmd = r.client.BitOpXor(ctx, dest, keys...)
+	default:
+		return 0, r.wrap(OpBitOp, dest, fmt.Errorf("gokvstores: invalid bit operation %d", op))
+	}
+
+	size, err := cmd.Result()
+	if err != nil {
+		return 0, r.wrap(OpBitOp, dest, err)
+	}
+
+	return size, nil
+}
+
+// BitPos returns the position of the first bit with the given value in the bytes between
+// start and end of the bitmap stored at key, or -1 if there is none. Missing bitmaps
+// are considered to be zeros.
+func (r *RedisStore) BitPos(ctx context.Context, key string, value bool, start int64, end int64) (int64, error) {
+	if err := r.checkConnected(OpBitPos, key); err != nil {
+		return 0, err
+	}
+
+	bit := int64(0)
+	if value {
+		bit = 1
+	}
+
+	pos, err := r.client.BitPos(ctx, key, bit, start, end).Result()
+	if err != nil {
+		return 0, r.wrap(OpBitPos, key, err)
+	}
+
+	return pos, nil
+}
+
+var _ BitmapStore = &RedisStore{}
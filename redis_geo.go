@@ -0,0 +1,160 @@
+package gokvstores
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	redis "github.com/go-redis/redis/v8"
+)
+
+// GeoAdd adds the given members to the geospatial index stored at key, or updates their
+// position, and returns the number of added members. Latitudes must be between -85.05112878
+// and 85.05112878 degrees.
+func (r *RedisStore) GeoAdd(ctx context.Context, key string, locations ...GeoLocation) (int, error) {
+	if err := r.checkConnected(OpGeoAdd, key); err != nil {
+		return 0, err
+	}
+
+	if err := checkGeoLocations(locations); err != nil {
+		return 0, r.wrap(OpGeoAdd, key, err)
+	}
+
+	// GEOADD requires at least one member.
+	if len(locations) == 0 {
+		return 0, nil
+	}
+
+	args := make([]*redis.GeoLocation, len(locations))
+	for i, l := range locations {
+		args[i] = &redis.GeoLocation{Name: l.Member, Longitude: l.Longitude, Latitude: l.Latitude}
+	}
+
+	count, err := r.client.GeoAdd(ctx, key, args...).Result()
+	if err != nil {
+		return 0, r.wrap(OpGeoAdd, key, err)
+	}
+
+	return int(count), nil
+}
+
+// GeoPos returns the positions of the given members, nil for missing members.
+func (r *RedisStore) GeoPos(ctx context.Context, key string, members ...string) ([]*GeoPosition, error) {
+	if err := r.checkConnected(OpGeoPos, key); err != nil {
+		return nil, err
+	}
+
+	// GEOPOS requires at least one member.
+	if len(members) == 0 {
+		return []*GeoPosition{}, nil
+	}
+
+	values, err := r.client.GeoPos(ctx, key, members...).Result()
+	if err != nil {
+		return nil, r.wrap(OpGeoPos, key, err)
+	}
+
+	positions := make([]*GeoPosition, len(values))
+	for i, v := range values {
+		if v != nil {
+			positions[i] = &GeoPosition{Longitude: v.Longitude, Latitude: v.Latitude}
+		}
+	}
+
+	return positions, nil
+}
+
+// GeoDist returns the distance between two members in the given unit, GeoMeters if empty.
+// It reports false if a member is missing.
+func (r *RedisStore) GeoDist(ctx context.Context, key string, member1 string, member2 string, unit GeoUnit) (float64, bool, error) {
+	if err := r.checkConnected(OpGeoDist, key); err != nil {
+		return 0, false, err
+	}
+
+	if _, err := unit.meters(); err != nil {
+		return 0, false, r.wrap(OpGeoDist, key, err)
+	}
+
+	if unit == "" {
+		unit = GeoMeters
+	}
+
+	distance, err := r.client.GeoDist(ctx, key, member1, member2, string(unit)).Result()
+	if err == redis.Nil {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, r.wrap(OpGeoDist, key, err)
+	}
+
+	return distance, true, nil
+}
+
+// GeoSearch returns the members of the geospatial index stored at key within the area
+// of query, with their position and distance, sorted by distance. It returns ErrNotFound
+// if the center of the query is a missing member of an existing index.
+//
+// It requires Redis 6.2 or later.
+func (r *RedisStore) GeoSearch(ctx context.Context, key string, query GeoSearchQuery) ([]GeoLocation, error) {
+	if err := r.checkConnected(OpGeoSearch, key); err != nil {
+		return nil, err
+	}
+
+	if err := checkGeoSearchQuery(query); err != nil {
+		return nil, r.wrap(OpGeoSearch, key, err)
+	}
+
+	unit := query.Unit
+	if unit == "" {
+		unit = GeoMeters
+	}
+
+	q := &redis.GeoSearchLocationQuery{
+		GeoSearchQuery: redis.GeoSearchQuery{
+			Member:    query.Member,
+			Longitude: query.Longitude,
+			Latitude:  query.Latitude,
+			Radius:    query.Radius,
+			BoxWidth:  query.Width,
+			BoxHeight: query.Height,
+			Sort:      "ASC",
+			Count:     query.Count,
+		},
+		WithCoord: true,
+		WithDist:  true,
+	}
+
+	if query.Radius > 0 {
+		q.RadiusUnit = string(unit)
+	} else {
+		q.BoxUnit = string(unit)
+	}
+
+	if query.Descending {
+		q.Sort = "DESC"
+	}
+
+	values, err := r.client.GeoSearchLocation(ctx, key, q).Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "could not decode requested zset member") {
+			err = fmt.Errorf("%w: member %q: %v", ErrNotFound, query.Member, err)
+		}
+
+		return nil, r.wrap(OpGeoSearch, key, err)
+	}
+
+	locations := make([]GeoLocation, len(values))
+	for i, v := range values {
+		locations[i] = GeoLocation{
+			Member:    v.Name,
+			Longitude: v.Longitude,
+			Latitude:  v.Latitude,
+			Dist:      v.Dist,
+		}
+	}
+
+	return locations, nil
+}
+
+var _ GeoStore = &RedisStore{}
@@ -0,0 +1,185 @@
+package gokvstores
+
+import (
+	"context"
+	"time"
+
+	redis "github.com/go-redis/redis/v8"
+)
+
+// blockInterval is the longest blocking command issued by RedisStore.
+// go-redis only cancels a blocking command by closing its connection once the context
+// deadline is reached, which may drop a value popped meanwhile, so blocking operations
+// issue short blocking commands instead and check the context between them.
+const blockInterval = time.Second
+
+// PushLeft inserts the given values at the head of the list stored at key,
+// one after the other, and returns the length of the list.
+func (r *RedisStore) PushLeft(ctx context.Context, key string, values ...string) (int, error) {
+	if err := r.checkConnected(OpPushLeft, key); err != nil {
+		return 0, err
+	}
+
+	// LPUSH requires at least one value.
+	if len(values) == 0 {
+		return r.Len(ctx, key)
+	}
+
+	length, err := r.client.LPush(ctx, key, stringArgs(values)...).Result()
+	if err != nil {
+		return 0, r.wrap(OpPushLeft, key, err)
+	}
+
+	return int(length), nil
+}
+
+// PushRight inserts the given values at the tail of the list stored at key
+// and returns the length of the list.
+func (r *RedisStore) PushRight(ctx context.Context, key string, values ...string) (int, error) {
+	if err := r.checkConnected(OpPushRight, key); err != nil {
+		return 0, err
+	}
+
+	// RPUSH requires at least one value.
+	if len(values) == 0 {
+		return r.Len(ctx, key)
+	}
+
+	length, err := r.client.RPush(ctx, key, stringArgs(values)...).Result()
+	if err != nil {
+		return 0, r.wrap(OpPushRight, key, err)
+	}
+
+	return int(length), nil
+}
+
+// PopLeft removes and returns the first value of the list stored at key.
+// It reports false if the list is empty.
+func (r *RedisStore) PopLeft(ctx context.Context, key string) (string, bool, error) {
+	if err := r.checkConnected(OpPopLeft, key); err != nil {
+		return "", false, err
+	}
+
+	value, err := r.client.LPop(ctx, key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, r.wrap(OpPopLeft, key, err)
+	}
+
+	return value, true, nil
+}
+
+// PopRight removes and returns the last value of the list stored at key.
+// It reports false if the list is empty.
+func (r *RedisStore) PopRight(ctx context.Context, key string) (string, bool, error) {
+	if err := r.checkConnected(OpPopRight, key); err != nil {
+		return "", false, err
+	}
+
+	value, err := r.client.RPop(ctx, key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, r.wrap(OpPopRight, key, err)
+	}
+
+	return value, true, nil
+}
+
+// BlockingPop removes and returns the first value of the first non-empty list
+// among keys, along with its key. If all lists are empty, it waits for a value
+// to be pushed until timeout elapses, or indefinitely if timeout is 0, and returns
+// an empty key on timeout. It stops waiting and returns an error when ctx is done.
+//
+// BLPOP timeouts have a one second resolution, so timeouts are rounded up to the second
+// and ctx is checked every second. With a cluster, all keys must hash to the same slot.
+func (r *RedisStore) BlockingPop(ctx context.Context, timeout time.Duration, keys ...string) (string, string, error) {
+	if err := r.checkConnected(OpBlockingPop, firstKey(keys)); err != nil {
+		return "", "", err
+	}
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", "", r.wrap(OpBlockingPop, firstKey(keys), err)
+		}
+
+		wait := blockInterval
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return "", "", nil
+			}
+
+			if remaining < wait {
+				wait = remaining
+			}
+		}
+
+		wait = (wait + time.Second - 1) / time.Second * time.Second
+
+		values, err := r.client.BLPop(context.WithoutCancel(ctx), wait, keys...).Result()
+		if err == redis.Nil {
+			continue
+		}
+
+		if err != nil {
+			return "", "", r.wrap(OpBlockingPop, firstKey(keys), err)
+		}
+
+		return values[0], values[1], nil
+	}
+}
+
+// Len returns the length of the list stored at key.
+func (r *RedisStore) Len(ctx context.Context, key string) (int, error) {
+	if err := r.checkConnected(OpLen, key); err != nil {
+		return 0, err
+	}
+
+	length, err := r.client.LLen(ctx, key).Result()
+	if err != nil {
+		return 0, r.wrap(OpLen, key, err)
+	}
+
+	return int(length), nil
+}
+
+// Range returns the values between the start and stop indexes, both included.
+// Negative indexes are offsets from the tail, -1 being the last value.
+func (r *RedisStore) Range(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	if err := r.checkConnected(OpRange, key); err != nil {
+		return nil, err
+	}
+
+	values, err := r.client.LRange(ctx, key, int64(start), int64(stop)).Result()
+	if err != nil {
+		return nil, r.wrap(OpRange, key, err)
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	return values, nil
+}
+
+func stringArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i := range values {
+		args[i] = values[i]
+	}
+
+	return args
+}
+
+var _ ListStore = &RedisStore{}
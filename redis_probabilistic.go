@@ -0,0 +1,229 @@
+package gokvstores
+
+import (
+	"context"
+	"encoding/binary"
+
+	redis "github.com/go-redis/redis/v8"
+)
+
+// Bloom filters are stored by RedisStore as strings, so that they need no Redis module:
+// a header made of bloomMagic and of the number of bits and of hash functions as big-endian
+// uint32, followed by the bits of the filter, which are set with SETBIT.
+const (
+	bloomMagic      = "BLM1"
+	bloomHeaderSize = len(bloomMagic) + 8
+)
+
+// PFAdd adds the given elements to the HyperLogLog stored at key, creating it if needed,
+// and reports whether its estimate changed.
+func (r *RedisStore) PFAdd(ctx context.Context, key string, elements ...string) (bool, error) {
+	if err := r.checkConnected(OpPFAdd, key); err != nil {
+		return false, err
+	}
+
+	changed, err := r.client.PFAdd(ctx, key, stringArgs(elements)...).Result()
+	if err != nil {
+		return false, r.wrap(OpPFAdd, key, err)
+	}
+
+	return changed == 1, nil
+}
+
+// PFCount returns the estimated number of distinct elements added to the HyperLogLogs
+// stored at keys, i.e. the cardinality of their union. Missing keys are ignored.
+// With a cluster, all keys must hash to the same slot.
+func (r *RedisStore) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	if err := r.checkConnected(OpPFCount, firstKey(keys)); err != nil {
+		return 0, err
+	}
+
+	count, err := r.client.PFCount(ctx, keys...).Result()
+	if err != nil {
+		return 0, r.wrap(OpPFCount, firstKey(keys), err)
+	}
+
+	return count, nil
+}
+
+// PFMerge stores at dest the union of the HyperLogLogs stored at dest and keys.
+// With a cluster, all keys must hash to the same slot.
+func (r *RedisStore) PFMerge(ctx context.Context, dest string, keys ...string) error {
+	if err := r.checkConnected(OpPFMerge, dest); err != nil {
+		return err
+	}
+
+	if err := r.client.PFMerge(ctx, dest, keys...).Err(); err != nil {
+		return r.wrap(OpPFMerge, dest, err)
+	}
+
+	return nil
+}
+
+// bloomHeader returns the header of a Bloom filter of m bits and k hash functions.
+func bloomHeader(m uint32, k uint32) string {
+	header := make([]byte, bloomHeaderSize)
+	copy(header, bloomMagic)
+	binary.BigEndian.PutUint32(header[len(bloomMagic):], m)
+	binary.BigEndian.PutUint32(header[len(bloomMagic)+4:], k)
+
+	return string(header)
+}
+
+// bloomFilter returns the number of bits and of hash functions of the Bloom filter stored
+// at key, or zeros if the key does not exist.
+func (r *RedisStore) bloomFilter(ctx context.Context, key string) (uint32, uint32, error) {
+	header, err := r.client.GetRange(ctx, key, 0, int64(bloomHeaderSize-1)).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return parseBloomHeader(header)
+}
+
+// parseBloomHeader returns the number of bits and of hash functions of a Bloom filter header,
+// or zeros if the header is empty.
+func parseBloomHeader(header string) (uint32, uint32, error) {
+	if header == "" {
+		return 0, 0, nil
+	}
+
+	if len(header) < bloomHeaderSize || header[:len(bloomMagic)] != bloomMagic {
+		return 0, 0, ErrWrongType
+	}
+
+	m := binary.BigEndian.Uint32([]byte(header[len(bloomMagic):]))
+	k := binary.BigEndian.Uint32([]byte(header[len(bloomMagic)+4:]))
+
+	return m, k, nil
+}
+
+// reserveBloomFilter creates a Bloom filter of m bits and k hash functions at key unless it exists,
+// and sets the given bits in the same transaction, so that a filter deleted in the meantime is
+// created again with its header rather than as a bare bitmap. Like the other capability keys,
+// the filter does not expire.
+// It returns the size of the Bloom filter stored at key and the previous values of the bits,
+// which are only meaningful if that size is m and k.
+func (r *RedisStore) reserveBloomFilter(ctx context.Context, key string, m uint32, k uint32, positions []uint32) (uint32, uint32, []*redis.IntCmd, error) {
+	var header *redis.StringCmd
+	cmds := make([]*redis.IntCmd, len(positions))
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetNX(ctx, key, bloomHeader(m, k), 0)
+		header = pipe.GetRange(ctx, key, 0, int64(bloomHeaderSize-1))
+		for i, p := range positions {
+			cmds[i] = pipe.SetBit(ctx, key, int64(bloomHeaderSize*8)+int64(p), 1)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	// The key exists after SETNX, so an empty header is an empty string.
+	m, k, err = parseBloomHeader(header.Val())
+	if err == nil && m == 0 {
+		err = ErrWrongType
+	}
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	return m, k, cmds, nil
+}
+
+// BFReserve creates an empty Bloom filter at key, sized for capacity items with the given
+// false positive rate between 0 and 1. It does nothing if key already holds a Bloom filter.
+func (r *RedisStore) BFReserve(ctx context.Context, key string, capacity int, errorRate float64) error {
+	if err := r.checkConnected(OpBFReserve, key); err != nil {
+		return err
+	}
+
+	m, k, err := bloomSize(capacity, errorRate)
+	if err != nil {
+		return r.wrap(OpBFReserve, key, err)
+	}
+
+	if _, _, _, err := r.reserveBloomFilter(ctx, key, m, k, nil); err != nil {
+		return r.wrap(OpBFReserve, key, err)
+	}
+
+	return nil
+}
+
+// BFAdd adds item to the Bloom filter stored at key and reports whether it was added,
+// i.e. it was not probably in the filter already. Missing filters are created with
+// DefaultBloomCapacity and DefaultBloomErrorRate.
+func (r *RedisStore) BFAdd(ctx context.Context, key string, item string) (bool, error) {
+	if err := r.checkConnected(OpBFAdd, key); err != nil {
+		return false, err
+	}
+
+	m, k, err := r.bloomFilter(ctx, key)
+	if err != nil {
+		return false, r.wrap(OpBFAdd, key, err)
+	}
+
+	if m == 0 {
+		m, k, _ = bloomSize(DefaultBloomCapacity, DefaultBloomErrorRate)
+	}
+
+	// The bits are set again if the filter was replaced by one of another size in the meantime.
+	for {
+		size, hashes, cmds, err := r.reserveBloomFilter(ctx, key, m, k, bloomPositions(item, m, k))
+		if err != nil {
+			return false, r.wrap(OpBFAdd, key, err)
+		}
+
+		if size != m || hashes != k {
+			m, k = size, hashes
+			continue
+		}
+
+		for _, cmd := range cmds {
+			if cmd.Val() == 0 {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+}
+
+// BFExists reports whether item was probably added to the Bloom filter stored at key.
+func (r *RedisStore) BFExists(ctx context.Context, key string, item string) (bool, error) {
+	if err := r.checkConnected(OpBFExists, key); err != nil {
+		return false, err
+	}
+
+	m, k, err := r.bloomFilter(ctx, key)
+	if err != nil || m == 0 {
+		return false, r.wrap(OpBFExists, key, err)
+	}
+
+	positions := bloomPositions(item, m, k)
+	cmds := make([]*redis.IntCmd, len(positions))
+
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, p := range positions {
+			cmds[i] = pipe.GetBit(ctx, key, int64(bloomHeaderSize*8)+int64(p))
+		}
+		return nil
+	})
+	if err != nil {
+		return false, r.wrap(OpBFExists, key, err)
+	}
+
+	for _, cmd := range cmds {
+		if cmd.Val() == 0 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+var (
+	_ HyperLogLogStore = &RedisStore{}
+	_ BloomFilterStore = &RedisStore{}
+)
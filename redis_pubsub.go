@@ -0,0 +1,109 @@
+package gokvstores
+
+import (
+	"context"
+	"fmt"
+
+	redis "github.com/go-redis/redis/v8"
+)
+
+// pubSubClient is implemented by the go-redis clients supporting Pub/Sub,
+// which is not part of redis.Cmdable.
+type pubSubClient interface {
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+	PSubscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+// Publish publishes message on channel and returns the number of subscriptions
+// which received it.
+//
+// With a cluster, messages are broadcast to all nodes but the count only includes
+// the subscriptions of the node the message was published to.
+func (r *RedisStore) Publish(ctx context.Context, channel string, message string) (int, error) {
+	if err := r.checkConnected(OpPublish, channel); err != nil {
+		return 0, err
+	}
+
+	count, err := r.client.Publish(ctx, channel, message).Result()
+	if err != nil {
+		return 0, r.wrap(OpPublish, channel, err)
+	}
+
+	return int(count), nil
+}
+
+// Subscribe subscribes to the given channels and returns the channel receiving
+// their messages, which is closed once ctx is done.
+//
+// The subscription uses a dedicated connection, which is re-established and
+// subscribed again after network failures. Messages published meanwhile are lost.
+func (r *RedisStore) Subscribe(ctx context.Context, channels ...string) (<-chan Message, error) {
+	return r.subscribe(ctx, OpSubscribe, channels, func(client pubSubClient) *redis.PubSub {
+		return client.Subscribe(ctx, channels...)
+	})
+}
+
+// PSubscribe subscribes to the channels matching the given glob-style patterns,
+// as KVStore.Keys, and returns the channel receiving their messages, which is
+// closed once ctx is done.
+//
+// As with Subscribe, the subscription is re-established after network failures.
+func (r *RedisStore) PSubscribe(ctx context.Context, patterns ...string) (<-chan Message, error) {
+	return r.subscribe(ctx, OpPSubscribe, patterns, func(client pubSubClient) *redis.PubSub {
+		return client.PSubscribe(ctx, patterns...)
+	})
+}
+
+// subscribe waits for the subscription returned by fn to be confirmed, then forwards
+// its messages until ctx is done.
+func (r *RedisStore) subscribe(ctx context.Context, op string, names []string, fn func(client pubSubClient) *redis.PubSub) (<-chan Message, error) {
+	if err := r.checkConnected(op, firstKey(names)); err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		return nil, r.wrap(op, "", errNoChannels)
+	}
+
+	client, ok := r.client.(pubSubClient)
+	if !ok {
+		return nil, r.wrap(op, firstKey(names), fmt.Errorf("gokvstores: %T does not support Pub/Sub", r.client))
+	}
+
+	pubsub := fn(client)
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, r.wrap(op, firstKey(names), err)
+	}
+
+	messages := make(chan Message, messageBufferSize)
+
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+
+		ch := pubsub.Channel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+
+				select {
+				case messages <- Message{Channel: msg.Channel, Pattern: msg.Pattern, Payload: msg.Payload}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return messages, nil
+}
+
+var _ PubSubStore = &RedisStore{}
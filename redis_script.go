@@ -0,0 +1,78 @@
+package gokvstores
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	redis "github.com/go-redis/redis/v8"
+
+	"github.com/ulule/gokvstores/internal/hashslot"
+)
+
+// RegisterScripts loads the given scripts in the script cache of Redis, on every master
+// node for a cluster. Registering scripts is optional since RunScript sends the scripts
+// missing from the cache, e.g. after a restart, but it saves sending them on first use.
+func (r *RedisStore) RegisterScripts(ctx context.Context, scripts ...*Script) error {
+	if err := r.checkConnected(OpRegisterScript, ""); err != nil {
+		return err
+	}
+
+	load := func(ctx context.Context, node redis.Cmdable) error {
+		for _, script := range scripts {
+			if err := node.ScriptLoad(ctx, script.src).Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return load(ctx, node)
+		})
+	} else {
+		err = load(ctx, r.client)
+	}
+
+	return r.wrap(OpRegisterScript, "", err)
+}
+
+// RunScript runs script atomically with the given keys and arguments, and returns its result.
+// The script is run with EVALSHA, and sent with EVAL only if Redis does not know it.
+//
+// On a cluster, the script runs on the node of its keys, which must all be in the same
+// hash slot: use hash tags, such as "{user:1}:balance" and "{user:1}:history", to group them.
+func (r *RedisStore) RunScript(ctx context.Context, script *Script, keys []string, args ...interface{}) *ScriptResult {
+	key := firstKey(keys)
+
+	if err := r.checkConnected(OpRunScript, key); err != nil {
+		return &ScriptResult{err: err}
+	}
+
+	if r.cluster {
+		for _, k := range keys[min(len(keys), 1):] {
+			if hashslot.Slot(k) != hashslot.Slot(key) {
+				return &ScriptResult{err: r.wrap(OpRunScript, key, fmt.Errorf("gokvstores: keys %q and %q are not in the same hash slot", key, k))}
+			}
+		}
+	}
+
+	val, err := r.client.EvalSha(ctx, script.hash, keys, args...).Result()
+	if err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT ") {
+		val, err = r.client.Eval(ctx, script.src, keys, args...).Result()
+	}
+
+	if err == redis.Nil {
+		return &ScriptResult{}
+	}
+
+	if err != nil {
+		return &ScriptResult{err: r.wrap(OpRunScript, key, err)}
+	}
+
+	return &ScriptResult{val: val}
+}
+
+var _ ScriptStore = &RedisStore{}
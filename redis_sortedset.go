@@ -0,0 +1,159 @@
+package gokvstores
+
+import (
+	"context"
+	"strconv"
+
+	redis "github.com/go-redis/redis/v8"
+)
+
+// ZAdd adds the given members to the sorted set stored at key, or updates their score,
+// and returns the number of added members.
+func (r *RedisStore) ZAdd(ctx context.Context, key string, members ...Z) (int, error) {
+	if err := r.checkConnected(OpZAdd, key); err != nil {
+		return 0, err
+	}
+
+	// ZADD requires at least one member.
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	zs := make([]*redis.Z, len(members))
+	for i := range members {
+		zs[i] = &redis.Z{Score: members[i].Score, Member: members[i].Member}
+	}
+
+	count, err := r.client.ZAdd(ctx, key, zs...).Result()
+	if err != nil {
+		return 0, r.wrap(OpZAdd, key, err)
+	}
+
+	return int(count), nil
+}
+
+// ZIncrBy increments the score of member in the sorted set stored at key,
+// adding it if needed, and returns its new score.
+func (r *RedisStore) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	if err := r.checkConnected(OpZIncrBy, key); err != nil {
+		return 0, err
+	}
+
+	score, err := r.client.ZIncrBy(ctx, key, increment, member).Result()
+	if err != nil {
+		return 0, r.wrap(OpZIncrBy, key, err)
+	}
+
+	return score, nil
+}
+
+// ZRangeByScore returns the members with a score between min and max, both included,
+// by ascending score. It skips offset members and returns at most count members,
+// or all of them if count <= 0.
+func (r *RedisStore) ZRangeByScore(ctx context.Context, key string, min float64, max float64, offset int, count int) ([]Z, error) {
+	if err := r.checkConnected(OpZRangeByScore, key); err != nil {
+		return nil, err
+	}
+
+	if count <= 0 {
+		count = -1
+	}
+
+	values, err := r.client.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min:    strconv.FormatFloat(min, 'g', -1, 64),
+		Max:    strconv.FormatFloat(max, 'g', -1, 64),
+		Offset: int64(offset),
+		Count:  int64(count),
+	}).Result()
+	if err != nil {
+		return nil, r.wrap(OpZRangeByScore, key, err)
+	}
+
+	return newZs(values), nil
+}
+
+// ZRevRange returns the members between the start and stop ranks, both included,
+// by descending score. Negative ranks are offsets from the lowest score, -1 being the last member.
+func (r *RedisStore) ZRevRange(ctx context.Context, key string, start int, stop int) ([]Z, error) {
+	if err := r.checkConnected(OpZRevRange, key); err != nil {
+		return nil, err
+	}
+
+	values, err := r.client.ZRevRangeWithScores(ctx, key, int64(start), int64(stop)).Result()
+	if err != nil {
+		return nil, r.wrap(OpZRevRange, key, err)
+	}
+
+	return newZs(values), nil
+}
+
+// ZRank returns the 0-based rank of member by ascending score, or -1 if it is not a member.
+func (r *RedisStore) ZRank(ctx context.Context, key string, member string) (int, error) {
+	if err := r.checkConnected(OpZRank, key); err != nil {
+		return 0, err
+	}
+
+	rank, err := r.client.ZRank(ctx, key, member).Result()
+	if err == redis.Nil {
+		return -1, nil
+	}
+
+	if err != nil {
+		return 0, r.wrap(OpZRank, key, err)
+	}
+
+	return int(rank), nil
+}
+
+// ZRem removes the given members and returns the number of removed members.
+func (r *RedisStore) ZRem(ctx context.Context, key string, members ...string) (int, error) {
+	if err := r.checkConnected(OpZRem, key); err != nil {
+		return 0, err
+	}
+
+	// ZREM requires at least one member.
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	values := make([]interface{}, len(members))
+	for i := range members {
+		values[i] = members[i]
+	}
+
+	count, err := r.client.ZRem(ctx, key, values...).Result()
+	if err != nil {
+		return 0, r.wrap(OpZRem, key, err)
+	}
+
+	return int(count), nil
+}
+
+// ZCard returns the number of members of the sorted set stored at key.
+func (r *RedisStore) ZCard(ctx context.Context, key string) (int, error) {
+	if err := r.checkConnected(OpZCard, key); err != nil {
+		return 0, err
+	}
+
+	count, err := r.client.ZCard(ctx, key).Result()
+	if err != nil {
+		return 0, r.wrap(OpZCard, key, err)
+	}
+
+	return int(count), nil
+}
+
+func newZs(values []redis.Z) []Z {
+	if len(values) == 0 {
+		return nil
+	}
+
+	zs := make([]Z, len(values))
+	for i := range values {
+		zs[i] = Z{Member: values[i].Member.(string), Score: values[i].Score}
+	}
+
+	return zs
+}
+
+var _ SortedSetStore = &RedisStore{}
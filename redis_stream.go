@@ -0,0 +1,301 @@
+package gokvstores
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	redis "github.com/go-redis/redis/v8"
+)
+
+// Append adds a message with the given values to the stream stored at key,
+// creating the stream if needed, and returns its ID.
+func (r *RedisStore) Append(ctx context.Context, key string, values map[string]string) (string, error) {
+	if err := r.checkConnected(OpAppend, key); err != nil {
+		return "", err
+	}
+
+	if len(values) == 0 {
+		return "", r.wrap(OpAppend, key, errNoStreamValues)
+	}
+
+	id, err := r.client.XAdd(ctx, &redis.XAddArgs{Stream: key, Values: values}).Result()
+	if err != nil {
+		return "", r.wrap(OpAppend, key, err)
+	}
+
+	return id, nil
+}
+
+// Read returns the messages with an ID greater than id, "0" reading the stream from its start.
+// It returns at most count messages, or all of them if count <= 0.
+func (r *RedisStore) Read(ctx context.Context, key string, id string, count int) ([]StreamMessage, error) {
+	if err := r.checkConnected(OpRead, key); err != nil {
+		return nil, err
+	}
+
+	streams, err := r.client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{key, id},
+		Count:   int64(count),
+		Block:   -1,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, r.wrap(OpRead, key, err)
+	}
+
+	return newStreamMessages(streams), nil
+}
+
+// CreateGroup creates a consumer group which delivers the messages with an ID greater than id,
+// "0" delivering the whole stream and "$" only new messages. The stream is created if needed.
+// It does nothing if the group already exists.
+func (r *RedisStore) CreateGroup(ctx context.Context, key string, group string, id string) error {
+	if err := r.checkConnected(OpCreateGroup, key); err != nil {
+		return err
+	}
+
+	err := r.client.XGroupCreateMkStream(ctx, key, group, id).Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP ") {
+		return nil
+	}
+
+	return r.wrap(OpCreateGroup, key, err)
+}
+
+// ReadGroup delivers to consumer at most count messages, or all of them if count <= 0,
+// never delivered to group. If there are none, it waits for new messages up to block,
+// or returns immediately if block <= 0. It returns ErrNoGroup if the group does not exist.
+//
+// As with BlockingPop, ctx is checked every second while waiting.
+func (r *RedisStore) ReadGroup(ctx context.Context, key string, group string, consumer string, count int, block time.Duration) ([]StreamMessage, error) {
+	if err := r.checkConnected(OpReadGroup, key); err != nil {
+		return nil, err
+	}
+
+	var deadline time.Time
+	if block > 0 {
+		deadline = time.Now().Add(block)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, r.wrap(OpReadGroup, key, err)
+		}
+
+		args := &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: consumer,
+			Streams:  []string{key, ">"},
+			Count:    int64(count),
+			Block:    -1,
+		}
+
+		cmdCtx := ctx
+		if !deadline.IsZero() {
+			wait := time.Until(deadline)
+			if wait > blockInterval {
+				wait = blockInterval
+			}
+
+			// BLOCK 0 waits indefinitely.
+			if wait < time.Millisecond {
+				wait = time.Millisecond
+			}
+
+			args.Block = wait
+			cmdCtx = context.WithoutCancel(ctx)
+		}
+
+		streams, err := r.client.XReadGroup(cmdCtx, args).Result()
+		if err == redis.Nil {
+			if !deadline.IsZero() && time.Now().Before(deadline) {
+				continue
+			}
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, r.wrap(OpReadGroup, key, err)
+		}
+
+		return newStreamMessages(streams), nil
+	}
+}
+
+// Ack acknowledges the given pending messages of group and returns the number of acknowledged messages.
+func (r *RedisStore) Ack(ctx context.Context, key string, group string, ids ...string) (int, error) {
+	if err := r.checkConnected(OpAck, key); err != nil {
+		return 0, err
+	}
+
+	// XACK requires at least one ID.
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	count, err := r.client.XAck(ctx, key, group, ids...).Result()
+	if err != nil {
+		return 0, r.wrap(OpAck, key, err)
+	}
+
+	return int(count), nil
+}
+
+// Pending returns the pending messages of group by ascending ID.
+// It returns at most count messages, or all of them if count <= 0.
+func (r *RedisStore) Pending(ctx context.Context, key string, group string, count int) ([]PendingMessage, error) {
+	if err := r.checkConnected(OpPending, key); err != nil {
+		return nil, err
+	}
+
+	limit := int64(count)
+	if count <= 0 {
+		limit = math.MaxInt64
+	}
+
+	values, err := r.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: key,
+		Group:  group,
+		Start:  "-",
+		End:    "+",
+		Count:  limit,
+	}).Result()
+	if err != nil {
+		return nil, r.wrap(OpPending, key, err)
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	pending := make([]PendingMessage, len(values))
+	for i := range values {
+		pending[i] = PendingMessage{
+			ID:         values[i].ID,
+			Consumer:   values[i].Consumer,
+			Idle:       values[i].Idle,
+			Deliveries: int(values[i].RetryCount),
+		}
+	}
+
+	return pending, nil
+}
+
+// Claim transfers to consumer at most count messages, or all of them if count <= 0,
+// pending in group for at least minIdle, and returns them. Pending messages which were
+// removed from the stream are not returned.
+//
+// It uses XAUTOCLAIM, which requires Redis 6.2 or later. The reply is parsed here since
+// go-redis v8 does not support the reply of Redis 7.
+func (r *RedisStore) Claim(ctx context.Context, key string, group string, consumer string, minIdle time.Duration, count int) ([]StreamMessage, error) {
+	if err := r.checkConnected(OpClaim, key); err != nil {
+		return nil, err
+	}
+
+	var messages []StreamMessage
+
+	start := "0-0"
+	for {
+		args := []interface{}{"xautoclaim", key, group, consumer, int64(minIdle / time.Millisecond), start}
+		if count > 0 {
+			args = append(args, "count", count-len(messages))
+		}
+
+		cmd := redis.NewSliceCmd(ctx, args...)
+		_ = r.client.Process(ctx, cmd)
+
+		reply, err := cmd.Result()
+		if err != nil {
+			return nil, r.wrap(OpClaim, key, err)
+		}
+
+		next, claimed, err := parseAutoClaim(reply)
+		if err != nil {
+			return nil, r.wrap(OpClaim, key, err)
+		}
+
+		messages = append(messages, claimed...)
+
+		if next == "0-0" || (count > 0 && len(messages) >= count) {
+			return messages, nil
+		}
+
+		start = next
+	}
+}
+
+// Trim removes the oldest messages of the stream stored at key so that it holds
+// at most maxLen messages, and returns the number of removed messages.
+func (r *RedisStore) Trim(ctx context.Context, key string, maxLen int) (int, error) {
+	if err := r.checkConnected(OpTrim, key); err != nil {
+		return 0, err
+	}
+
+	count, err := r.client.XTrimMaxLen(ctx, key, int64(maxLen)).Result()
+	if err != nil {
+		return 0, r.wrap(OpTrim, key, err)
+	}
+
+	return int(count), nil
+}
+
+func newStreamMessages(streams []redis.XStream) []StreamMessage {
+	var messages []StreamMessage
+	for _, stream := range streams {
+		for _, message := range stream.Messages {
+			values := make(map[string]string, len(message.Values))
+			for field, value := range message.Values {
+				values[field] = fmt.Sprint(value)
+			}
+
+			messages = append(messages, StreamMessage{ID: message.ID, Values: values})
+		}
+	}
+
+	return messages
+}
+
+// parseAutoClaim parses a XAUTOCLAIM reply, "next-cursor [[id [field value ...]] ...] [deleted-id ...]",
+// the list of deleted IDs being only returned by Redis 7. Redis 6.2 returns nil entries instead.
+func parseAutoClaim(reply []interface{}) (string, []StreamMessage, error) {
+	if len(reply) < 2 {
+		return "", nil, fmt.Errorf("gokvstores: unexpected XAUTOCLAIM reply %v", reply)
+	}
+
+	next, ok := reply[0].(string)
+	entries, ok2 := reply[1].([]interface{})
+	if !ok || !ok2 {
+		return "", nil, fmt.Errorf("gokvstores: unexpected XAUTOCLAIM reply %v", reply)
+	}
+
+	var messages []StreamMessage
+	for _, entry := range entries {
+		entry, ok := entry.([]interface{})
+		if !ok || len(entry) != 2 {
+			continue
+		}
+
+		id, ok := entry[0].(string)
+		fields, ok2 := entry[1].([]interface{})
+		if !ok || !ok2 {
+			continue
+		}
+
+		message := StreamMessage{ID: id, Values: make(map[string]string, len(fields)/2)}
+		for i := 0; i+1 < len(fields); i += 2 {
+			message.Values[fmt.Sprint(fields[i])] = fmt.Sprint(fields[i+1])
+		}
+
+		messages = append(messages, message)
+	}
+
+	return next, messages, nil
+}
+
+var _ StreamStore = &RedisStore{}
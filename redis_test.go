@@ -1,25 +1,66 @@
-package gokvstores
+package gokvstores_test
 
 import (
 	"context"
+	"errors"
+	"net"
+	"os"
 	"sort"
+	"strconv"
 	"testing"
 	"time"
 
+	redis "github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ulule/gokvstores"
+	"github.com/ulule/gokvstores/kvstoretest"
+	"github.com/ulule/gokvstores/redistest"
 )
 
+// newRedisServer returns the address of the Redis server to test against and a function to stop it.
+// Tests run against an in-process server, which runs the emulations of the scripts of kvstoretest,
+// unless REDIS_ADDR is set.
+func newRedisServer(t *testing.T) (string, func()) {
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		return addr, func() {}
+	}
+
+	server, err := redistest.NewServer()
+	require.NoError(t, err)
+
+	registerScripts(server)
+
+	return server.Addr(), func() {
+		assert.NoError(t, server.Close())
+	}
+}
+
+func newRedisClientStore(t *testing.T, options *gokvstores.RedisClientOptions) gokvstores.KVStore {
+	store, err := gokvstores.NewRedisClientStore(context.Background(), options, time.Second*30)
+
+	assert.Nil(t, err)
+
+	return store
+}
+
 func TestRedisStore(t *testing.T) {
-	ctx := context.Background()
-	store, err := NewRedisClientStore(ctx, &RedisClientOptions{
-		Addr:     "localhost:6379",
+	addr, closeServer := newRedisServer(t)
+	defer closeServer()
+
+	options := &gokvstores.RedisClientOptions{
+		Addr:     addr,
 		Password: "",
 		DB:       0,
-	}, time.Second*30)
+	}
 
-	assert.Nil(t, err)
+	kvstoretest.RunConformance(t, func(t *testing.T) gokvstores.KVStore {
+		return newRedisClientStore(t, options)
+	})
 
-	testStore(t, store)
+	ctx := context.Background()
+	store := newRedisClientStore(t, options)
 
 	is := assert.New(t)
 
@@ -31,7 +72,7 @@ func TestRedisStore(t *testing.T) {
 	expectedStrings := []string{"order1", "order2", "order3"}
 
 	for key, expected := range mapResults {
-		err = store.SetMap(ctx, key, expected)
+		err := store.SetMap(ctx, key, expected)
 		is.NoError(err)
 	}
 
@@ -49,3 +90,585 @@ func TestRedisStore(t *testing.T) {
 
 	assert.Nil(t, store.Close())
 }
+
+func TestRedisStore_Capabilities(t *testing.T) {
+	addr, closeServer := newRedisServer(t)
+	defer closeServer()
+
+	runCapabilityConformance(t, func(t *testing.T) gokvstores.KVStore {
+		return newRedisClientStore(t, &gokvstores.RedisClientOptions{Addr: addr})
+	})
+}
+
+func TestRedisStore_PubSubReconnect(t *testing.T) {
+	is := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server, err := redistest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	store := newRedisClientStore(t, &gokvstores.RedisClientOptions{Addr: server.Addr()}).(gokvstores.PubSubStore)
+
+	messages, err := store.Subscribe(ctx, "news")
+	require.NoError(t, err)
+
+	server.CloseConnections()
+
+	// Messages published before the subscription is re-established are lost.
+	is.Eventually(func() bool {
+		count, err := store.Publish(ctx, "news", "hello")
+		return err == nil && count == 1
+	}, 10*time.Second, 50*time.Millisecond)
+
+	select {
+	case msg := <-messages:
+		is.Equal(gokvstores.Message{Channel: "news", Payload: "hello"}, msg)
+	case <-time.After(5 * time.Second):
+		is.Fail("no message received")
+	}
+}
+
+// TestRedisStore_HyperLogLogEstimates checks that MemoryStore gives the exact estimates
+// of Redis, which needs a real server since the in-process server shares its sketches.
+func TestRedisStore_HyperLogLogEstimates(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR is not set")
+	}
+
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store := newRedisClientStore(t, &gokvstores.RedisClientOptions{Addr: addr})
+	redisStore := store.(gokvstores.HyperLogLogStore)
+
+	memoryStore := newMemoryStore(t).(gokvstores.HyperLogLogStore)
+
+	for _, n := range []int{1, 10, 100, 1000, 10000, 100000} {
+		key := "hll:estimates:" + strconv.Itoa(n)
+		is.NoError(store.Delete(ctx, key))
+
+		elements := make([]string, 0, 1000)
+		for i := 1; i <= n; i++ {
+			elements = append(elements, strconv.Itoa(i))
+			if len(elements) == cap(elements) || i == n {
+				_, err := redisStore.PFAdd(ctx, key, elements...)
+				is.NoError(err)
+				_, err = memoryStore.PFAdd(ctx, key, elements...)
+				is.NoError(err)
+				elements = elements[:0]
+			}
+		}
+
+		expected, err := redisStore.PFCount(ctx, key)
+		is.NoError(err)
+
+		count, err := memoryStore.PFCount(ctx, key)
+		is.NoError(err)
+		is.Equal(expected, count, "%d elements", n)
+
+		is.NoError(store.Delete(ctx, key))
+	}
+}
+
+func TestRedisStore_CapabilityExpiration(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	addr, closeServer := newRedisServer(t)
+	defer closeServer()
+
+	store := newRedisClientStore(t, &gokvstores.RedisClientOptions{Addr: addr}).(*gokvstores.RedisStore)
+	defer store.Close()
+
+	is.NoError(store.Flush(ctx))
+
+	// The store expiration only applies to KVStore values, as in MemoryStore.
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	ttl := store.Client().PTTL(ctx, "key").Val()
+	is.True(ttl > 0 && ttl <= 30*time.Second, ttl)
+
+	for _, key := range createCapabilityKeys(t, store) {
+		is.Equal(time.Duration(-1), store.Client().PTTL(ctx, key).Val(), key)
+	}
+}
+
+// deleteHook deletes key before each pipeline setting bits, as a concurrent client would.
+type deleteHook struct {
+	client *redis.Client
+	key    string
+}
+
+func (h deleteHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h deleteHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h deleteHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	for _, cmd := range cmds {
+		if cmd.Name() == "setbit" {
+			return ctx, h.client.Del(ctx, h.key).Err()
+		}
+	}
+	return ctx, nil
+}
+
+func (h deleteHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestRedisStore_BloomFilterDeleted(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	addr, closeServer := newRedisServer(t)
+	defer closeServer()
+
+	store := newRedisClientStore(t, &gokvstores.RedisClientOptions{Addr: addr}).(*gokvstores.RedisStore)
+	defer store.Close()
+
+	is.NoError(store.Flush(ctx))
+	is.NoError(store.BFReserve(ctx, "bloom", 100, 0.01))
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+
+	// A filter deleted while an item is added is created again with its header,
+	// rather than as a bitmap which later calls would reject with ErrWrongType.
+	store.AddHook(deleteHook{client: client, key: "bloom"})
+
+	for _, item := range []string{"a", "b"} {
+		added, err := store.BFAdd(ctx, "bloom", item)
+		is.NoError(err)
+		is.True(added)
+
+		exists, err := store.BFExists(ctx, "bloom", item)
+		is.NoError(err)
+		is.True(exists)
+	}
+}
+
+// registerScripts registers the emulations of the kvstoretest scripts on server.
+func registerScripts(server *redistest.Server) {
+	server.RegisterScript(kvstoretest.CompareAndSwapScript.Source(), func(call func(args ...string) (interface{}, error), keys []string, args []string) (interface{}, error) {
+		value, err := call("GET", keys[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if s, _ := value.(string); s != args[0] {
+			return int64(0), nil
+		}
+
+		if _, err := call("SET", keys[0], args[1]); err != nil {
+			return nil, err
+		}
+
+		return int64(1), nil
+	})
+
+	server.RegisterScript(kvstoretest.GetScript.Source(), func(call func(args ...string) (interface{}, error), keys []string, args []string) (interface{}, error) {
+		value, err := call("GET", keys[0])
+		if value == nil || err != nil {
+			return false, err
+		}
+
+		return value, nil
+	})
+
+	server.RegisterScript(kvstoretest.EchoScript.Source(), func(call func(args ...string) (interface{}, error), keys []string, args []string) (interface{}, error) {
+		values := make([]interface{}, 0, len(keys)+len(args))
+		for _, v := range append(append([]string{}, keys...), args...) {
+			values = append(values, v)
+		}
+
+		return values, nil
+	})
+}
+
+func TestRedisStore_RunScript(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	addr, closeServer := newRedisServer(t)
+	defer closeServer()
+
+	store := newRedisClientStore(t, &gokvstores.RedisClientOptions{Addr: addr}).(*gokvstores.RedisStore)
+	defer store.Close()
+
+	client := store.Client()
+	is.NoError(client.ScriptFlush(ctx).Err())
+
+	script := kvstoretest.EchoScript
+	is.Equal(script.Hash(), client.ScriptLoad(ctx, script.Source()).Val())
+	is.NoError(client.ScriptFlush(ctx).Err())
+
+	// Scripts missing from the script cache are sent with EVAL.
+	strs, err := store.RunScript(ctx, script, []string{"key"}, "value").StringSlice()
+	is.NoError(err)
+	is.Equal([]string{"key", "value"}, strs)
+	is.Equal([]bool{true}, client.ScriptExists(ctx, script.Hash()).Val())
+
+	is.NoError(client.ScriptFlush(ctx).Err())
+	is.NoError(store.RegisterScripts(ctx, kvstoretest.GetScript, script))
+	is.Equal([]bool{true, true}, client.ScriptExists(ctx, kvstoretest.GetScript.Hash(), script.Hash()).Val())
+
+	// Keys of scripts run on a cluster must be in the same hash slot.
+	cluster := gokvstores.NewRedisStoreFromClient(redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{addr}}), time.Minute)
+	defer cluster.Close()
+
+	err = cluster.RunScript(ctx, script, []string{"{user:1}:balance", "user:2"}).Err()
+	is.Error(err)
+
+	var opErr *gokvstores.OpError
+	is.True(errors.As(err, &opErr))
+	is.Equal(gokvstores.OpRunScript, opErr.Op)
+	is.Equal("{user:1}:balance", opErr.Key)
+}
+
+func TestRedisStore_Failures(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, err := redistest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	server.FailNext("ping", 1, "LOADING Redis is loading the dataset in memory")
+
+	_, err = gokvstores.NewRedisClientStore(ctx, &gokvstores.RedisClientOptions{Addr: server.Addr(), MaxRetries: -1}, time.Minute)
+	is.EqualError(err, "gokvstores: redis Ping: LOADING Redis is loading the dataset in memory")
+	is.True(errors.Is(err, gokvstores.ErrUnavailable))
+
+	store := newRedisClientStore(t, &gokvstores.RedisClientOptions{
+		Addr:        server.Addr(),
+		MaxRetries:  -1,
+		ReadTimeout: 100 * time.Millisecond,
+	})
+	defer store.Close()
+
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	// Error replies
+
+	server.FailNext("get", 1, "ERR injected failure")
+
+	_, err = store.Get(ctx, "key")
+	is.EqualError(err, `gokvstores: redis Get "key": ERR injected failure`)
+
+	var opErr *gokvstores.OpError
+	is.True(errors.As(err, &opErr))
+	is.Equal(gokvstores.OpGet, opErr.Op)
+	is.Equal("key", opErr.Key)
+	is.Equal(gokvstores.BackendRedis, opErr.Backend)
+
+	v, err := store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("value", v)
+
+	// Timeouts
+
+	server.SetLatency(300 * time.Millisecond)
+
+	_, err = store.Get(ctx, "key")
+	var netErr net.Error
+	is.True(errors.As(err, &netErr))
+	is.True(netErr != nil && netErr.Timeout())
+	is.True(errors.Is(err, gokvstores.ErrTimeout))
+
+	server.SetLatency(0)
+
+	v, err = store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("value", v)
+
+	// Connection drops
+
+	server.CloseConnections()
+
+	_, err = store.Get(ctx, "key")
+	is.True(errors.Is(err, gokvstores.ErrUnavailable))
+
+	v, err = store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("value", v)
+}
+
+func TestRedisStore_WrongType(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	addr, closeServer := newRedisServer(t)
+	defer closeServer()
+
+	store := newRedisClientStore(t, &gokvstores.RedisClientOptions{Addr: addr})
+	defer store.Close()
+
+	is.NoError(store.Flush(ctx))
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	_, err := store.GetMap(ctx, "key")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+	is.False(errors.Is(err, gokvstores.ErrUnavailable))
+
+	_, err = store.GetSlice(ctx, "key")
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+
+	is.True(errors.Is(store.AppendSlice(ctx, "key", "a"), gokvstores.ErrWrongType))
+
+	_, err = store.GetMaps(ctx, []string{"key"})
+	is.True(errors.Is(err, gokvstores.ErrWrongType))
+}
+
+func TestRedisStore_ConnectSkip(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, err := redistest.NewServer()
+	require.NoError(t, err)
+	addr := server.Addr()
+	require.NoError(t, server.Close())
+
+	store, err := gokvstores.NewRedisClientStore(ctx, &gokvstores.RedisClientOptions{
+		Addr:       addr,
+		MaxRetries: -1,
+		Connect:    gokvstores.ConnectSkip,
+	}, time.Minute)
+	is.NoError(err)
+	defer store.Close()
+
+	select {
+	case <-store.(*gokvstores.RedisStore).Ready():
+	default:
+		t.Error("store should be ready")
+	}
+
+	_, err = store.Get(ctx, "key")
+	is.True(errors.Is(err, gokvstores.ErrUnavailable))
+}
+
+func TestRedisStore_ConnectBackground(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, err := redistest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	server.FailNext("ping", 3, "LOADING Redis is loading the dataset in memory")
+
+	store, err := gokvstores.NewRedisClientStore(ctx, &gokvstores.RedisClientOptions{
+		Addr:              server.Addr(),
+		MaxRetries:        -1,
+		Connect:           gokvstores.ConnectBackground,
+		ConnectMinBackoff: 50 * time.Millisecond,
+	}, time.Minute)
+	is.NoError(err)
+	defer store.Close()
+
+	err = store.Set(ctx, "key", "value")
+	is.True(errors.Is(err, gokvstores.ErrUnavailable))
+
+	var opErr *gokvstores.OpError
+	is.True(errors.As(err, &opErr))
+	is.Equal(gokvstores.OpSet, opErr.Op)
+
+	status := store.(*gokvstores.RedisStore).Health(ctx)
+	is.False(status.Healthy)
+	is.True(errors.Is(status.Err, gokvstores.ErrUnavailable))
+
+	select {
+	case <-store.(*gokvstores.RedisStore).Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("store should be ready")
+	}
+
+	is.True(store.(*gokvstores.RedisStore).Health(ctx).Healthy)
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	v, err := store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("value", v)
+}
+
+func TestRedisStore_ConnectBackgroundHealth(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, err := redistest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	// Only the first ping fails, and the store does not retry before the end of the test:
+	// health checks must not report it healthy although Redis answers them.
+	server.FailNext("ping", 1, "LOADING Redis is loading the dataset in memory")
+
+	store, err := gokvstores.NewRedisClientStore(ctx, &gokvstores.RedisClientOptions{
+		Addr:              server.Addr(),
+		MaxRetries:        -1,
+		Connect:           gokvstores.ConnectBackground,
+		ConnectMinBackoff: time.Hour,
+	}, time.Minute)
+	is.NoError(err)
+	defer store.Close()
+
+	for i := 0; i < 2; i++ {
+		status := store.(*gokvstores.RedisStore).Health(ctx)
+		is.False(status.Healthy)
+		is.True(errors.Is(status.Err, gokvstores.ErrUnavailable))
+	}
+
+	is.True(errors.Is(store.Set(ctx, "key", "value"), gokvstores.ErrUnavailable))
+}
+
+func TestRedisStore_ConnectBackgroundClose(t *testing.T) {
+	is := assert.New(t)
+
+	server, err := redistest.NewServer()
+	require.NoError(t, err)
+	addr := server.Addr()
+	require.NoError(t, server.Close())
+
+	store, err := gokvstores.NewRedisClientStore(context.Background(), &gokvstores.RedisClientOptions{
+		Addr:    addr,
+		Connect: gokvstores.ConnectBackground,
+	}, time.Minute)
+	is.NoError(err)
+
+	is.NoError(store.Close())
+
+	select {
+	case <-store.(*gokvstores.RedisStore).Ready():
+		t.Error("store should not be ready")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRedisStore_Options(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, err := redistest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	// ReadOnly sends READONLY on every new connection, before OnConnect.
+
+	connected := 0
+
+	store := newRedisClientStore(t, &gokvstores.RedisClientOptions{
+		Addr:     server.Addr(),
+		ReadOnly: true,
+		OnConnect: func(ctx context.Context, cn *redis.Conn) error {
+			connected++
+			return nil
+		},
+	})
+	is.NoError(store.Set(ctx, "key", "value"))
+	is.Equal(1, connected)
+	is.NoError(store.Close())
+
+	server.FailNext("readonly", 1, "ERR This instance has cluster support disabled")
+
+	_, err = gokvstores.NewRedisClientStore(ctx, &gokvstores.RedisClientOptions{
+		Addr:       server.Addr(),
+		MaxRetries: -1,
+		ReadOnly:   true,
+	}, time.Minute)
+	is.EqualError(err, "gokvstores: redis Ping: ERR This instance has cluster support disabled")
+
+	// Raw options take precedence.
+
+	store = newRedisClientStore(t, &gokvstores.RedisClientOptions{
+		Addr: "127.0.0.1:1",
+		Raw:  &redis.Options{Addr: server.Addr(), DB: 2},
+	})
+	defer store.Close()
+
+	is.Equal(2, store.(*gokvstores.RedisStore).DB())
+	is.NoError(store.Set(ctx, "key", "value"))
+}
+
+func TestNewRedisStoreFromClient(t *testing.T) {
+	addr, closeServer := newRedisServer(t)
+	defer closeServer()
+
+	kvstoretest.RunConformance(t, func(t *testing.T) gokvstores.KVStore {
+		return gokvstores.NewRedisStoreFromClient(redis.NewClient(&redis.Options{Addr: addr}), time.Minute)
+	})
+
+	is := assert.New(t)
+
+	store := gokvstores.NewRedisStoreFromClient(redis.NewClient(&redis.Options{Addr: addr, DB: 3}), time.Minute)
+	defer store.Close()
+
+	is.Equal(3, store.DB())
+	is.False(store.Cluster())
+
+	cluster := gokvstores.NewRedisStoreFromClient(redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{addr}}), time.Minute)
+	defer cluster.Close()
+
+	is.True(cluster.Cluster())
+}
+
+func TestRedisStore_Client(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	addr, closeServer := newRedisServer(t)
+	defer closeServer()
+
+	store := newRedisClientStore(t, &gokvstores.RedisClientOptions{Addr: addr}).(*gokvstores.RedisStore)
+	defer store.Close()
+
+	is.NoError(store.Flush(ctx))
+	is.NoError(store.SetSlice(ctx, "set", []interface{}{"a", "b"}))
+
+	client := store.Client()
+	is.IsType(&redis.Client{}, client)
+	is.Equal(int64(2), client.SCard(ctx, "set").Val())
+
+	cmds, err := store.Pipeline(ctx, func(pipeline *gokvstores.RedisStore) error {
+		is.IsType(gokvstores.RedisPipeline{}, pipeline.Client())
+
+		pipeline.Client().SCard(ctx, "set")
+		return pipeline.Set(ctx, "key", "value")
+	})
+	is.NoError(err)
+	is.Len(cmds, 2)
+	is.Equal(int64(2), cmds[0].(*redis.IntCmd).Val())
+
+	v, err := store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("value", v)
+}
+
+func TestRedisStore_Retries(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, err := redistest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	store := newRedisClientStore(t, &gokvstores.RedisClientOptions{
+		Addr:       server.Addr(),
+		MaxRetries: 3,
+	})
+	defer store.Close()
+
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	server.CloseConnections()
+
+	v, err := store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("value", v)
+}
@@ -0,0 +1,179 @@
+package gokvstores
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	redis "github.com/go-redis/redis/v8"
+)
+
+// Watch returns the channel receiving the events of the keys matching the given
+// glob-style pattern, as KVStore.Keys, which is closed once ctx is done.
+//
+// It subscribes to keyspace notifications, on every master node for a cluster, and
+// enables them with CONFIG SET if needed. If CONFIG is not allowed, as on some managed
+// services, notify-keyspace-events must include the "KA" flags in the server configuration.
+// Cluster nodes added after Watch is called are not watched.
+func (r *RedisStore) Watch(ctx context.Context, pattern string) (<-chan Event, error) {
+	if err := r.checkConnected(OpWatch, pattern); err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("__keyspace@%d__:", r.db)
+
+	pubsubs, err := r.watch(ctx, prefix+pattern)
+	if err != nil {
+		return nil, r.wrap(OpWatch, pattern, err)
+	}
+
+	events := make(chan Event, messageBufferSize)
+
+	var wg sync.WaitGroup
+	for _, pubsub := range pubsubs {
+		wg.Add(1)
+
+		go func(pubsub *redis.PubSub) {
+			defer wg.Done()
+			defer pubsub.Close()
+
+			ch := pubsub.Channel()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg, ok := <-ch:
+					if !ok {
+						return
+					}
+
+					typ, ok := keyspaceEventType(msg.Payload)
+					if !ok {
+						continue
+					}
+
+					select {
+					case events <- Event{Type: typ, Key: strings.TrimPrefix(msg.Channel, prefix)}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}(pubsub)
+	}
+
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+
+	return events, nil
+}
+
+// watch enables keyspace notifications and subscribes to the given channel pattern,
+// on every master node for a cluster.
+func (r *RedisStore) watch(ctx context.Context, pattern string) ([]*redis.PubSub, error) {
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		var (
+			mu      sync.Mutex
+			pubsubs []*redis.PubSub
+		)
+
+		err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			pubsub, err := psubscribeKeyspace(ctx, node, node, pattern)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			pubsubs = append(pubsubs, pubsub)
+			mu.Unlock()
+
+			return nil
+		})
+		if err != nil {
+			for _, pubsub := range pubsubs {
+				pubsub.Close()
+			}
+			return nil, err
+		}
+
+		return pubsubs, nil
+	}
+
+	client, ok := r.client.(pubSubClient)
+	if !ok {
+		return nil, fmt.Errorf("gokvstores: %T does not support Pub/Sub", r.client)
+	}
+
+	pubsub, err := psubscribeKeyspace(ctx, r.client, client, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*redis.PubSub{pubsub}, nil
+}
+
+// psubscribeKeyspace enables keyspace notifications on a node and subscribes to the given channel pattern.
+func psubscribeKeyspace(ctx context.Context, node redis.Cmdable, client pubSubClient, pattern string) (*redis.PubSub, error) {
+	// Errors are ignored since notifications may be enabled in the server configuration.
+	_ = enableKeyspaceEvents(ctx, node)
+
+	pubsub := client.PSubscribe(ctx, pattern)
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	return pubsub, nil
+}
+
+// enableKeyspaceEvents enables the keyspace notifications (K) of all events (A), keeping the enabled flags.
+func enableKeyspaceEvents(ctx context.Context, client redis.Cmdable) error {
+	values, err := client.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		return err
+	}
+
+	var flags string
+	if len(values) == 2 {
+		flags, _ = values[1].(string)
+	}
+
+	missing := ""
+	if !strings.Contains(flags, "K") {
+		missing += "K"
+	}
+
+	if !strings.Contains(flags, "A") {
+		missing += "A"
+	}
+
+	if missing == "" {
+		return nil
+	}
+
+	return client.ConfigSet(ctx, "notify-keyspace-events", flags+missing).Err()
+}
+
+// keyspaceEventType returns the type of the given keyspace event, which is the name
+// of the command changing the key, or false if the event does not change the key value.
+func keyspaceEventType(event string) (EventType, bool) {
+	switch event {
+	case "del", "rename_from":
+		return EventDelete, true
+	case "expired":
+		return EventExpire, true
+	case "evicted":
+		return EventEvict, true
+	case "expire", "persist", "new":
+		return 0, false
+	default:
+		return EventSet, true
+	}
+}
+
+var _ WatchStore = &RedisStore{}
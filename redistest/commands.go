@@ -0,0 +1,2546 @@
+package redistest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ulule/gokvstores/internal/geohash"
+	"github.com/ulule/gokvstores/internal/glob"
+	"github.com/ulule/gokvstores/internal/hll"
+)
+
+// command is a Redis command implementation.
+// A positive arity is the exact number of arguments, command name included,
+// a negative arity is the minimum number of arguments.
+type command struct {
+	arity int
+	fn    func(s *Server, c *conn, args []string) interface{}
+}
+
+var commands = map[string]command{
+	// Connection
+	"ping":   {-1, cmdPing},
+	"echo":   {2, cmdEcho},
+	"select": {2, cmdSelect},
+	"auth":   {-2, cmdAuth},
+	"quit":   {1, cmdQuit},
+	"role":   {1, cmdRole},
+
+	// Cluster
+	"cluster":   {-2, cmdCluster},
+	"readonly":  {1, cmdReadOnly},
+	"readwrite": {1, cmdReadWrite},
+
+	// Server
+	"config": {-3, cmdConfig},
+
+	// Keys
+	"del":      {-2, cmdDel},
+	"exists":   {-2, cmdExists},
+	"keys":     {2, cmdKeys},
+	"expire":   {3, cmdExpire},
+	"pexpire":  {3, cmdPExpire},
+	"ttl":      {2, cmdTTL},
+	"pttl":     {2, cmdPTTL},
+	"type":     {2, cmdType},
+	"dbsize":   {1, cmdDBSize},
+	"flushdb":  {-1, cmdFlushDB},
+	"flushall": {-1, cmdFlushAll},
+
+	// Strings
+	"get":   {2, cmdGet},
+	"set":   {-3, cmdSet},
+	"mget":  {-2, cmdMGet},
+	"setnx": {3, cmdSetNX},
+
+	// Bitmaps
+	"getrange": {4, cmdGetRange},
+	"setbit":   {4, cmdSetBit},
+	"getbit":   {3, cmdGetBit},
+	"bitcount": {-2, cmdBitCount},
+	"bitop":    {-4, cmdBitOp},
+	"bitpos":   {-3, cmdBitPos},
+
+	// HyperLogLogs
+	"pfadd":   {-2, cmdPFAdd},
+	"pfcount": {-2, cmdPFCount},
+	"pfmerge": {-2, cmdPFMerge},
+
+	// Hashes
+	"hget":    {3, cmdHGet},
+	"hmget":   {-3, cmdHMGet},
+	"hgetall": {2, cmdHGetAll},
+	"hset":    {-4, cmdHSet},
+	"hmset":   {-4, cmdHMSet},
+	"hdel":    {-3, cmdHDel},
+
+	// Sets
+	"sadd":     {-3, cmdSAdd},
+	"srem":     {-3, cmdSRem},
+	"smembers": {2, cmdSMembers},
+	"scard":    {2, cmdSCard},
+
+	// Sorted sets
+	"zadd":             {-4, cmdZAdd},
+	"zincrby":          {4, cmdZIncrBy},
+	"zrange":           {-4, cmdZRange},
+	"zrangebyscore":    {-4, cmdZRangeByScore},
+	"zrevrange":        {-4, cmdZRevRange},
+	"zremrangebyscore": {4, cmdZRemRangeByScore},
+	"zrank":            {3, cmdZRank},
+	"zrem":             {-3, cmdZRem},
+	"zcard":            {2, cmdZCard},
+	"zscore":           {3, cmdZScore},
+
+	// Geospatial indexes
+	"geoadd":    {-5, cmdGeoAdd},
+	"geopos":    {-2, cmdGeoPos},
+	"geodist":   {-4, cmdGeoDist},
+	"geosearch": {-7, cmdGeoSearch},
+
+	// Lists
+	"lpush":  {-3, cmdLPush},
+	"rpush":  {-3, cmdRPush},
+	"lpop":   {2, cmdLPop},
+	"rpop":   {2, cmdRPop},
+	"blpop":  {-3, cmdBLPop},
+	"llen":   {2, cmdLLen},
+	"lrange": {4, cmdLRange},
+
+	// Streams
+	"xadd":       {-5, cmdXAdd},
+	"xlen":       {2, cmdXLen},
+	"xread":      {-4, cmdXRead},
+	"xgroup":     {-2, cmdXGroup},
+	"xreadgroup": {-7, cmdXReadGroup},
+	"xack":       {-4, cmdXAck},
+	"xpending":   {-3, cmdXPending},
+	"xautoclaim": {-6, cmdXAutoClaim},
+	"xtrim":      {-4, cmdXTrim},
+
+	// Scripting, see init
+
+	// Pub/Sub
+	"subscribe":  {-2, cmdSubscribe},
+	"psubscribe": {-2, cmdPSubscribe},
+	"publish":    {3, cmdPublish},
+}
+
+// ----------------------------------------------------------------------------
+// Connection
+// ----------------------------------------------------------------------------
+
+func cmdPing(s *Server, c *conn, args []string) interface{} {
+	switch len(args) {
+	case 0:
+		return status("PONG")
+	case 1:
+		return args[0]
+	default:
+		return errWrongNumberOfArgs("ping")
+	}
+}
+
+func cmdEcho(s *Server, c *conn, args []string) interface{} {
+	return args[0]
+}
+
+func cmdSelect(s *Server, c *conn, args []string) interface{} {
+	index, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errNotInt
+	}
+
+	if index < 0 || index > 15 {
+		return redisError("ERR DB index is out of range")
+	}
+
+	c.db = index
+
+	return statusOK
+}
+
+func cmdAuth(s *Server, c *conn, args []string) interface{} {
+	return statusOK
+}
+
+func cmdQuit(s *Server, c *conn, args []string) interface{} {
+	c.quit = true
+	return statusOK
+}
+
+func cmdRole(s *Server, c *conn, args []string) interface{} {
+	return []interface{}{"master", 0, []interface{}{}}
+}
+
+// ----------------------------------------------------------------------------
+// Cluster
+// ----------------------------------------------------------------------------
+
+// cmdCluster implements CLUSTER INFO and CLUSTER SLOTS of a cluster made of the server
+// alone, which serves all the hash slots.
+func cmdCluster(s *Server, c *conn, args []string) interface{} {
+	switch strings.ToLower(args[0]) {
+	case "info":
+		return "cluster_state:ok\r\ncluster_slots_assigned:16384\r\ncluster_known_nodes:1\r\ncluster_size:1\r\n"
+	case "slots":
+		host, port, _ := net.SplitHostPort(s.Addr())
+		p, _ := strconv.Atoi(port)
+
+		return []interface{}{[]interface{}{0, 16383, []interface{}{host, p, "redistest"}}}
+	default:
+		return redisError("ERR unknown subcommand '" + args[0] + "'")
+	}
+}
+
+func cmdReadOnly(s *Server, c *conn, args []string) interface{} {
+	return statusOK
+}
+
+func cmdReadWrite(s *Server, c *conn, args []string) interface{} {
+	return statusOK
+}
+
+// ----------------------------------------------------------------------------
+// Server
+// ----------------------------------------------------------------------------
+
+// cmdConfig implements CONFIG GET and CONFIG SET of notify-keyspace-events,
+// the only supported parameter.
+func cmdConfig(s *Server, c *conn, args []string) interface{} {
+	if len(args) != 2 && len(args) != 3 {
+		return errWrongNumberOfArgs("config")
+	}
+
+	const parameter = "notify-keyspace-events"
+
+	switch strings.ToLower(args[0]) {
+	case "get":
+		if len(args) != 2 {
+			return errWrongNumberOfArgs("config")
+		}
+
+		if !glob.Match(args[1], parameter) {
+			return []string{}
+		}
+
+		return []string{parameter, s.notifyFlags}
+	case "set":
+		if len(args) != 3 {
+			return errWrongNumberOfArgs("config")
+		}
+
+		if strings.ToLower(args[1]) != parameter {
+			return redisError("ERR Unsupported CONFIG parameter: " + args[1])
+		}
+
+		for _, flag := range args[2] {
+			if !strings.ContainsRune("KEA"+keyspaceClasses, flag) {
+				return redisError("ERR Invalid event class character. Use 'Ag$lshzxeKEtmdn'.")
+			}
+		}
+
+		s.notifyFlags = args[2]
+
+		return statusOK
+	default:
+		return errSyntax
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Keys
+// ----------------------------------------------------------------------------
+
+func cmdDel(s *Server, c *conn, args []string) interface{} {
+	d := s.db(c)
+
+	count := 0
+	for _, key := range args {
+		if d.del(key) {
+			s.notifyKeyspace(c.db, 'g', "del", key)
+			count++
+		}
+	}
+
+	return count
+}
+
+func cmdExists(s *Server, c *conn, args []string) interface{} {
+	d := s.db(c)
+
+	count := 0
+	for _, key := range args {
+		if d.get(key) != nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+func cmdKeys(s *Server, c *conn, args []string) interface{} {
+	return s.db(c).keys(args[0])
+}
+
+func cmdExpire(s *Server, c *conn, args []string) interface{} {
+	return expire(s, c, args, time.Second)
+}
+
+func cmdPExpire(s *Server, c *conn, args []string) interface{} {
+	return expire(s, c, args, time.Millisecond)
+}
+
+func expire(s *Server, c *conn, args []string, unit time.Duration) interface{} {
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errNotInt
+	}
+
+	d := s.db(c)
+
+	i := d.get(args[0])
+	if i == nil {
+		return 0
+	}
+
+	if n <= 0 {
+		d.del(args[0])
+		return 1
+	}
+
+	i.expireAt = time.Now().Add(time.Duration(n) * unit)
+
+	return 1
+}
+
+func cmdTTL(s *Server, c *conn, args []string) interface{} {
+	return ttl(s, c, args, time.Second)
+}
+
+func cmdPTTL(s *Server, c *conn, args []string) interface{} {
+	return ttl(s, c, args, time.Millisecond)
+}
+
+func ttl(s *Server, c *conn, args []string, unit time.Duration) interface{} {
+	i := s.db(c).get(args[0])
+	if i == nil {
+		return -2
+	}
+
+	if i.expireAt.IsZero() {
+		return -1
+	}
+
+	remaining := time.Until(i.expireAt)
+
+	return int64((remaining + unit - 1) / unit)
+}
+
+func cmdType(s *Server, c *conn, args []string) interface{} {
+	switch s.db(c).lookup(args[0]).(type) {
+	case string, *hll.Sketch:
+		return status("string")
+	case map[string]string:
+		return status("hash")
+	case map[string]struct{}:
+		return status("set")
+	case map[string]float64:
+		return status("zset")
+	case []string:
+		return status("list")
+	case *stream:
+		return status("stream")
+	default:
+		return status("none")
+	}
+}
+
+func cmdDBSize(s *Server, c *conn, args []string) interface{} {
+	return len(s.db(c).keys("*"))
+}
+
+func cmdFlushDB(s *Server, c *conn, args []string) interface{} {
+	delete(s.dbs, c.db)
+	return statusOK
+}
+
+func cmdFlushAll(s *Server, c *conn, args []string) interface{} {
+	s.dbs = map[int]*db{}
+	return statusOK
+}
+
+// ----------------------------------------------------------------------------
+// Strings
+// ----------------------------------------------------------------------------
+
+func cmdGet(s *Server, c *conn, args []string) interface{} {
+	switch v := s.db(c).lookup(args[0]).(type) {
+	case nil:
+		return nil
+	case string:
+		return v
+	default:
+		return errWrongType
+	}
+}
+
+func cmdSet(s *Server, c *conn, args []string) interface{} {
+	key, value := args[0], args[1]
+
+	var (
+		expiration time.Duration
+		nx, xx     bool
+		keepTTL    bool
+	)
+
+	for i := 2; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "keepttl":
+			keepTTL = true
+		case "ex", "px":
+			if i+1 >= len(args) {
+				return errSyntax
+			}
+
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				return errNotInt
+			}
+
+			if n <= 0 {
+				return redisError("ERR invalid expire time in 'set' command")
+			}
+
+			unit := time.Second
+			if strings.ToLower(args[i]) == "px" {
+				unit = time.Millisecond
+			}
+
+			expiration = time.Duration(n) * unit
+			i++
+		default:
+			return errSyntax
+		}
+	}
+
+	if nx && xx {
+		return errSyntax
+	}
+
+	d := s.db(c)
+	existing := d.get(key)
+
+	if (nx && existing != nil) || (xx && existing == nil) {
+		return nil
+	}
+
+	switch {
+	case keepTTL && existing != nil:
+		existing.value = value
+	default:
+		d.set(key, value)
+	}
+
+	if expiration > 0 {
+		d.get(key).expireAt = time.Now().Add(expiration)
+	}
+
+	return statusOK
+}
+
+func cmdSetNX(s *Server, c *conn, args []string) interface{} {
+	d := s.db(c)
+
+	if d.get(args[0]) != nil {
+		return 0
+	}
+
+	d.set(args[0], args[1])
+
+	return 1
+}
+
+func cmdMGet(s *Server, c *conn, args []string) interface{} {
+	d := s.db(c)
+
+	values := make([]interface{}, len(args))
+	for i, key := range args {
+		if v, ok := d.lookup(key).(string); ok {
+			values[i] = v
+		}
+	}
+
+	return values
+}
+
+// ----------------------------------------------------------------------------
+// Bitmaps
+// ----------------------------------------------------------------------------
+
+var (
+	errBitOffset = redisError("ERR bit offset is not an integer or out of range")
+	errBitValue  = redisError("ERR bit is not an integer or out of range")
+)
+
+// maxBitOffset is the highest bit offset of a string, which holds at most 512 MB.
+const maxBitOffset = 1<<32 - 1
+
+func cmdGetRange(s *Server, c *conn, args []string) interface{} {
+	start, err := strconv.Atoi(args[1])
+	if err != nil {
+		return errNotInt
+	}
+
+	end, err := strconv.Atoi(args[2])
+	if err != nil {
+		return errNotInt
+	}
+
+	v, ok := s.db(c).str(args[0])
+	if !ok {
+		return errWrongType
+	}
+
+	if start < 0 {
+		start += len(v)
+	}
+
+	if end < 0 {
+		end += len(v)
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if end < 0 {
+		end = 0
+	}
+
+	if end >= len(v) {
+		end = len(v) - 1
+	}
+
+	if start > end {
+		return ""
+	}
+
+	return v[start : end+1]
+}
+
+// parseBitOffset parses the bit offset of SETBIT and GETBIT.
+func parseBitOffset(arg string) (int64, bool) {
+	offset, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || offset < 0 || offset > maxBitOffset {
+		return 0, false
+	}
+
+	return offset, true
+}
+
+// bit returns the bit at offset in v, bits being numbered from the most significant bit of the first byte.
+func bit(v string, offset int64) int {
+	if offset/8 >= int64(len(v)) {
+		return 0
+	}
+
+	return int(v[offset/8]>>(7-offset%8)) & 1
+}
+
+func cmdSetBit(s *Server, c *conn, args []string) interface{} {
+	offset, ok := parseBitOffset(args[1])
+	if !ok {
+		return errBitOffset
+	}
+
+	if args[2] != "0" && args[2] != "1" {
+		return errBitValue
+	}
+
+	d := s.db(c)
+
+	v, ok := d.str(args[0])
+	if !ok {
+		return errWrongType
+	}
+
+	previous := bit(v, offset)
+
+	b := []byte(v)
+	if n := int(offset/8) + 1; n > len(b) {
+		b = append(b, make([]byte, n-len(b))...)
+	}
+
+	mask := byte(1) << (7 - offset%8)
+	if args[2] == "1" {
+		b[offset/8] |= mask
+	} else {
+		b[offset/8] &^= mask
+	}
+
+	d.update(args[0], string(b))
+
+	return previous
+}
+
+func cmdGetBit(s *Server, c *conn, args []string) interface{} {
+	offset, ok := parseBitOffset(args[1])
+	if !ok {
+		return errBitOffset
+	}
+
+	v, ok := s.db(c).str(args[0])
+	if !ok {
+		return errWrongType
+	}
+
+	return bit(v, offset)
+}
+
+// byteRange parses the optional start and end byte offsets of BITCOUNT and BITPOS and converts
+// them into indexes within a string of the given length. It reports false if the range is empty.
+func byteRange(args []string, length int) (int, int, bool, interface{}) {
+	start, end := 0, length-1
+
+	if len(args) > 0 {
+		var err error
+		if start, err = strconv.Atoi(args[0]); err != nil {
+			return 0, 0, false, errNotInt
+		}
+
+		end = -1
+		if len(args) > 1 {
+			if end, err = strconv.Atoi(args[1]); err != nil {
+				return 0, 0, false, errNotInt
+			}
+		}
+	}
+
+	if start < 0 {
+		start += length
+	}
+
+	if end < 0 {
+		end += length
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if end < 0 {
+		end = 0
+	}
+
+	if end >= length {
+		end = length - 1
+	}
+
+	return start, end, start <= end, nil
+}
+
+func cmdBitCount(s *Server, c *conn, args []string) interface{} {
+	if len(args) != 1 && len(args) != 3 {
+		return errSyntax
+	}
+
+	v, ok := s.db(c).str(args[0])
+	if !ok {
+		return errWrongType
+	}
+
+	start, end, ok, errReply := byteRange(args[1:], len(v))
+	if errReply != nil {
+		return errReply
+	}
+
+	if !ok {
+		return 0
+	}
+
+	count := 0
+	for i := start; i <= end; i++ {
+		count += bits.OnesCount8(v[i])
+	}
+
+	return count
+}
+
+func cmdBitOp(s *Server, c *conn, args []string) interface{} {
+	op := strings.ToLower(args[0])
+	if op != "and" && op != "or" && op != "xor" {
+		return errSyntax
+	}
+
+	d := s.db(c)
+	dest := args[1]
+
+	sources := make([]string, len(args)-2)
+	size := 0
+
+	for i, key := range args[2:] {
+		v, ok := d.str(key)
+		if !ok {
+			return errWrongType
+		}
+
+		sources[i] = v
+		size = max(size, len(v))
+	}
+
+	if size == 0 {
+		if d.del(dest) {
+			s.notifyKeyspace(c.db, 'g', "del", dest)
+		}
+		return 0
+	}
+
+	result := make([]byte, size)
+	for i := range result {
+		for j, source := range sources {
+			var v byte
+			if i < len(source) {
+				v = source[i]
+			}
+
+			switch {
+			case j == 0:
+				result[i] = v
+			case op == "and":
+				result[i] &= v
+			case op == "or":
+				result[i] |= v
+			default:
+				result[i] ^= v
+			}
+		}
+	}
+
+	d.set(dest, string(result))
+	s.notifyKeyspace(c.db, '$', "set", dest)
+
+	return size
+}
+
+func cmdBitPos(s *Server, c *conn, args []string) interface{} {
+	if len(args) > 4 {
+		return errSyntax
+	}
+
+	if args[1] != "0" && args[1] != "1" {
+		return redisError("ERR The bit argument must be 1 or 0.")
+	}
+
+	value := args[1] == "1"
+
+	d := s.db(c)
+
+	if d.get(args[0]) == nil {
+		if value {
+			return -1
+		}
+		return 0
+	}
+
+	v, ok := d.str(args[0])
+	if !ok {
+		return errWrongType
+	}
+
+	start, end, ok, errReply := byteRange(args[2:], len(v))
+	if errReply != nil {
+		return errReply
+	}
+
+	if !ok {
+		return -1
+	}
+
+	for i := start; i <= end; i++ {
+		b := v[i]
+		if !value {
+			b = ^b
+		}
+
+		if b != 0 {
+			return i*8 + bits.LeadingZeros8(b)
+		}
+	}
+
+	// Without an end, the string is considered padded with zeros.
+	if !value && len(args) < 4 {
+		return (end + 1) * 8
+	}
+
+	return -1
+}
+
+// ----------------------------------------------------------------------------
+// HyperLogLogs
+// ----------------------------------------------------------------------------
+
+func cmdPFAdd(s *Server, c *conn, args []string) interface{} {
+	d := s.db(c)
+	created := d.get(args[0]) == nil
+
+	sketch, ok := d.hyperLogLog(args[0], true)
+	if !ok {
+		return errWrongType
+	}
+
+	changed := created
+	for _, element := range args[1:] {
+		if sketch.Add(element) {
+			changed = true
+		}
+	}
+
+	if changed {
+		return 1
+	}
+
+	return 0
+}
+
+func cmdPFCount(s *Server, c *conn, args []string) interface{} {
+	d := s.db(c)
+	union := hll.New()
+
+	for _, key := range args {
+		sketch, ok := d.hyperLogLog(key, false)
+		if !ok {
+			return errWrongType
+		}
+
+		if sketch != nil {
+			union.Merge(sketch)
+		}
+	}
+
+	return union.Count()
+}
+
+func cmdPFMerge(s *Server, c *conn, args []string) interface{} {
+	d := s.db(c)
+
+	sources := make([]*hll.Sketch, 0, len(args))
+	for _, key := range args {
+		sketch, ok := d.hyperLogLog(key, false)
+		if !ok {
+			return errWrongType
+		}
+
+		if sketch != nil {
+			sources = append(sources, sketch)
+		}
+	}
+
+	// Types are checked before creating dest.
+	dest, ok := d.hyperLogLog(args[0], true)
+	if !ok {
+		return errWrongType
+	}
+
+	for _, sketch := range sources {
+		dest.Merge(sketch)
+	}
+
+	return statusOK
+}
+
+// ----------------------------------------------------------------------------
+// Hashes
+// ----------------------------------------------------------------------------
+
+func cmdHMGet(s *Server, c *conn, args []string) interface{} {
+	h, ok := s.db(c).hash(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	reply := make([]interface{}, len(args)-1)
+	for i, field := range args[1:] {
+		if v, found := h[field]; found {
+			reply[i] = v
+		}
+	}
+
+	return reply
+}
+
+func cmdHGet(s *Server, c *conn, args []string) interface{} {
+	h, ok := s.db(c).hash(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	if v, found := h[args[1]]; found {
+		return v
+	}
+
+	return nil
+}
+
+func cmdHGetAll(s *Server, c *conn, args []string) interface{} {
+	h, ok := s.db(c).hash(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	fields := make([]string, 0, len(h))
+	for field := range h {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	values := make([]string, 0, 2*len(h))
+	for _, field := range fields {
+		values = append(values, field, h[field])
+	}
+
+	return values
+}
+
+func cmdHSet(s *Server, c *conn, args []string) interface{} {
+	if len(args)%2 != 1 {
+		return errWrongNumberOfArgs("hset")
+	}
+
+	h, ok := s.db(c).hash(args[0], true)
+	if !ok {
+		return errWrongType
+	}
+
+	count := 0
+	for i := 1; i < len(args); i += 2 {
+		if _, found := h[args[i]]; !found {
+			count++
+		}
+		h[args[i]] = args[i+1]
+	}
+
+	return count
+}
+
+func cmdHMSet(s *Server, c *conn, args []string) interface{} {
+	if len(args)%2 != 1 {
+		return errWrongNumberOfArgs("hmset")
+	}
+
+	if reply := cmdHSet(s, c, args); reply == errWrongType {
+		return reply
+	}
+
+	return statusOK
+}
+
+func cmdHDel(s *Server, c *conn, args []string) interface{} {
+	d := s.db(c)
+
+	h, ok := d.hash(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	count := 0
+	for _, field := range args[1:] {
+		if _, found := h[field]; found {
+			delete(h, field)
+			count++
+		}
+	}
+
+	if h != nil && len(h) == 0 {
+		d.del(args[0])
+	}
+
+	return count
+}
+
+// ----------------------------------------------------------------------------
+// Sets
+// ----------------------------------------------------------------------------
+
+func cmdSAdd(s *Server, c *conn, args []string) interface{} {
+	set, ok := s.db(c).setValue(args[0], true)
+	if !ok {
+		return errWrongType
+	}
+
+	count := 0
+	for _, member := range args[1:] {
+		if _, found := set[member]; !found {
+			set[member] = struct{}{}
+			count++
+		}
+	}
+
+	return count
+}
+
+func cmdSRem(s *Server, c *conn, args []string) interface{} {
+	d := s.db(c)
+
+	set, ok := d.setValue(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	count := 0
+	for _, member := range args[1:] {
+		if _, found := set[member]; found {
+			delete(set, member)
+			count++
+		}
+	}
+
+	if set != nil && len(set) == 0 {
+		d.del(args[0])
+	}
+
+	return count
+}
+
+func cmdSMembers(s *Server, c *conn, args []string) interface{} {
+	set, ok := s.db(c).setValue(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	members := make([]string, 0, len(set))
+	for member := range set {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+
+	return members
+}
+
+func cmdSCard(s *Server, c *conn, args []string) interface{} {
+	set, ok := s.db(c).setValue(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	return len(set)
+}
+
+// ----------------------------------------------------------------------------
+// Sorted sets
+// ----------------------------------------------------------------------------
+
+func cmdZAdd(s *Server, c *conn, args []string) interface{} {
+	if len(args)%2 != 1 {
+		return errSyntax
+	}
+
+	scores := make([]float64, 0, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		score, err := parseFloat(args[i])
+		if err != nil {
+			return errNotFloat
+		}
+		scores = append(scores, score)
+	}
+
+	z, ok := s.db(c).zset(args[0], true)
+	if !ok {
+		return errWrongType
+	}
+
+	count := 0
+	for i := 1; i < len(args); i += 2 {
+		if _, found := z[args[i+1]]; !found {
+			count++
+		}
+		z[args[i+1]] = scores[i/2]
+	}
+
+	return count
+}
+
+func cmdZIncrBy(s *Server, c *conn, args []string) interface{} {
+	increment, err := parseFloat(args[1])
+	if err != nil {
+		return errNotFloat
+	}
+
+	z, ok := s.db(c).zset(args[0], true)
+	if !ok {
+		return errWrongType
+	}
+
+	z[args[2]] += increment
+
+	return formatFloat(z[args[2]])
+}
+
+func cmdZRangeByScore(s *Server, c *conn, args []string) interface{} {
+	min, minExclusive, err := parseScoreBound(args[1])
+	if err != nil {
+		return redisError("ERR min or max is not a float")
+	}
+
+	max, maxExclusive, err := parseScoreBound(args[2])
+	if err != nil {
+		return redisError("ERR min or max is not a float")
+	}
+
+	var (
+		withScores    bool
+		offset, count = 0, -1
+	)
+
+	for i := 3; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "withscores":
+			withScores = true
+		case "limit":
+			if i+2 >= len(args) {
+				return errSyntax
+			}
+
+			var err1, err2 error
+			offset, err1 = strconv.Atoi(args[i+1])
+			count, err2 = strconv.Atoi(args[i+2])
+			if err1 != nil || err2 != nil {
+				return errNotInt
+			}
+			i += 2
+		default:
+			return errSyntax
+		}
+	}
+
+	z, ok := s.db(c).zset(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	var members []zmember
+	for _, m := range sortedMembers(z) {
+		if m.score < min || (minExclusive && m.score == min) {
+			continue
+		}
+		if m.score > max || (maxExclusive && m.score == max) {
+			break
+		}
+		members = append(members, m)
+	}
+
+	if offset < 0 || offset >= len(members) {
+		members = nil
+	} else {
+		members = members[offset:]
+	}
+
+	if count >= 0 && count < len(members) {
+		members = members[:count]
+	}
+
+	return zreply(members, withScores)
+}
+
+func cmdZRange(s *Server, c *conn, args []string) interface{} {
+	return zrange(s, c, args, false)
+}
+
+func cmdZRevRange(s *Server, c *conn, args []string) interface{} {
+	return zrange(s, c, args, true)
+}
+
+// zrange implements ZRANGE and ZREVRANGE by index.
+func zrange(s *Server, c *conn, args []string, reverse bool) interface{} {
+	start, err1 := strconv.Atoi(args[1])
+	stop, err2 := strconv.Atoi(args[2])
+	if err1 != nil || err2 != nil {
+		return errNotInt
+	}
+
+	withScores := false
+	if len(args) == 4 && strings.ToLower(args[3]) == "withscores" {
+		withScores = true
+	} else if len(args) > 3 {
+		return errSyntax
+	}
+
+	z, ok := s.db(c).zset(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	members := sortedMembers(z)
+	if reverse {
+		for i, j := 0, len(members)-1; i < j; i, j = i+1, j-1 {
+			members[i], members[j] = members[j], members[i]
+		}
+	}
+
+	length := len(members)
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop || start >= length {
+		return []string{}
+	}
+
+	return zreply(members[start:stop+1], withScores)
+}
+
+func cmdZRank(s *Server, c *conn, args []string) interface{} {
+	z, ok := s.db(c).zset(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	for i, m := range sortedMembers(z) {
+		if m.member == args[1] {
+			return i
+		}
+	}
+
+	return nil
+}
+
+func cmdZRemRangeByScore(s *Server, c *conn, args []string) interface{} {
+	min, minExclusive, err := parseScoreBound(args[1])
+	if err != nil {
+		return redisError("ERR min or max is not a float")
+	}
+
+	max, maxExclusive, err := parseScoreBound(args[2])
+	if err != nil {
+		return redisError("ERR min or max is not a float")
+	}
+
+	d := s.db(c)
+
+	z, ok := d.zset(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	count := 0
+	for member, score := range z {
+		if score < min || (minExclusive && score == min) || score > max || (maxExclusive && score == max) {
+			continue
+		}
+
+		delete(z, member)
+		count++
+	}
+
+	if z != nil && len(z) == 0 {
+		d.del(args[0])
+	}
+
+	return count
+}
+
+func cmdZRem(s *Server, c *conn, args []string) interface{} {
+	d := s.db(c)
+
+	z, ok := d.zset(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	count := 0
+	for _, member := range args[1:] {
+		if _, found := z[member]; found {
+			delete(z, member)
+			count++
+		}
+	}
+
+	if z != nil && len(z) == 0 {
+		d.del(args[0])
+	}
+
+	return count
+}
+
+func cmdZCard(s *Server, c *conn, args []string) interface{} {
+	z, ok := s.db(c).zset(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	return len(z)
+}
+
+func cmdZScore(s *Server, c *conn, args []string) interface{} {
+	z, ok := s.db(c).zset(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	if score, found := z[args[1]]; found {
+		return formatFloat(score)
+	}
+
+	return nil
+}
+
+// zmember is a sorted set member with its score.
+type zmember struct {
+	member string
+	score  float64
+}
+
+// sortedMembers returns the members of the given sorted set, by ascending score.
+func sortedMembers(z map[string]float64) []zmember {
+	members := make([]zmember, 0, len(z))
+	for member, score := range z {
+		members = append(members, zmember{member, score})
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].score != members[j].score {
+			return members[i].score < members[j].score
+		}
+		return members[i].member < members[j].member
+	})
+
+	return members
+}
+
+func zreply(members []zmember, withScores bool) []string {
+	values := make([]string, 0, 2*len(members))
+	for _, m := range members {
+		values = append(values, m.member)
+		if withScores {
+			values = append(values, formatFloat(m.score))
+		}
+	}
+	return values
+}
+
+// parseScoreBound parses a ZRANGEBYSCORE bound, e.g. "1.5", "(1.5" or "-inf".
+func parseScoreBound(s string) (float64, bool, error) {
+	exclusive := strings.HasPrefix(s, "(")
+	if exclusive {
+		s = s[1:]
+	}
+
+	f, err := parseFloat(s)
+
+	return f, exclusive, err
+}
+
+func parseFloat(s string) (float64, error) {
+	switch strings.ToLower(s) {
+	case "inf", "+inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if math.IsNaN(f) {
+		return 0, strconv.ErrSyntax
+	}
+
+	return f, nil
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(f, 'g', 17, 64)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Geospatial indexes
+// ----------------------------------------------------------------------------
+
+var errGeoUnit = redisError("ERR unsupported unit provided. please use M, KM, FT, MI")
+
+// geoUnits are the number of meters of the distance units.
+var geoUnits = map[string]float64{"m": 1, "km": 1000, "ft": 0.3048, "mi": 1609.34}
+
+func errGeoPosition(lon float64, lat float64) redisError {
+	return redisError(fmt.Sprintf("ERR invalid longitude,latitude pair %f,%f", lon, lat))
+}
+
+// parseGeoPosition parses a longitude and a latitude.
+func parseGeoPosition(args []string) (float64, float64, interface{}) {
+	lon, err := parseFloat(args[0])
+	if err != nil {
+		return 0, 0, errNotFloat
+	}
+
+	lat, err := parseFloat(args[1])
+	if err != nil {
+		return 0, 0, errNotFloat
+	}
+
+	if !geohash.Valid(lon, lat) {
+		return 0, 0, errGeoPosition(lon, lat)
+	}
+
+	return lon, lat, nil
+}
+
+// formatCoordinate formats a coordinate as Redis does, with 17 decimals without trailing zeros.
+func formatCoordinate(f float64) string {
+	s := strings.TrimRight(strconv.FormatFloat(f, 'f', 17, 64), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// formatDistance formats a distance as Redis does, with 4 decimals.
+func formatDistance(f float64) string {
+	return strconv.FormatFloat(f, 'f', 4, 64)
+}
+
+func cmdGeoAdd(s *Server, c *conn, args []string) interface{} {
+	if len(args)%3 != 1 {
+		return errSyntax
+	}
+
+	scores := make([]float64, 0, len(args)/3)
+	for i := 1; i < len(args); i += 3 {
+		lon, lat, errReply := parseGeoPosition(args[i:])
+		if errReply != nil {
+			return errReply
+		}
+		scores = append(scores, float64(geohash.Encode(lon, lat)))
+	}
+
+	z, ok := s.db(c).zset(args[0], true)
+	if !ok {
+		return errWrongType
+	}
+
+	count := 0
+	for i := 1; i < len(args); i += 3 {
+		if _, found := z[args[i+2]]; !found {
+			count++
+		}
+		z[args[i+2]] = scores[i/3]
+	}
+
+	return count
+}
+
+func cmdGeoPos(s *Server, c *conn, args []string) interface{} {
+	z, ok := s.db(c).zset(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	positions := make([]interface{}, len(args)-1)
+	for i, member := range args[1:] {
+		score, found := z[member]
+		if !found {
+			positions[i] = nilArray{}
+			continue
+		}
+
+		lon, lat := geohash.Decode(uint64(score))
+		positions[i] = []string{formatCoordinate(lon), formatCoordinate(lat)}
+	}
+
+	return positions
+}
+
+func cmdGeoDist(s *Server, c *conn, args []string) interface{} {
+	if len(args) > 4 {
+		return errSyntax
+	}
+
+	unit := 1.0
+	if len(args) == 4 {
+		var found bool
+		if unit, found = geoUnits[strings.ToLower(args[3])]; !found {
+			return errGeoUnit
+		}
+	}
+
+	z, ok := s.db(c).zset(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	score1, found1 := z[args[1]]
+	score2, found2 := z[args[2]]
+	if !found1 || !found2 {
+		return nil
+	}
+
+	lon1, lat1 := geohash.Decode(uint64(score1))
+	lon2, lat2 := geohash.Decode(uint64(score2))
+
+	return formatDistance(geohash.Distance(lon1, lat1, lon2, lat2) / unit)
+}
+
+// geoSearchOptions are the options of GEOSEARCH.
+type geoSearchOptions struct {
+	member                string
+	lon, lat              float64
+	radius, width, height float64
+	unit                  float64
+	byRadius, byBox       bool
+	sort                  string
+	count                 int
+	withCoord, withDist   bool
+}
+
+func parseGeoSearchOptions(args []string) (geoSearchOptions, interface{}) {
+	var o geoSearchOptions
+
+	fromMember, fromLonLat := false, false
+
+	for i := 0; i < len(args); i++ {
+		remaining := len(args) - i - 1
+
+		switch strings.ToLower(args[i]) {
+		case "frommember":
+			if remaining < 1 {
+				return o, errSyntax
+			}
+			o.member = args[i+1]
+			fromMember = true
+			i++
+		case "fromlonlat":
+			if remaining < 2 {
+				return o, errSyntax
+			}
+			lon, lat, errReply := parseGeoPosition(args[i+1:])
+			if errReply != nil {
+				return o, errReply
+			}
+			o.lon, o.lat = lon, lat
+			fromLonLat = true
+			i += 2
+		case "byradius":
+			if remaining < 2 {
+				return o, errSyntax
+			}
+			radius, err := parseFloat(args[i+1])
+			if err != nil {
+				return o, errNotFloat
+			}
+			if radius < 0 {
+				return o, redisError("ERR radius cannot be negative")
+			}
+			unit, found := geoUnits[strings.ToLower(args[i+2])]
+			if !found {
+				return o, errGeoUnit
+			}
+			o.radius, o.unit = radius, unit
+			o.byRadius = true
+			i += 2
+		case "bybox":
+			if remaining < 3 {
+				return o, errSyntax
+			}
+			width, err := parseFloat(args[i+1])
+			if err != nil {
+				return o, errNotFloat
+			}
+			height, err := parseFloat(args[i+2])
+			if err != nil {
+				return o, errNotFloat
+			}
+			if width < 0 || height < 0 {
+				return o, redisError("ERR height or width cannot be negative")
+			}
+			unit, found := geoUnits[strings.ToLower(args[i+3])]
+			if !found {
+				return o, errGeoUnit
+			}
+			o.width, o.height, o.unit = width, height, unit
+			o.byBox = true
+			i += 3
+		case "asc", "desc":
+			o.sort = strings.ToLower(args[i])
+		case "count":
+			if remaining < 1 {
+				return o, errSyntax
+			}
+			count, err := strconv.Atoi(args[i+1])
+			if err != nil || count <= 0 {
+				return o, redisError("ERR COUNT must be > 0")
+			}
+			o.count = count
+			i++
+		case "withcoord":
+			o.withCoord = true
+		case "withdist":
+			o.withDist = true
+		default:
+			return o, errSyntax
+		}
+	}
+
+	if fromMember == fromLonLat {
+		return o, redisError("ERR exactly one of FROMMEMBER or FROMLONLAT can be specified for GEOSEARCH")
+	}
+
+	if o.byRadius == o.byBox {
+		return o, redisError("ERR exactly one of BYRADIUS and BYBOX can be specified for GEOSEARCH")
+	}
+
+	// A count without an order returns the closest members.
+	if o.count > 0 && o.sort == "" {
+		o.sort = "asc"
+	}
+
+	return o, nil
+}
+
+// geoPoint is a member found by GEOSEARCH.
+type geoPoint struct {
+	member   string
+	lon, lat float64
+	dist     float64
+}
+
+func cmdGeoSearch(s *Server, c *conn, args []string) interface{} {
+	o, errReply := parseGeoSearchOptions(args[1:])
+	if errReply != nil {
+		return errReply
+	}
+
+	z, ok := s.db(c).zset(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	if z == nil {
+		return []interface{}{}
+	}
+
+	lon, lat := o.lon, o.lat
+	if o.member != "" {
+		score, found := z[o.member]
+		if !found {
+			return redisError("ERR could not decode requested zset member")
+		}
+		lon, lat = geohash.Decode(uint64(score))
+	}
+
+	// All members are scanned instead of the geohash areas around the center.
+	var points []geoPoint
+	for member, score := range z {
+		mlon, mlat := geohash.Decode(uint64(score))
+
+		var (
+			dist float64
+			in   bool
+		)
+
+		if o.byRadius {
+			dist, in = geohash.InRadius(lon, lat, o.radius*o.unit, mlon, mlat)
+		} else {
+			dist, in = geohash.InBox(lon, lat, o.width*o.unit, o.height*o.unit, mlon, mlat)
+		}
+
+		if in {
+			points = append(points, geoPoint{member, mlon, mlat, dist / o.unit})
+		}
+	}
+
+	switch o.sort {
+	case "asc":
+		sort.Slice(points, func(i, j int) bool { return points[i].dist < points[j].dist })
+	case "desc":
+		sort.Slice(points, func(i, j int) bool { return points[i].dist > points[j].dist })
+	}
+
+	if o.count > 0 && len(points) > o.count {
+		points = points[:o.count]
+	}
+
+	reply := make([]interface{}, len(points))
+	for i, p := range points {
+		if !o.withDist && !o.withCoord {
+			reply[i] = p.member
+			continue
+		}
+
+		item := []interface{}{p.member}
+		if o.withDist {
+			item = append(item, formatDistance(p.dist))
+		}
+		if o.withCoord {
+			item = append(item, []string{formatCoordinate(p.lon), formatCoordinate(p.lat)})
+		}
+
+		reply[i] = item
+	}
+
+	return reply
+}
+
+// ----------------------------------------------------------------------------
+// Lists
+// ----------------------------------------------------------------------------
+
+func cmdLPush(s *Server, c *conn, args []string) interface{} {
+	d := s.db(c)
+
+	l, ok := d.list(args[0])
+	if !ok {
+		return errWrongType
+	}
+
+	values := make([]string, 0, len(l)+len(args)-1)
+	for i := len(args) - 1; i > 0; i-- {
+		values = append(values, args[i])
+	}
+	values = append(values, l...)
+
+	d.update(args[0], values)
+
+	return len(values)
+}
+
+func cmdRPush(s *Server, c *conn, args []string) interface{} {
+	d := s.db(c)
+
+	l, ok := d.list(args[0])
+	if !ok {
+		return errWrongType
+	}
+
+	values := append(l, args[1:]...)
+	d.update(args[0], values)
+
+	return len(values)
+}
+
+func cmdLPop(s *Server, c *conn, args []string) interface{} {
+	return pop(s.db(c), args[0], true)
+}
+
+func cmdRPop(s *Server, c *conn, args []string) interface{} {
+	return pop(s.db(c), args[0], false)
+}
+
+func cmdBLPop(s *Server, c *conn, args []string) interface{} {
+	keys := args[:len(args)-1]
+
+	timeout, err := parseFloat(args[len(args)-1])
+	if err != nil || timeout < 0 {
+		return redisError("ERR timeout is not a float or out of range")
+	}
+
+	d := s.db(c)
+	for _, key := range keys {
+		switch value := pop(d, key, true).(type) {
+		case string:
+			return []string{key, value}
+		case redisError:
+			return value
+		}
+	}
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(time.Duration(timeout * float64(time.Second)))
+	}
+
+	return blocked{deadline: deadline}
+}
+
+func cmdLLen(s *Server, c *conn, args []string) interface{} {
+	l, ok := s.db(c).list(args[0])
+	if !ok {
+		return errWrongType
+	}
+
+	return len(l)
+}
+
+func cmdLRange(s *Server, c *conn, args []string) interface{} {
+	start, err1 := strconv.Atoi(args[1])
+	stop, err2 := strconv.Atoi(args[2])
+	if err1 != nil || err2 != nil {
+		return errNotInt
+	}
+
+	l, ok := s.db(c).list(args[0])
+	if !ok {
+		return errWrongType
+	}
+
+	length := len(l)
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop || start >= length {
+		return []string{}
+	}
+
+	return append([]string{}, l[start:stop+1]...)
+}
+
+// pop removes and returns the first or last value of the list stored at key.
+func pop(d *db, key string, first bool) interface{} {
+	l, ok := d.list(key)
+	if !ok {
+		return errWrongType
+	}
+
+	if len(l) == 0 {
+		return nil
+	}
+
+	var value string
+	if first {
+		value, l = l[0], l[1:]
+	} else {
+		value, l = l[len(l)-1], l[:len(l)-1]
+	}
+
+	if len(l) == 0 {
+		d.del(key)
+	} else {
+		d.update(key, l)
+	}
+
+	return value
+}
+
+// ----------------------------------------------------------------------------
+// Streams
+// ----------------------------------------------------------------------------
+
+var (
+	errInvalidStreamID  = redisError("ERR Invalid stream ID specified as stream command argument")
+	errStreamIDTooSmall = redisError("ERR The ID specified in XADD is equal or smaller than the target stream top item")
+)
+
+func errNoGroup(key string, group string, command string) redisError {
+	return redisError("NOGROUP No such key '" + key + "' or consumer group '" + group + "' in " + command)
+}
+
+func cmdXAdd(s *Server, c *conn, args []string) interface{} {
+	key, id, fields := args[0], args[1], args[2:]
+	if len(fields)%2 != 0 {
+		return errWrongNumberOfArgs("xadd")
+	}
+
+	st, ok := s.db(c).stream(key, true)
+	if !ok {
+		return errWrongType
+	}
+
+	var entryID streamID
+	if id == "*" {
+		entryID = streamID{ms: uint64(time.Now().UnixMilli())}
+		if !st.lastID.less(entryID) {
+			entryID = streamID{ms: st.lastID.ms, seq: st.lastID.seq + 1}
+		}
+	} else {
+		if entryID, ok = parseStreamID(id); !ok {
+			return errInvalidStreamID
+		}
+		if !st.lastID.less(entryID) {
+			return errStreamIDTooSmall
+		}
+	}
+
+	st.entries = append(st.entries, streamEntry{id: entryID, fields: append([]string{}, fields...)})
+	st.lastID = entryID
+
+	return entryID.String()
+}
+
+func cmdXLen(s *Server, c *conn, args []string) interface{} {
+	st, ok := s.db(c).stream(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	if st == nil {
+		return 0
+	}
+
+	return len(st.entries)
+}
+
+// streamsOptions are the options of XREAD and XREADGROUP.
+type streamsOptions struct {
+	count   int
+	block   bool
+	timeout time.Duration
+	keys    []string
+	ids     []string
+}
+
+// parseStreamsOptions parses "[COUNT count] [BLOCK milliseconds] [NOACK] STREAMS key [key ...] id [id ...]".
+func parseStreamsOptions(args []string) (streamsOptions, interface{}) {
+	var opts streamsOptions
+
+	for i := 0; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "count", "block":
+			if i+1 >= len(args) {
+				return opts, errSyntax
+			}
+
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 0 {
+				return opts, errNotInt
+			}
+
+			if strings.ToLower(args[i]) == "count" {
+				opts.count = n
+			} else {
+				opts.block = true
+				opts.timeout = time.Duration(n) * time.Millisecond
+			}
+			i++
+		case "noack":
+		case "streams":
+			streams := args[i+1:]
+			if len(streams) == 0 || len(streams)%2 != 0 {
+				return opts, redisError("ERR Unbalanced 'xread' list of streams: for each stream key an ID or '$' must be specified.")
+			}
+
+			opts.keys = streams[:len(streams)/2]
+			opts.ids = streams[len(streams)/2:]
+
+			return opts, nil
+		default:
+			return opts, errSyntax
+		}
+	}
+
+	return opts, errSyntax
+}
+
+// blocked returns the reply of a blocking XREAD or XREADGROUP which found no entries.
+func (o streamsOptions) blocked() interface{} {
+	if !o.block {
+		return nilArray{}
+	}
+
+	var deadline time.Time
+	if o.timeout > 0 {
+		deadline = time.Now().Add(o.timeout)
+	}
+
+	return blocked{deadline: deadline}
+}
+
+func streamEntryReply(entry *streamEntry) []interface{} {
+	return []interface{}{entry.id.String(), entry.fields}
+}
+
+func cmdXRead(s *Server, c *conn, args []string) interface{} {
+	opts, errReply := parseStreamsOptions(args)
+	if errReply != nil {
+		return errReply
+	}
+
+	d := s.db(c)
+
+	var reply []interface{}
+	for i, key := range opts.keys {
+		st, ok := d.stream(key, false)
+		if !ok {
+			return errWrongType
+		}
+
+		if st == nil {
+			continue
+		}
+
+		after := st.lastID
+		if opts.ids[i] != "$" {
+			if after, ok = parseStreamID(opts.ids[i]); !ok {
+				return errInvalidStreamID
+			}
+		}
+
+		var entries []interface{}
+		for j := st.after(after); j < len(st.entries); j++ {
+			if opts.count > 0 && len(entries) == opts.count {
+				break
+			}
+			entries = append(entries, streamEntryReply(&st.entries[j]))
+		}
+
+		if len(entries) > 0 {
+			reply = append(reply, []interface{}{key, entries})
+		}
+	}
+
+	if len(reply) == 0 {
+		return opts.blocked()
+	}
+
+	return reply
+}
+
+func cmdXGroup(s *Server, c *conn, args []string) interface{} {
+	if strings.ToLower(args[0]) != "create" {
+		return redisError("ERR unknown subcommand '" + args[0] + "'")
+	}
+
+	if len(args) < 4 || len(args) > 5 {
+		return errWrongNumberOfArgs("xgroup|create")
+	}
+
+	key, name, id := args[1], args[2], args[3]
+
+	mkStream := false
+	if len(args) == 5 {
+		if strings.ToLower(args[4]) != "mkstream" {
+			return errSyntax
+		}
+		mkStream = true
+	}
+
+	st, ok := s.db(c).stream(key, mkStream)
+	if !ok {
+		return errWrongType
+	}
+
+	if st == nil {
+		return redisError("ERR The XGROUP subcommand requires the key to exist. Note that for CREATE you may want to use the MKSTREAM option to create an empty stream automatically.")
+	}
+
+	if _, found := st.groups[name]; found {
+		return redisError("BUSYGROUP Consumer Group name already exists")
+	}
+
+	lastID := st.lastID
+	if id != "$" {
+		if lastID, ok = parseStreamID(id); !ok {
+			return errInvalidStreamID
+		}
+	}
+
+	st.groups[name] = &group{lastID: lastID, pending: map[streamID]*pendingEntry{}}
+
+	return statusOK
+}
+
+func cmdXReadGroup(s *Server, c *conn, args []string) interface{} {
+	if strings.ToLower(args[0]) != "group" {
+		return errSyntax
+	}
+
+	name, consumer := args[1], args[2]
+
+	opts, errReply := parseStreamsOptions(args[3:])
+	if errReply != nil {
+		return errReply
+	}
+
+	d := s.db(c)
+	now := time.Now()
+
+	var reply []interface{}
+	for i, key := range opts.keys {
+		if opts.ids[i] != ">" {
+			return redisError("ERR only '>' IDs are supported")
+		}
+
+		st, ok := d.stream(key, false)
+		if !ok {
+			return errWrongType
+		}
+
+		var g *group
+		if st != nil {
+			g = st.groups[name]
+		}
+
+		if g == nil {
+			return errNoGroup(key, name, "XREADGROUP with GROUP option")
+		}
+
+		var entries []interface{}
+		for j := st.after(g.lastID); j < len(st.entries); j++ {
+			if opts.count > 0 && len(entries) == opts.count {
+				break
+			}
+
+			entry := &st.entries[j]
+			g.lastID = entry.id
+			g.pending[entry.id] = &pendingEntry{consumer: consumer, delivered: now, deliveries: 1}
+
+			entries = append(entries, streamEntryReply(entry))
+		}
+
+		if len(entries) > 0 {
+			reply = append(reply, []interface{}{key, entries})
+		}
+	}
+
+	if len(reply) == 0 {
+		return opts.blocked()
+	}
+
+	return reply
+}
+
+// streamGroup returns the given consumer group of the stream stored at key.
+func streamGroup(d *db, key string, name string, command string) (*stream, *group, interface{}) {
+	st, ok := d.stream(key, false)
+	if !ok {
+		return nil, nil, errWrongType
+	}
+
+	if st == nil || st.groups[name] == nil {
+		return nil, nil, errNoGroup(key, name, command)
+	}
+
+	return st, st.groups[name], nil
+}
+
+func cmdXAck(s *Server, c *conn, args []string) interface{} {
+	_, g, errReply := streamGroup(s.db(c), args[0], args[1], "XACK")
+	if errReply == errWrongType {
+		return errReply
+	}
+
+	if g == nil {
+		return 0
+	}
+
+	count := 0
+	for _, arg := range args[2:] {
+		id, ok := parseStreamID(arg)
+		if !ok {
+			return errInvalidStreamID
+		}
+
+		if _, found := g.pending[id]; found {
+			delete(g.pending, id)
+			count++
+		}
+	}
+
+	return count
+}
+
+// cmdXPending implements the extended form of XPENDING, "key group start end count [consumer]",
+// with "-" and "+" as start and end.
+func cmdXPending(s *Server, c *conn, args []string) interface{} {
+	if len(args) < 5 || len(args) > 6 {
+		return errSyntax
+	}
+
+	if args[2] != "-" || args[3] != "+" {
+		return redisError("ERR only '-' and '+' ranges are supported")
+	}
+
+	count, err := strconv.ParseInt(args[4], 10, 64)
+	if err != nil {
+		return errNotInt
+	}
+
+	_, g, errReply := streamGroup(s.db(c), args[0], args[1], "XPENDING")
+	if errReply != nil {
+		return errReply
+	}
+
+	now := time.Now()
+
+	reply := []interface{}{}
+	for _, id := range g.pendingIDs() {
+		if int64(len(reply)) >= count {
+			break
+		}
+
+		p := g.pending[id]
+		if len(args) == 6 && p.consumer != args[5] {
+			continue
+		}
+
+		reply = append(reply, []interface{}{id.String(), p.consumer, now.Sub(p.delivered).Milliseconds(), p.deliveries})
+	}
+
+	return reply
+}
+
+// cmdXAutoClaim replies as Redis 7, with the IDs of deleted entries as third element.
+func cmdXAutoClaim(s *Server, c *conn, args []string) interface{} {
+	key, name, consumer := args[0], args[1], args[2]
+
+	minIdle, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return errNotInt
+	}
+
+	start, ok := parseStreamID(args[4])
+	if !ok {
+		return errInvalidStreamID
+	}
+
+	count := 100
+	if len(args) > 5 {
+		if len(args) != 7 || strings.ToLower(args[5]) != "count" {
+			return errSyntax
+		}
+
+		if count, err = strconv.Atoi(args[6]); err != nil || count <= 0 {
+			return errNotInt
+		}
+	}
+
+	st, g, errReply := streamGroup(s.db(c), key, name, "XAUTOCLAIM")
+	if errReply != nil {
+		return errReply
+	}
+
+	now := time.Now()
+
+	var (
+		claimed = []interface{}{}
+		deleted = []string{}
+		next    = streamID{}
+		scanned = 0
+	)
+
+	for _, id := range g.pendingIDs() {
+		if id.less(start) {
+			continue
+		}
+
+		if scanned == count {
+			next = id
+			break
+		}
+		scanned++
+
+		p := g.pending[id]
+		if now.Sub(p.delivered).Milliseconds() < minIdle {
+			continue
+		}
+
+		entry := st.entry(id)
+		if entry == nil {
+			delete(g.pending, id)
+			deleted = append(deleted, id.String())
+			continue
+		}
+
+		p.consumer = consumer
+		p.delivered = now
+		p.deliveries++
+
+		claimed = append(claimed, streamEntryReply(entry))
+	}
+
+	return []interface{}{next.String(), claimed, deleted}
+}
+
+func cmdXTrim(s *Server, c *conn, args []string) interface{} {
+	if strings.ToLower(args[1]) != "maxlen" {
+		return errSyntax
+	}
+
+	threshold := args[2]
+	if (threshold == "=" || threshold == "~") && len(args) == 4 {
+		threshold = args[3]
+	} else if len(args) != 3 {
+		return errSyntax
+	}
+
+	maxLen, err := strconv.Atoi(threshold)
+	if err != nil || maxLen < 0 {
+		return errNotInt
+	}
+
+	st, ok := s.db(c).stream(args[0], false)
+	if !ok {
+		return errWrongType
+	}
+
+	if st == nil || len(st.entries) <= maxLen {
+		return 0
+	}
+
+	count := len(st.entries) - maxLen
+	st.entries = append([]streamEntry{}, st.entries[count:]...)
+
+	return count
+}
+
+// ----------------------------------------------------------------------------
+// Transactions
+// ----------------------------------------------------------------------------
+
+// Transaction commands are added by init since EXEC runs commands.
+func init() {
+	commands["multi"] = command{1, cmdMulti}
+	commands["exec"] = command{1, cmdExec}
+	commands["discard"] = command{1, cmdDiscard}
+}
+
+func cmdMulti(s *Server, c *conn, args []string) interface{} {
+	if c.multi {
+		return redisError("ERR MULTI calls can not be nested")
+	}
+
+	c.multi = true
+
+	return statusOK
+}
+
+// cmdExec executes the queued commands at once, since s.mu is held.
+// Blocking commands do not block in a transaction.
+func cmdExec(s *Server, c *conn, args []string) interface{} {
+	if !c.multi {
+		return redisError("ERR EXEC without MULTI")
+	}
+
+	queued, aborted := c.queued, c.aborted
+	c.multi, c.queued, c.aborted = false, nil, false
+
+	if aborted {
+		return redisError("EXECABORT Transaction discarded because of previous errors.")
+	}
+
+	results := make([]interface{}, len(queued))
+	for i, args := range queued {
+		reply := s.run(c, args)
+		if _, ok := reply.(blocked); ok {
+			reply = nilArray{}
+		}
+		results[i] = reply
+	}
+
+	return results
+}
+
+func cmdDiscard(s *Server, c *conn, args []string) interface{} {
+	if !c.multi {
+		return redisError("ERR DISCARD without MULTI")
+	}
+
+	c.multi, c.queued, c.aborted = false, nil, false
+
+	return statusOK
+}
+
+// ----------------------------------------------------------------------------
+// Scripting
+// ----------------------------------------------------------------------------
+
+// Scripting commands are added by init since scripts run commands.
+func init() {
+	commands["eval"] = command{-3, cmdEval}
+	commands["evalsha"] = command{-3, cmdEvalSHA}
+	commands["script"] = command{-2, cmdScript}
+}
+
+func scriptSHA1(script string) string {
+	sum := sha1.Sum([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+func cmdEval(s *Server, c *conn, args []string) interface{} {
+	sha := scriptSHA1(args[0])
+	s.loaded[sha] = struct{}{}
+
+	return eval(s, c, sha, args[1:])
+}
+
+func cmdEvalSHA(s *Server, c *conn, args []string) interface{} {
+	sha := strings.ToLower(args[0])
+	if _, found := s.loaded[sha]; !found {
+		return redisError("NOSCRIPT No matching script. Please use EVAL.")
+	}
+
+	return eval(s, c, sha, args[1:])
+}
+
+// eval runs the script with the given SHA1 digest with "numkeys key [key ...] arg [arg ...]".
+func eval(s *Server, c *conn, sha string, args []string) interface{} {
+	numKeys, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errNotInt
+	}
+
+	if numKeys < 0 || numKeys > len(args)-1 {
+		return redisError("ERR Number of keys can't be greater than number of args")
+	}
+
+	fn, found := s.scripts[sha]
+	if !found {
+		return redisError("ERR redistest: script " + sha + " is not registered")
+	}
+
+	call := func(args ...string) (interface{}, error) {
+		return scriptValue(s.run(c, args))
+	}
+
+	reply, err := fn(call, args[1:numKeys+1], args[numKeys+1:])
+	if err != nil {
+		return redisError(err.Error())
+	}
+
+	return scriptReply(reply)
+}
+
+// scriptValue converts a command reply to the value returned by redis.call.
+func scriptValue(reply interface{}) (interface{}, error) {
+	switch v := reply.(type) {
+	case redisError:
+		return nil, errors.New(string(v))
+	case status:
+		return string(v), nil
+	case int:
+		return int64(v), nil
+	case nilArray, blocked:
+		return nil, nil
+	case []string:
+		values := make([]interface{}, len(v))
+		for i, s := range v {
+			values[i] = s
+		}
+		return values, nil
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i], _ = scriptValue(item)
+		}
+		return values, nil
+	default:
+		return v, nil
+	}
+}
+
+// scriptReply converts a script result to its reply, as Redis converts Lua values.
+func scriptReply(v interface{}) interface{} {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return 1
+		}
+		return nil
+	case int:
+		return v
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case string, nil:
+		return v
+	case []interface{}:
+		reply := make([]interface{}, len(v))
+		for i, item := range v {
+			reply[i] = scriptReply(item)
+		}
+		return reply
+	default:
+		panic(fmt.Sprintf("redistest: unsupported script value type %T", v))
+	}
+}
+
+// cmdScript implements SCRIPT LOAD, EXISTS and FLUSH.
+func cmdScript(s *Server, c *conn, args []string) interface{} {
+	switch strings.ToLower(args[0]) {
+	case "load":
+		if len(args) != 2 {
+			return errWrongNumberOfArgs("script|load")
+		}
+
+		sha := scriptSHA1(args[1])
+		s.loaded[sha] = struct{}{}
+
+		return sha
+	case "exists":
+		reply := make([]interface{}, len(args)-1)
+		for i, sha := range args[1:] {
+			_, found := s.loaded[strings.ToLower(sha)]
+			reply[i] = 0
+			if found {
+				reply[i] = 1
+			}
+		}
+
+		return reply
+	case "flush":
+		s.loaded = map[string]struct{}{}
+
+		return statusOK
+	default:
+		return errSyntax
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Pub/Sub
+// ----------------------------------------------------------------------------
+
+func cmdSubscribe(s *Server, c *conn, args []string) interface{} {
+	if c.channels == nil {
+		c.channels = map[string]struct{}{}
+	}
+
+	return subscribe(c, "subscribe", c.channels, args)
+}
+
+func cmdPSubscribe(s *Server, c *conn, args []string) interface{} {
+	if c.patterns == nil {
+		c.patterns = map[string]struct{}{}
+	}
+
+	return subscribe(c, "psubscribe", c.patterns, args)
+}
+
+// subscribe adds names to the given subscriptions and returns a confirmation for each of them.
+func subscribe(c *conn, kind string, subscriptions map[string]struct{}, names []string) interface{} {
+	reply := make(replies, len(names))
+	for i, name := range names {
+		subscriptions[name] = struct{}{}
+		reply[i] = []interface{}{kind, name, len(c.channels) + len(c.patterns)}
+	}
+
+	return reply
+}
+
+func cmdPublish(s *Server, c *conn, args []string) interface{} {
+	return s.publish(args[0], args[1])
+}
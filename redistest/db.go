@@ -0,0 +1,329 @@
+package redistest
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ulule/gokvstores/internal/glob"
+	"github.com/ulule/gokvstores/internal/hll"
+)
+
+// item is a value stored in a database.
+//
+// Values are stored as:
+//
+//	string                 for strings
+//	map[string]string      for hashes
+//	map[string]struct{}    for sets
+//	map[string]float64     for sorted sets
+//	[]string               for lists
+//	*stream                for streams
+//	*hll.Sketch            for HyperLogLogs, which are strings in Redis
+type item struct {
+	value    interface{}
+	expireAt time.Time
+}
+
+func (i *item) expired(now time.Time) bool {
+	return !i.expireAt.IsZero() && !now.Before(i.expireAt)
+}
+
+// db is a Redis database.
+type db struct {
+	items map[string]*item
+
+	// expired is called when an expired key is removed, to notify it.
+	expired func(key string)
+}
+
+func newDB() *db {
+	return &db{items: map[string]*item{}}
+}
+
+// get returns the item stored at key, removing it if expired.
+func (d *db) get(key string) *item {
+	i, ok := d.items[key]
+	if !ok {
+		return nil
+	}
+
+	if i.expired(time.Now()) {
+		d.expire(key)
+		return nil
+	}
+
+	return i
+}
+
+// expire removes the given expired key.
+func (d *db) expire(key string) {
+	delete(d.items, key)
+
+	if d.expired != nil {
+		d.expired(key)
+	}
+}
+
+// lookup returns the value stored at key or nil.
+func (d *db) lookup(key string) interface{} {
+	if i := d.get(key); i != nil {
+		return i.value
+	}
+	return nil
+}
+
+// set stores value at key, discarding any expiration.
+func (d *db) set(key string, value interface{}) {
+	d.items[key] = &item{value: value}
+}
+
+// update stores value at key, keeping the existing expiration.
+func (d *db) update(key string, value interface{}) {
+	if i := d.get(key); i != nil {
+		i.value = value
+		return
+	}
+	d.set(key, value)
+}
+
+// del removes the given key and reports whether it existed.
+func (d *db) del(key string) bool {
+	if d.get(key) == nil {
+		return false
+	}
+	delete(d.items, key)
+	return true
+}
+
+// keys returns the sorted keys matching the given pattern.
+func (d *db) keys(pattern string) []string {
+	now := time.Now()
+	keys := []string{}
+
+	for key, i := range d.items {
+		if i.expired(now) {
+			d.expire(key)
+			continue
+		}
+		if glob.Match(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// str returns the string stored at key, or an empty string if the key does not exist.
+func (d *db) str(key string) (string, bool) {
+	switch v := d.lookup(key).(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	default:
+		return "", false
+	}
+}
+
+// hyperLogLog returns the HyperLogLog stored at key.
+// If the key does not exist, it returns nil or a new HyperLogLog when create is true.
+func (d *db) hyperLogLog(key string, create bool) (*hll.Sketch, bool) {
+	switch v := d.lookup(key).(type) {
+	case nil:
+		if !create {
+			return nil, true
+		}
+		sketch := hll.New()
+		d.set(key, sketch)
+		return sketch, true
+	case *hll.Sketch:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// hash returns the hash stored at key.
+// If the key does not exist, it returns nil or a new hash when create is true.
+func (d *db) hash(key string, create bool) (map[string]string, bool) {
+	switch v := d.lookup(key).(type) {
+	case nil:
+		if !create {
+			return nil, true
+		}
+		h := map[string]string{}
+		d.set(key, h)
+		return h, true
+	case map[string]string:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// setValue returns the set stored at key.
+// If the key does not exist, it returns nil or a new set when create is true.
+func (d *db) setValue(key string, create bool) (map[string]struct{}, bool) {
+	switch v := d.lookup(key).(type) {
+	case nil:
+		if !create {
+			return nil, true
+		}
+		s := map[string]struct{}{}
+		d.set(key, s)
+		return s, true
+	case map[string]struct{}:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// zset returns the sorted set stored at key.
+// If the key does not exist, it returns nil or a new sorted set when create is true.
+func (d *db) zset(key string, create bool) (map[string]float64, bool) {
+	switch v := d.lookup(key).(type) {
+	case nil:
+		if !create {
+			return nil, true
+		}
+		z := map[string]float64{}
+		d.set(key, z)
+		return z, true
+	case map[string]float64:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// list returns the list stored at key, or nil if the key does not exist.
+// Lists are replaced with update, and deleted once empty.
+func (d *db) list(key string) ([]string, bool) {
+	switch v := d.lookup(key).(type) {
+	case nil:
+		return nil, true
+	case []string:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// stream returns the stream stored at key.
+// If the key does not exist, it returns nil or a new stream when create is true.
+func (d *db) stream(key string, create bool) (*stream, bool) {
+	switch v := d.lookup(key).(type) {
+	case nil:
+		if !create {
+			return nil, true
+		}
+		st := &stream{groups: map[string]*group{}}
+		d.set(key, st)
+		return st, true
+	case *stream:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// streamID is a stream entry ID.
+type streamID struct {
+	ms  uint64
+	seq uint64
+}
+
+// parseStreamID parses an ID formatted as "<ms>-<seq>" or "<ms>".
+func parseStreamID(s string) (streamID, bool) {
+	ms, seq, found := strings.Cut(s, "-")
+
+	var (
+		id  streamID
+		err error
+	)
+
+	if id.ms, err = strconv.ParseUint(ms, 10, 64); err != nil {
+		return streamID{}, false
+	}
+
+	if found {
+		if id.seq, err = strconv.ParseUint(seq, 10, 64); err != nil {
+			return streamID{}, false
+		}
+	}
+
+	return id, true
+}
+
+func (id streamID) String() string {
+	return strconv.FormatUint(id.ms, 10) + "-" + strconv.FormatUint(id.seq, 10)
+}
+
+func (id streamID) less(other streamID) bool {
+	return id.ms < other.ms || (id.ms == other.ms && id.seq < other.seq)
+}
+
+// streamEntry is an entry of a stream.
+type streamEntry struct {
+	id     streamID
+	fields []string
+}
+
+// stream is a stream value.
+type stream struct {
+	entries []streamEntry
+	lastID  streamID
+	groups  map[string]*group
+}
+
+// after returns the index of the first entry with an ID greater than id.
+func (st *stream) after(id streamID) int {
+	return sort.Search(len(st.entries), func(i int) bool {
+		return id.less(st.entries[i].id)
+	})
+}
+
+// entry returns the entry with the given ID, or nil if it was deleted.
+func (st *stream) entry(id streamID) *streamEntry {
+	i := sort.Search(len(st.entries), func(i int) bool {
+		return !st.entries[i].id.less(id)
+	})
+
+	if i < len(st.entries) && st.entries[i].id == id {
+		return &st.entries[i]
+	}
+
+	return nil
+}
+
+// group is a consumer group of a stream.
+type group struct {
+	lastID  streamID
+	pending map[streamID]*pendingEntry
+}
+
+// pendingIDs returns the IDs of the pending entries by ascending ID.
+func (g *group) pendingIDs() []streamID {
+	ids := make([]streamID, 0, len(g.pending))
+	for id := range g.pending {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].less(ids[j])
+	})
+
+	return ids
+}
+
+// pendingEntry is an entry delivered to a consumer but not acknowledged yet.
+type pendingEntry struct {
+	consumer   string
+	delivered  time.Time
+	deliveries int
+}
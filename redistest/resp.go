@@ -0,0 +1,188 @@
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// conn is a client connection.
+type conn struct {
+	net.Conn
+	rd   *bufio.Reader
+	wr   *bufio.Writer
+	db   int
+	quit bool
+
+	// wmu serializes writes, since Pub/Sub messages are written by publishers.
+	wmu sync.Mutex
+
+	// channels and patterns are the Pub/Sub subscriptions, guarded by Server.mu.
+	channels map[string]struct{}
+	patterns map[string]struct{}
+
+	// multi is set between MULTI and EXEC, queued holds the commands of the transaction
+	// and aborted is set when one of them is rejected.
+	multi   bool
+	queued  [][]string
+	aborted bool
+}
+
+// status is a simple string reply.
+type status string
+
+// redisError is an error reply.
+type redisError string
+
+// protocolError is an error reply sent before closing the connection.
+type protocolError string
+
+func (e protocolError) Error() string {
+	return string(e)
+}
+
+// replies are several replies to a single command, such as SUBSCRIBE confirmations.
+type replies []interface{}
+
+// nilArray is a null array reply.
+type nilArray struct{}
+
+// blocked is returned by blocking commands which cannot be served yet.
+// The command is executed again until it is served or the deadline, if any, is reached.
+type blocked struct {
+	deadline time.Time
+}
+
+const (
+	statusOK     = status("OK")
+	errWrongType = redisError("WRONGTYPE Operation against a key holding the wrong kind of value")
+	errSyntax    = redisError("ERR syntax error")
+	errNotInt    = redisError("ERR value is not an integer or out of range")
+	errNotFloat  = redisError("ERR value is not a valid float")
+)
+
+func errWrongNumberOfArgs(command string) redisError {
+	return redisError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", command))
+}
+
+// readCommand reads a command sent as a RESP array of bulk strings.
+func (c *conn) readCommand() ([]string, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 || line[0] != '*' {
+		return nil, protocolError(fmt.Sprintf("ERR Protocol error: expected '*', got '%s'", line))
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, protocolError("ERR Protocol error: invalid multibulk length")
+	}
+
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line) == 0 || line[0] != '$' {
+			return nil, protocolError(fmt.Sprintf("ERR Protocol error: expected '$', got '%s'", line))
+		}
+
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, protocolError("ERR Protocol error: invalid bulk length")
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(c.rd, buf); err != nil {
+			return nil, err
+		}
+
+		args = append(args, string(buf[:size]))
+	}
+
+	return args, nil
+}
+
+func (c *conn) readLine() (string, error) {
+	line, err := c.rd.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", protocolError("ERR Protocol error: invalid line ending")
+	}
+
+	return line[:len(line)-2], nil
+}
+
+// writeReply writes the given value as a RESP reply.
+func (c *conn) writeReply(v interface{}) error {
+	var err error
+
+	switch v := v.(type) {
+	case nil:
+		_, err = c.wr.WriteString("$-1\r\n")
+	case nilArray:
+		_, err = c.wr.WriteString("*-1\r\n")
+	case replies:
+		for _, reply := range v {
+			if err = c.writeReply(reply); err != nil {
+				return err
+			}
+		}
+	case status:
+		_, err = fmt.Fprintf(c.wr, "+%s\r\n", v)
+	case redisError:
+		_, err = fmt.Fprintf(c.wr, "-%s\r\n", v)
+	case protocolError:
+		_, err = fmt.Fprintf(c.wr, "-%s\r\n", v)
+	case int:
+		_, err = fmt.Fprintf(c.wr, ":%d\r\n", v)
+	case int64:
+		_, err = fmt.Fprintf(c.wr, ":%d\r\n", v)
+	case string:
+		_, err = fmt.Fprintf(c.wr, "$%d\r\n%s\r\n", len(v), v)
+	case []string:
+		if _, err = fmt.Fprintf(c.wr, "*%d\r\n", len(v)); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err = c.writeReply(item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if _, err = fmt.Fprintf(c.wr, "*%d\r\n", len(v)); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err = c.writeReply(item); err != nil {
+				return err
+			}
+		}
+	default:
+		panic(fmt.Sprintf("redistest: unsupported reply type %T", v))
+	}
+
+	return err
+}
+
+// push writes a Pub/Sub message.
+func (c *conn) push(message []interface{}) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.writeReply(message) == nil {
+		c.wr.Flush()
+	}
+}
@@ -0,0 +1,488 @@
+// Package redistest provides an in-process Redis server for tests.
+//
+// The server speaks RESP2 and implements the subset of commands used by
+// gokvstores.RedisStore, so the Redis backend can be tested without a running
+// redis-server. Failures can be injected to test how clients behave when the
+// server is slow, returns errors or drops connections:
+//
+//	server, err := redistest.NewServer()
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer server.Close()
+//
+//	store, err := gokvstores.NewRedisClientStore(ctx, &gokvstores.RedisClientOptions{
+//		Addr: server.Addr(),
+//	}, time.Minute)
+package redistest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ulule/gokvstores/internal/glob"
+)
+
+// Server is an in-process Redis server.
+type Server struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	dbs      map[int]*db
+	conns    map[*conn]struct{}
+	latency  time.Duration
+	failures []*failure
+	closed   bool
+
+	// notifyFlags is the notify-keyspace-events configuration.
+	notifyFlags string
+
+	// scripts are the registered script emulations and loaded the SHA1 digests of
+	// the scripts loaded with EVAL or SCRIPT LOAD, both by SHA1 digest.
+	scripts map[string]ScriptFunc
+	loaded  map[string]struct{}
+
+	wg sync.WaitGroup
+}
+
+// failure is an error reply injected in place of a command execution.
+type failure struct {
+	command string
+	count   int
+	message string
+}
+
+// NewServer starts a new server listening on a random local port.
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: listener,
+		dbs:      map[int]*db{},
+		conns:    map[*conn]struct{}{},
+		scripts:  map[string]ScriptFunc{},
+		loaded:   map[string]struct{}{},
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server and closes all client connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	err := s.listener.Close()
+	s.CloseConnections()
+	s.wg.Wait()
+
+	return err
+}
+
+// SetLatency delays every reply by the given duration.
+// It can be used to trigger client read timeouts.
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency = latency
+}
+
+// FailNext makes the next count executions of command reply with the given error message
+// instead of being executed. An empty command matches every command.
+//
+//	server.FailNext("get", 3, "ERR injected failure")
+func (s *Server) FailNext(command string, count int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, &failure{
+		command: strings.ToLower(command),
+		count:   count,
+		message: message,
+	})
+}
+
+// ScriptFunc emulates a Lua script, since the server cannot run Lua.
+//
+// call runs a command as redis.call does, returning an int64, a string, nil or
+// a []interface{} of these values, or an error for error replies. The returned
+// value is sent as the script reply, a non-nil error being sent as an error reply.
+type ScriptFunc func(call func(args ...string) (interface{}, error), keys []string, args []string) (interface{}, error)
+
+// RegisterScript makes EVAL and EVALSHA run fn for the given script source.
+// Evaluating scripts which are not registered fails.
+//
+//	server.RegisterScript(source, func(call func(args ...string) (interface{}, error), keys []string, args []string) (interface{}, error) {
+//		return call("INCRBY", keys[0], args[0])
+//	})
+func (s *Server) RegisterScript(script string, fn ScriptFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scripts[scriptSHA1(script)] = fn
+}
+
+// CloseConnections closes all client connections, as a server restart or a network failure would.
+func (s *Server) CloseConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.conns {
+		c.Close()
+	}
+}
+
+// Flush removes all keys from all databases.
+func (s *Server) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dbs = map[int]*db{}
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		nc, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		c := &conn{
+			Conn: nc,
+			rd:   bufio.NewReader(nc),
+			wr:   bufio.NewWriter(nc),
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			nc.Close()
+			return
+		}
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handle(c)
+	}
+}
+
+func (s *Server) handle(c *conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		c.Close()
+	}()
+
+	for {
+		args, err := c.readCommand()
+		if err != nil {
+			var perr protocolError
+			if errors.As(err, &perr) {
+				c.wmu.Lock()
+				c.writeReply(perr)
+				c.wr.Flush()
+				c.wmu.Unlock()
+			}
+			return
+		}
+
+		if len(args) == 0 {
+			continue
+		}
+
+		reply, latency := s.exec(c, args)
+		if b, ok := reply.(blocked); ok {
+			reply = s.block(c, args, b)
+		}
+
+		if latency > 0 {
+			time.Sleep(latency)
+		}
+
+		if err := c.write(reply); err != nil {
+			return
+		}
+
+		if c.quit {
+			return
+		}
+	}
+}
+
+// write writes the reply to a command.
+// Replies to pipelined commands are flushed at once.
+func (c *conn) write(reply interface{}) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if err := c.writeReply(reply); err != nil {
+		return err
+	}
+
+	if c.rd.Buffered() == 0 || c.quit {
+		return c.wr.Flush()
+	}
+
+	return nil
+}
+
+// exec executes the given command and returns its reply and the latency to apply.
+func (s *Server) exec(c *conn, args []string) (interface{}, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := strings.ToLower(args[0])
+
+	if f := s.nextFailure(name); f != nil {
+		return redisError(f.message), s.latency
+	}
+	if c.multi && !transactionCommands[name] {
+		return s.queue(c, args), s.latency
+	}
+	return s.run(c, args), s.latency
+}
+
+// transactionCommands are the commands which are executed rather than queued in a transaction.
+var transactionCommands = map[string]bool{"multi": true, "exec": true, "discard": true}
+
+// queue queues a command of the current transaction. Unknown commands and commands with
+// a wrong number of arguments abort the transaction, as they do in Redis.
+func (s *Server) queue(c *conn, args []string) interface{} {
+	name := strings.ToLower(args[0])
+
+	cmd, ok := commands[name]
+	if !ok {
+		c.aborted = true
+		return redisError("ERR unknown command '" + args[0] + "'")
+	}
+
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		c.aborted = true
+		return errWrongNumberOfArgs(name)
+	}
+
+	c.queued = append(c.queued, args)
+
+	return status("QUEUED")
+}
+
+// run executes the given command and returns its reply. It must be called with s.mu held.
+func (s *Server) run(c *conn, args []string) interface{} {
+	name := strings.ToLower(args[0])
+
+	cmd, ok := commands[name]
+	if !ok {
+		return redisError("ERR unknown command '" + args[0] + "'")
+	}
+
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		return errWrongNumberOfArgs(name)
+	}
+
+	event, notify := keyspaceEvents[name]
+	existed := notify && s.db(c).get(args[1]) != nil
+
+	reply := cmd.fn(s, c, args[1:])
+	if notify {
+		s.notifyCommand(c, event, args[1], existed, reply)
+	}
+
+	return reply
+}
+
+// blockInterval is the interval at which blocked commands are executed again.
+const blockInterval = 5 * time.Millisecond
+
+// block executes a blocked command again until it is served, its deadline is reached
+// or the server is closed.
+func (s *Server) block(c *conn, args []string, b blocked) interface{} {
+	for {
+		if !b.deadline.IsZero() && !time.Now().Before(b.deadline) {
+			return nilArray{}
+		}
+
+		time.Sleep(blockInterval)
+
+		s.mu.Lock()
+		closed := s.closed
+		s.mu.Unlock()
+
+		if closed {
+			return nilArray{}
+		}
+
+		reply, _ := s.exec(c, args)
+		if _, ok := reply.(blocked); !ok {
+			return reply
+		}
+	}
+}
+
+func (s *Server) nextFailure(command string) *failure {
+	for i, f := range s.failures {
+		if f.command != "" && f.command != command {
+			continue
+		}
+
+		f.count--
+		if f.count <= 0 {
+			s.failures = append(s.failures[:i], s.failures[i+1:]...)
+		}
+
+		return f
+	}
+
+	return nil
+}
+
+// db returns the database selected by the given connection.
+func (s *Server) db(c *conn) *db {
+	d, ok := s.dbs[c.db]
+	if !ok {
+		index := c.db
+
+		d = newDB()
+		d.expired = func(key string) {
+			s.notifyKeyspace(index, 'x', "expired", key)
+		}
+		s.dbs[c.db] = d
+	}
+	return d
+}
+
+// keyspaceEvent is the keyspace notification of a command changing its first key.
+type keyspaceEvent struct {
+	class string // class flag of the event in notify-keyspace-events
+	name  string
+
+	// conditional events are only sent when the command replies with neither 0 nor nil.
+	conditional bool
+}
+
+// keyspaceEvents are the notifications of the commands changing their first key.
+// DEL notifies each of its keys itself.
+var keyspaceEvents = map[string]keyspaceEvent{
+	"expire":  {"g", "expire", true},
+	"pexpire": {"g", "expire", true},
+
+	"set":   {"$", "set", true},
+	"setnx": {"$", "set", true},
+
+	"setbit":  {"$", "setbit", false},
+	"pfadd":   {"$", "pfadd", true},
+	"pfmerge": {"$", "pfadd", false},
+
+	"hset":  {"h", "hset", false},
+	"hmset": {"h", "hset", false},
+	"hdel":  {"h", "hdel", true},
+
+	"sadd": {"s", "sadd", true},
+	"srem": {"s", "srem", true},
+
+	"zadd":             {"z", "zadd", false},
+	"zincrby":          {"z", "zincr", false},
+	"zrem":             {"z", "zrem", true},
+	"zremrangebyscore": {"z", "zrembyscore", true},
+	"geoadd":           {"z", "zadd", false},
+
+	"lpush": {"l", "lpush", false},
+	"rpush": {"l", "rpush", false},
+	"lpop":  {"l", "lpop", true},
+	"rpop":  {"l", "rpop", true},
+
+	"xadd":  {"t", "xadd", false},
+	"xtrim": {"t", "xtrim", true},
+}
+
+// notifyCommand sends the keyspace notification of a command changing key, along with
+// a "del" notification if the command removed it, e.g. by removing the last member of a set.
+func (s *Server) notifyCommand(c *conn, event keyspaceEvent, key string, existed bool, reply interface{}) {
+	switch reply {
+	case nil, nilArray{}:
+		return
+	case 0:
+		if event.conditional {
+			return
+		}
+	}
+
+	if _, ok := reply.(redisError); ok {
+		return
+	}
+
+	s.notifyKeyspace(c.db, event.class[0], event.name, key)
+
+	if existed && s.db(c).get(key) == nil {
+		s.notifyKeyspace(c.db, 'g', "del", key)
+	}
+}
+
+// keyspaceClasses are the event classes enabled by the "A" flag of notify-keyspace-events.
+const keyspaceClasses = "g$lshzxet"
+
+// notifyKeyspace publishes the given event of key on its keyspace and keyevent channels,
+// as enabled by notify-keyspace-events.
+func (s *Server) notifyKeyspace(db int, class byte, event string, key string) {
+	flags := s.notifyFlags
+
+	enabled := strings.IndexByte(flags, class) >= 0 ||
+		(strings.Contains(flags, "A") && strings.IndexByte(keyspaceClasses, class) >= 0)
+	if !enabled {
+		return
+	}
+
+	if strings.Contains(flags, "K") {
+		s.publish(fmt.Sprintf("__keyspace@%d__:%s", db, key), event)
+	}
+
+	if strings.Contains(flags, "E") {
+		s.publish(fmt.Sprintf("__keyevent@%d__:%s", db, event), key)
+	}
+}
+
+// publish sends message to the subscribers of channel and returns their number.
+func (s *Server) publish(channel string, message string) int {
+	count := 0
+	for sc := range s.conns {
+		if _, found := sc.channels[channel]; found {
+			sc.push([]interface{}{"message", channel, message})
+			count++
+		}
+
+		for pattern := range sc.patterns {
+			if glob.Match(pattern, channel) {
+				sc.push([]interface{}{"pmessage", pattern, channel, message})
+				count++
+			}
+		}
+	}
+
+	return count
+}
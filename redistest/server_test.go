@@ -0,0 +1,226 @@
+package redistest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(t *testing.T) (*Server, *redis.Client) {
+	server, err := NewServer()
+	require.NoError(t, err)
+
+	return server, redis.NewClient(&redis.Options{Addr: server.Addr()})
+}
+
+func TestServer(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, client := newClient(t)
+	defer server.Close()
+	defer client.Close()
+
+	is.Equal("PONG", client.Ping(ctx).Val())
+	is.Equal([]interface{}{"master", int64(0), []interface{}{}}, client.Do(ctx, "role").Val())
+
+	// Strings
+
+	is.NoError(client.Set(ctx, "key", "value", 0).Err())
+	is.Equal("value", client.Get(ctx, "key").Val())
+	is.Equal(redis.Nil, client.Get(ctx, "missing").Err())
+	is.False(client.SetNX(ctx, "key", "other", 0).Val())
+	is.True(client.SetNX(ctx, "new", "other", 0).Val())
+	is.Equal([]interface{}{"value", nil}, client.MGet(ctx, "key", "missing").Val())
+
+	// Expiration
+
+	is.NoError(client.Set(ctx, "expiring", "value", 50*time.Millisecond).Err())
+	is.True(client.PTTL(ctx, "expiring").Val() > 0)
+	is.Equal(time.Duration(-1), client.TTL(ctx, "key").Val())
+	time.Sleep(100 * time.Millisecond)
+	is.Equal(int64(0), client.Exists(ctx, "expiring").Val())
+
+	// Hashes and sets
+
+	is.Equal(int64(2), client.HSet(ctx, "hash", "a", "1", "b", "2").Val())
+	is.Equal(map[string]string{"a": "1", "b": "2"}, client.HGetAll(ctx, "hash").Val())
+	is.Equal([]interface{}{"2", nil, "1"}, client.HMGet(ctx, "hash", "b", "c", "a").Val())
+	is.Equal(int64(2), client.HDel(ctx, "hash", "a", "b").Val())
+	is.Equal(int64(0), client.Exists(ctx, "hash").Val())
+
+	is.Equal(int64(2), client.SAdd(ctx, "set", "a", "b", "a").Val())
+	is.Equal([]string{"a", "b"}, client.SMembers(ctx, "set").Val())
+	is.Equal("set", client.Type(ctx, "set").Val())
+
+	// Sorted sets
+
+	is.Equal(int64(2), client.ZAdd(ctx, "zset", &redis.Z{Score: 1, Member: "a"}, &redis.Z{Score: 2.5, Member: "b"}).Val())
+	is.Equal(3.5, client.ZIncrBy(ctx, "zset", 1, "b").Val())
+	is.Equal([]string{"a"}, client.ZRangeByScore(ctx, "zset", &redis.ZRangeBy{Min: "-inf", Max: "(3.5"}).Val())
+	is.Equal([]redis.Z{{Score: 3.5, Member: "b"}, {Score: 1, Member: "a"}}, client.ZRevRangeWithScores(ctx, "zset", 0, -1).Val())
+	is.Equal(int64(1), client.ZRank(ctx, "zset", "b").Val())
+	is.Equal(redis.Nil, client.ZRank(ctx, "zset", "missing").Err())
+	is.Equal("zset", client.Type(ctx, "zset").Val())
+
+	is.Equal([]redis.Z{{Score: 3.5, Member: "b"}}, client.ZRangeWithScores(ctx, "zset", -1, -1).Val())
+	is.Equal(int64(1), client.ZRemRangeByScore(ctx, "zset", "-inf", "1").Val())
+	is.Equal([]string{"b"}, client.ZRange(ctx, "zset", 0, -1).Val())
+	is.Equal(int64(1), client.ZRemRangeByScore(ctx, "zset", "(1", "+inf").Val())
+	is.Equal(int64(0), client.Exists(ctx, "zset").Val())
+
+	// Bitmaps
+
+	is.NoError(client.Set(ctx, "bits", "\xff", 0).Err())
+	is.Equal(int64(0), client.SetBit(ctx, "bits", 9, 1).Val())
+	is.Equal("\xff\x40", client.Get(ctx, "bits").Val())
+	is.Equal(int64(9), client.BitCount(ctx, "bits", nil).Val())
+	is.Equal(int64(8), client.BitPos(ctx, "bits", 0).Val())
+	is.NoError(client.Set(ctx, "ones", "\xff\xff", 0).Err())
+	is.Equal(int64(16), client.BitPos(ctx, "ones", 0).Val())
+	is.Equal(int64(-1), client.BitPos(ctx, "ones", 0, 0, -1).Val())
+	is.Equal("\xff", client.GetRange(ctx, "bits", 0, 0).Val())
+
+	// Errors
+
+	is.EqualError(client.HGetAll(ctx, "key").Err(), "WRONGTYPE Operation against a key holding the wrong kind of value")
+	is.EqualError(client.Do(ctx, "unknown").Err(), "ERR unknown command 'unknown'")
+	is.EqualError(client.Do(ctx, "get").Err(), "ERR wrong number of arguments for 'get' command")
+
+	// Keys and databases
+
+	is.Equal([]string{"key", "new"}, client.Keys(ctx, "[kn]*").Val())
+	is.Equal(int64(3), client.Del(ctx, "key", "new", "set", "missing").Val())
+
+	other := redis.NewClient(&redis.Options{Addr: server.Addr(), DB: 1})
+	defer other.Close()
+
+	is.NoError(other.Set(ctx, "key", "db1", 0).Err())
+	is.Equal(int64(0), client.Exists(ctx, "key").Val())
+	is.NoError(client.FlushDB(ctx).Err())
+	is.Equal("db1", other.Get(ctx, "key").Val())
+}
+
+func TestServer_Pipeline(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, client := newClient(t)
+	defer server.Close()
+	defer client.Close()
+
+	cmds, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range []string{"a", "b", "c"} {
+			pipe.Set(ctx, key, key, 0)
+			pipe.Get(ctx, key)
+		}
+		return nil
+	})
+	is.NoError(err)
+	is.Len(cmds, 6)
+	is.Equal("c", cmds[5].(*redis.StringCmd).Val())
+}
+
+func TestServer_Transaction(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, client := newClient(t)
+	defer server.Close()
+	defer client.Close()
+
+	var get *redis.StringCmd
+	cmds, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, "key", "value", 0)
+		pipe.SAdd(ctx, "key", "a")
+		get = pipe.Get(ctx, "key")
+		return nil
+	})
+	is.Error(err)
+	is.Len(cmds, 3)
+	is.NoError(cmds[0].Err())
+	is.Error(cmds[1].Err())
+	is.Equal("value", get.Val())
+
+	// Queued commands are not executed before EXEC, nor after DISCARD.
+	conn := client.Conn(ctx)
+	defer conn.Close()
+
+	do := func(args ...interface{}) *redis.Cmd {
+		cmd := redis.NewCmd(ctx, args...)
+		_ = conn.Process(ctx, cmd)
+		return cmd
+	}
+
+	is.NoError(do("multi").Err())
+	is.Equal("QUEUED", do("set", "key", "other").Val())
+	is.Equal("value", client.Get(ctx, "key").Val())
+	is.Error(do("multi").Err())
+	is.NoError(do("discard").Err())
+	is.Equal("value", client.Get(ctx, "key").Val())
+	is.Error(do("exec").Err())
+
+	// An unknown command aborts the transaction.
+	is.NoError(do("multi").Err())
+	is.Error(do("unknown").Err())
+	is.Equal("QUEUED", do("set", "key", "other").Val())
+	is.EqualError(do("exec").Err(), "EXECABORT Transaction discarded because of previous errors.")
+	is.Equal("value", client.Get(ctx, "key").Val())
+}
+
+func TestServer_FailNext(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, client := newClient(t)
+	defer server.Close()
+	defer client.Close()
+
+	server.FailNext("", 2, "ERR injected")
+
+	is.EqualError(client.Ping(ctx).Err(), "ERR injected")
+	is.EqualError(client.Get(ctx, "key").Err(), "ERR injected")
+	is.NoError(client.Ping(ctx).Err())
+}
+
+func TestServer_KeyspaceNotifications(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	server, client := newClient(t)
+	defer server.Close()
+	defer client.Close()
+
+	is.Equal([]interface{}{"notify-keyspace-events", ""}, client.ConfigGet(ctx, "notify-keyspace-events").Val())
+	is.Error(client.ConfigSet(ctx, "notify-keyspace-events", "Kq").Err())
+	is.NoError(client.ConfigSet(ctx, "notify-keyspace-events", "Kgs").Err())
+
+	pubsub := client.PSubscribe(ctx, "__keyspace@0__:*")
+	defer pubsub.Close()
+
+	_, err := pubsub.Receive(ctx)
+	require.NoError(t, err)
+
+	// Strings are not notified without the "$" flag.
+	is.NoError(client.Set(ctx, "key", "value", 0).Err())
+	is.NoError(client.SAdd(ctx, "set", "a").Err())
+	is.NoError(client.SRem(ctx, "set", "missing").Err())
+	is.NoError(client.SRem(ctx, "set", "a").Err())
+	is.NoError(client.Del(ctx, "key").Err())
+
+	for _, event := range []string{"sadd", "srem", "del"} {
+		msg, err := pubsub.ReceiveMessage(ctx)
+		require.NoError(t, err)
+		is.Equal("__keyspace@0__:set", msg.Channel)
+		is.Equal(event, msg.Payload)
+	}
+
+	msg, err := pubsub.ReceiveMessage(ctx)
+	require.NoError(t, err)
+	is.Equal("__keyspace@0__:key", msg.Channel)
+	is.Equal("del", msg.Payload)
+}
@@ -0,0 +1,338 @@
+package gokvstores
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy defines how RetryStore retries failed calls.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a call, the first one included.
+	// Defaults to 3.
+	MaxAttempts int
+
+	// MinBackoff is the backoff before the first retry. It doubles on every retry.
+	// Defaults to 8 milliseconds.
+	MinBackoff time.Duration
+
+	// MaxBackoff is the maximum backoff between retries. Defaults to 512 milliseconds.
+	MaxBackoff time.Duration
+
+	// Jitter is the fraction, between 0 and 1, of each backoff which is randomized,
+	// so that clients failing at the same time do not retry at the same time.
+	// Zero disables jitter.
+	Jitter float64
+
+	// Retryable reports whether a failed call can be retried. Defaults to IsRetryable.
+	Retryable func(err error) bool
+
+	// RetryNonIdempotent enables retries of non-idempotent operations (see IsIdempotent),
+	// which may then be applied more than once.
+	RetryNonIdempotent bool
+}
+
+// IsRetryable reports whether err is a transient failure, i.e. ErrTimeout or ErrUnavailable.
+func IsRetryable(err error) bool {
+	switch classify(err) {
+	case ErrTimeout, ErrUnavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsIdempotent reports whether applying the given operation (see the Op constants)
+// more than once has the same effect and result as applying it once. Operations reporting
+// what they changed, such as ZAdd, are not idempotent since a retry of an applied call
+// reports no change. Unknown operations, such as those of other stores, are not idempotent.
+func IsIdempotent(op string) bool {
+	switch op {
+	case OpGet, OpMGet, OpSet, OpSetWithExpiration, OpGetMap, OpGetMaps, OpSetMap, OpSetMaps,
+		OpDeleteMap, OpGetSlice, OpSetSlice, OpExists, OpExistsAll, OpExistsAny, OpCountExisting,
+		OpDelete, OpKeys, OpFlush:
+		return true
+	case OpZRangeByScore, OpZRevRange, OpZRank, OpZCard, OpLen, OpRange, OpRead, OpPending,
+		OpPFCount, OpPFMerge, OpBFReserve, OpBFExists, OpGetBit, OpBitCount, OpBitOp, OpBitPos,
+		OpGeoPos, OpGeoDist, OpGeoSearch, OpRegisterScript:
+		return true
+	default:
+		return false
+	}
+}
+
+// RetryStore is a KVStore wrapper retrying calls which fail with a transient error,
+// with an exponential backoff.
+//
+// Non-idempotent operations are not retried, unless RetryPolicy.RetryNonIdempotent is set.
+// Calls are not retried once their context is done.
+type RetryStore struct {
+	store  KVStore
+	policy RetryPolicy
+}
+
+// NewRetryStore returns a RetryStore wrapping the given store.
+func NewRetryStore(store KVStore, policy RetryPolicy) *RetryStore {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = 8 * time.Millisecond
+	}
+
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = 512 * time.Millisecond
+	}
+
+	if policy.MaxBackoff < policy.MinBackoff {
+		policy.MaxBackoff = policy.MinBackoff
+	}
+
+	if policy.Jitter < 0 {
+		policy.Jitter = 0
+	} else if policy.Jitter > 1 {
+		policy.Jitter = 1
+	}
+
+	if policy.Retryable == nil {
+		policy.Retryable = IsRetryable
+	}
+
+	return &RetryStore{
+		store:  store,
+		policy: policy,
+	}
+}
+
+// Unwrap returns the wrapped store.
+func (r *RetryStore) Unwrap() KVStore {
+	return r.store
+}
+
+// do calls fn until it succeeds, fails with a non-retryable error, or the policy
+// attempts are exhausted. It returns the error of the last attempt.
+func (r *RetryStore) do(ctx context.Context, op string, fn func() error) error {
+	attempts := r.policy.MaxAttempts
+	if !r.policy.RetryNonIdempotent && !IsIdempotent(op) {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= attempts || !r.policy.Retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		if sleep(ctx, r.backoff(attempt)) != nil {
+			return err
+		}
+	}
+}
+
+// backoff returns the duration to wait before the given retry, starting from 1.
+func (r *RetryStore) backoff(retry int) time.Duration {
+	backoff := r.policy.MaxBackoff
+	if shift := uint(retry - 1); shift < 32 && r.policy.MinBackoff<<shift < r.policy.MaxBackoff {
+		backoff = r.policy.MinBackoff << shift
+	}
+
+	if r.policy.Jitter > 0 {
+		jitter := time.Duration(r.policy.Jitter * float64(backoff))
+		if jitter > 0 {
+			backoff = backoff - jitter + time.Duration(rand.Int63n(int64(jitter)+1))
+		}
+	}
+
+	return backoff
+}
+
+// Get returns value for the given key.
+func (r *RetryStore) Get(ctx context.Context, key string) (interface{}, error) {
+	var v interface{}
+	err := r.do(ctx, OpGet, func() (err error) {
+		v, err = r.store.Get(ctx, key)
+		return err
+	})
+	return v, err
+}
+
+// MGet returns map of key, value for a list of keys.
+func (r *RetryStore) MGet(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	var v map[string]interface{}
+	err := r.do(ctx, OpMGet, func() (err error) {
+		v, err = r.store.MGet(ctx, keys)
+		return err
+	})
+	return v, err
+}
+
+// Set sets value for the given key.
+func (r *RetryStore) Set(ctx context.Context, key string, value interface{}) error {
+	return r.do(ctx, OpSet, func() error {
+		return r.store.Set(ctx, key, value)
+	})
+}
+
+// SetWithExpiration sets the value for the given key for a specified duration.
+func (r *RetryStore) SetWithExpiration(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return r.do(ctx, OpSetWithExpiration, func() error {
+		return r.store.SetWithExpiration(ctx, key, value, expiration)
+	})
+}
+
+// GetMap returns map for the given key.
+func (r *RetryStore) GetMap(ctx context.Context, key string) (map[string]interface{}, error) {
+	var v map[string]interface{}
+	err := r.do(ctx, OpGetMap, func() (err error) {
+		v, err = r.store.GetMap(ctx, key)
+		return err
+	})
+	return v, err
+}
+
+// GetMaps returns maps for the given keys.
+func (r *RetryStore) GetMaps(ctx context.Context, keys []string) (map[string]map[string]interface{}, error) {
+	var v map[string]map[string]interface{}
+	err := r.do(ctx, OpGetMaps, func() (err error) {
+		v, err = r.store.GetMaps(ctx, keys)
+		return err
+	})
+	return v, err
+}
+
+// SetMap sets map for the given key.
+func (r *RetryStore) SetMap(ctx context.Context, key string, value map[string]interface{}) error {
+	return r.do(ctx, OpSetMap, func() error {
+		return r.store.SetMap(ctx, key, value)
+	})
+}
+
+// SetMaps sets the given maps.
+func (r *RetryStore) SetMaps(ctx context.Context, maps map[string]map[string]interface{}) error {
+	return r.do(ctx, OpSetMaps, func() error {
+		return r.store.SetMaps(ctx, maps)
+	})
+}
+
+// DeleteMap removes the specified fields from the map stored at key.
+func (r *RetryStore) DeleteMap(ctx context.Context, key string, fields ...string) error {
+	return r.do(ctx, OpDeleteMap, func() error {
+		return r.store.DeleteMap(ctx, key, fields...)
+	})
+}
+
+// GetSlice returns slice for the given key.
+func (r *RetryStore) GetSlice(ctx context.Context, key string) ([]interface{}, error) {
+	var v []interface{}
+	err := r.do(ctx, OpGetSlice, func() (err error) {
+		v, err = r.store.GetSlice(ctx, key)
+		return err
+	})
+	return v, err
+}
+
+// SetSlice sets slice for the given key.
+func (r *RetryStore) SetSlice(ctx context.Context, key string, value []interface{}) error {
+	return r.do(ctx, OpSetSlice, func() error {
+		return r.store.SetSlice(ctx, key, value)
+	})
+}
+
+// AppendSlice appends values to an existing slice.
+// If key does not exist, creates slice.
+func (r *RetryStore) AppendSlice(ctx context.Context, key string, values ...interface{}) error {
+	return r.do(ctx, OpAppendSlice, func() error {
+		return r.store.AppendSlice(ctx, key, values...)
+	})
+}
+
+// Exists checks if all the given keys exist.
+func (r *RetryStore) Exists(ctx context.Context, keys ...string) (bool, error) {
+	var v bool
+	err := r.do(ctx, OpExists, func() (err error) {
+		v, err = r.store.Exists(ctx, keys...)
+		return err
+	})
+	return v, err
+}
+
+// ExistsAll checks if all the given keys exist.
+func (r *RetryStore) ExistsAll(ctx context.Context, keys ...string) (bool, error) {
+	var v bool
+	err := r.do(ctx, OpExistsAll, func() (err error) {
+		v, err = r.store.ExistsAll(ctx, keys...)
+		return err
+	})
+	return v, err
+}
+
+// ExistsAny checks if at least one of the given keys exists.
+func (r *RetryStore) ExistsAny(ctx context.Context, keys ...string) (bool, error) {
+	var v bool
+	err := r.do(ctx, OpExistsAny, func() (err error) {
+		v, err = r.store.ExistsAny(ctx, keys...)
+		return err
+	})
+	return v, err
+}
+
+// CountExisting returns the number of given keys that exist.
+func (r *RetryStore) CountExisting(ctx context.Context, keys ...string) (int, error) {
+	var v int
+	err := r.do(ctx, OpCountExisting, func() (err error) {
+		v, err = r.store.CountExisting(ctx, keys...)
+		return err
+	})
+	return v, err
+}
+
+// Delete deletes the given key.
+func (r *RetryStore) Delete(ctx context.Context, key string) error {
+	return r.do(ctx, OpDelete, func() error {
+		return r.store.Delete(ctx, key)
+	})
+}
+
+// Flush flushes the store.
+func (r *RetryStore) Flush(ctx context.Context) error {
+	return r.do(ctx, OpFlush, func() error {
+		return r.store.Flush(ctx)
+	})
+}
+
+// Keys returns all keys matching pattern.
+func (r *RetryStore) Keys(ctx context.Context, pattern string) ([]interface{}, error) {
+	var v []interface{}
+	err := r.do(ctx, OpKeys, func() (err error) {
+		v, err = r.store.Keys(ctx, pattern)
+		return err
+	})
+	return v, err
+}
+
+// Close closes the wrapped store. It is never retried.
+func (r *RetryStore) Close() error {
+	return r.store.Close()
+}
+
+// RunScript runs script on the wrapped store, which must implement ScriptStore.
+func (r *RetryStore) RunScript(ctx context.Context, script *Script, keys []string, args ...interface{}) *ScriptResult {
+	var v interface{}
+	err := r.do(ctx, OpRunScript, func() (err error) {
+		v, err = RunScript(ctx, r.store, script, keys, args...).Result()
+		return err
+	})
+	return &ScriptResult{val: v, err: err}
+}
+
+// Health checks the wrapped store, which should implement HealthChecker.
+func (r *RetryStore) Health(ctx context.Context) HealthStatus {
+	return Health(ctx, r.store)
+}
+
+var (
+	_ KVStore       = &RetryStore{}
+	_ ScriptStore   = &RetryStore{}
+	_ HealthChecker = &RetryStore{}
+)
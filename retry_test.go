@@ -0,0 +1,221 @@
+package gokvstores_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ulule/gokvstores"
+	"github.com/ulule/gokvstores/kvstoretest"
+)
+
+func newRetryStore(t *testing.T, policy gokvstores.RetryPolicy) (*gokvstores.RetryStore, *gokvstores.RecordingStore, *gokvstores.ChaosStore) {
+	chaos := newChaosStore(t, gokvstores.ChaosOptions{})
+	recording := gokvstores.NewRecordingStore(chaos)
+
+	return gokvstores.NewRetryStore(recording, policy), recording, chaos
+}
+
+func TestRetryStore(t *testing.T) {
+	kvstoretest.RunConformance(t, func(t *testing.T) gokvstores.KVStore {
+		store, _, _ := newRetryStore(t, gokvstores.RetryPolicy{})
+		return store
+	})
+}
+
+func TestRetryStore_Retries(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store, recording, chaos := newRetryStore(t, gokvstores.RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+		Jitter:      0.5,
+	})
+
+	is.NoError(store.Set(ctx, "key", "value"))
+
+	chaos.FailNext(gokvstores.OpGet, 2, gokvstores.ErrUnavailable)
+
+	v, err := store.Get(ctx, "key")
+	is.NoError(err)
+	is.Equal("value", v)
+	recording.AssertNumberOfCalls(t, gokvstores.OpGet, 3)
+
+	recording.Reset()
+	chaos.FailNext(gokvstores.OpGet, 3, gokvstores.ErrTimeout)
+
+	_, err = store.Get(ctx, "key")
+	is.Equal(gokvstores.ErrTimeout, err)
+	recording.AssertNumberOfCalls(t, gokvstores.OpGet, 3)
+
+	// Non-retryable errors
+
+	recording.Reset()
+	chaos.FailNext(gokvstores.OpGet, 1, gokvstores.ErrWrongType)
+
+	_, err = store.Get(ctx, "key")
+	is.Equal(gokvstores.ErrWrongType, err)
+	recording.AssertNumberOfCalls(t, gokvstores.OpGet, 1)
+
+	chaos.Reset()
+}
+
+func TestRetryStore_NonIdempotent(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	store, recording, chaos := newRetryStore(t, gokvstores.RetryPolicy{MinBackoff: time.Millisecond})
+
+	chaos.FailNext(gokvstores.OpAppendSlice, 1, gokvstores.ErrTimeout)
+	is.Equal(gokvstores.ErrTimeout, store.AppendSlice(ctx, "key", "a"))
+	recording.AssertNumberOfCalls(t, gokvstores.OpAppendSlice, 1)
+
+	store, recording, chaos = newRetryStore(t, gokvstores.RetryPolicy{
+		MinBackoff:         time.Millisecond,
+		RetryNonIdempotent: true,
+	})
+
+	chaos.FailNext(gokvstores.OpAppendSlice, 1, gokvstores.ErrTimeout)
+	is.NoError(store.AppendSlice(ctx, "key", "a"))
+	recording.AssertNumberOfCalls(t, gokvstores.OpAppendSlice, 2)
+}
+
+func TestIsIdempotent(t *testing.T) {
+	is := assert.New(t)
+
+	for op, idempotent := range map[string]bool{
+		gokvstores.OpGet:               true,
+		gokvstores.OpMGet:              true,
+		gokvstores.OpSet:               true,
+		gokvstores.OpSetWithExpiration: true,
+		gokvstores.OpGetMap:            true,
+		gokvstores.OpGetMaps:           true,
+		gokvstores.OpSetMap:            true,
+		gokvstores.OpSetMaps:           true,
+		gokvstores.OpDeleteMap:         true,
+		gokvstores.OpGetSlice:          true,
+		gokvstores.OpSetSlice:          true,
+		gokvstores.OpAppendSlice:       false,
+		gokvstores.OpExists:            true,
+		gokvstores.OpExistsAll:         true,
+		gokvstores.OpExistsAny:         true,
+		gokvstores.OpCountExisting:     true,
+		gokvstores.OpDelete:            true,
+		gokvstores.OpFlush:             true,
+		gokvstores.OpKeys:              true,
+		gokvstores.OpClose:             false,
+
+		gokvstores.OpZAdd:          false,
+		gokvstores.OpZIncrBy:       false,
+		gokvstores.OpZRangeByScore: true,
+		gokvstores.OpZRevRange:     true,
+		gokvstores.OpZRank:         true,
+		gokvstores.OpZRem:          false,
+		gokvstores.OpZCard:         true,
+
+		gokvstores.OpPushLeft:    false,
+		gokvstores.OpPushRight:   false,
+		gokvstores.OpPopLeft:     false,
+		gokvstores.OpPopRight:    false,
+		gokvstores.OpBlockingPop: false,
+		gokvstores.OpLen:         true,
+		gokvstores.OpRange:       true,
+
+		gokvstores.OpAppend:      false,
+		gokvstores.OpRead:        true,
+		gokvstores.OpCreateGroup: false,
+		gokvstores.OpReadGroup:   false,
+		gokvstores.OpAck:         false,
+		gokvstores.OpPending:     true,
+		gokvstores.OpClaim:       false,
+		gokvstores.OpTrim:        false,
+
+		gokvstores.OpPublish:    false,
+		gokvstores.OpSubscribe:  false,
+		gokvstores.OpPSubscribe: false,
+
+		gokvstores.OpPFAdd:     false,
+		gokvstores.OpPFCount:   true,
+		gokvstores.OpPFMerge:   true,
+		gokvstores.OpBFReserve: true,
+		gokvstores.OpBFAdd:     false,
+		gokvstores.OpBFExists:  true,
+
+		gokvstores.OpSetBit:   false,
+		gokvstores.OpGetBit:   true,
+		gokvstores.OpBitCount: true,
+		gokvstores.OpBitOp:    true,
+		gokvstores.OpBitPos:   true,
+
+		gokvstores.OpGeoAdd:    false,
+		gokvstores.OpGeoPos:    true,
+		gokvstores.OpGeoDist:   true,
+		gokvstores.OpGeoSearch: true,
+
+		gokvstores.OpRunScript:      false,
+		gokvstores.OpRegisterScript: true,
+
+		"Unknown": false,
+	} {
+		is.Equal(idempotent, gokvstores.IsIdempotent(op), op)
+	}
+}
+
+func TestRetryStore_Retryable(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	errCustom := errors.New("custom")
+
+	store, recording, chaos := newRetryStore(t, gokvstores.RetryPolicy{
+		MinBackoff: time.Millisecond,
+		Retryable: func(err error) bool {
+			return errors.Is(err, errCustom)
+		},
+	})
+
+	chaos.FailNext(gokvstores.OpSet, 2, errCustom)
+	is.NoError(store.Set(ctx, "key", "value"))
+	recording.AssertNumberOfCalls(t, gokvstores.OpSet, 3)
+
+	recording.Reset()
+	chaos.FailNext(gokvstores.OpSet, 1, gokvstores.ErrUnavailable)
+	is.Equal(gokvstores.ErrUnavailable, store.Set(ctx, "key", "value"))
+	recording.AssertNumberOfCalls(t, gokvstores.OpSet, 1)
+}
+
+func TestRetryStore_Context(t *testing.T) {
+	is := assert.New(t)
+
+	store, recording, chaos := newRetryStore(t, gokvstores.RetryPolicy{
+		MaxAttempts: 10,
+		MinBackoff:  time.Second,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	chaos.FailNext(gokvstores.OpGet, 10, gokvstores.ErrUnavailable)
+
+	start := time.Now()
+	_, err := store.Get(ctx, "key")
+	is.Equal(gokvstores.ErrUnavailable, err)
+	is.Less(time.Since(start), time.Second)
+	recording.AssertNumberOfCalls(t, gokvstores.OpGet, 1)
+}
+
+func TestIsRetryable(t *testing.T) {
+	is := assert.New(t)
+
+	is.True(gokvstores.IsRetryable(gokvstores.ErrTimeout))
+	is.True(gokvstores.IsRetryable(context.DeadlineExceeded))
+	is.True(gokvstores.IsRetryable(&gokvstores.OpError{Op: gokvstores.OpGet, Err: gokvstores.ErrUnavailable}))
+	is.False(gokvstores.IsRetryable(gokvstores.ErrWrongType))
+	is.False(gokvstores.IsRetryable(gokvstores.ErrNotFound))
+	is.False(gokvstores.IsRetryable(context.Canceled))
+	is.False(gokvstores.IsRetryable(nil))
+}
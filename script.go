@@ -0,0 +1,345 @@
+package gokvstores
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Script is a Lua script run atomically by Redis, for operations which are not covered
+// by the stores interfaces, e.g. a compare-and-set. Scripts are identified by the SHA1
+// digest of their source, so that they are only sent to Redis when it does not know them.
+type Script struct {
+	src  string
+	hash string
+}
+
+// NewScript returns the script of the given Lua source. Keys accessed by the script
+// must be passed as keys to RunScript, and other arguments as args, as for EVAL.
+func NewScript(src string) *Script {
+	sum := sha1.Sum([]byte(src))
+
+	return &Script{src: src, hash: hex.EncodeToString(sum[:])}
+}
+
+// Source returns the Lua source of the script.
+func (s *Script) Source() string {
+	return s.src
+}
+
+// Hash returns the SHA1 digest of the script, as used by EVALSHA.
+func (s *Script) Hash() string {
+	return s.hash
+}
+
+// ScriptFunc is the Go equivalent of a script for stores which cannot run Lua, such as
+// MemoryStore. As in Lua, args are the arguments formatted as strings, and the result
+// is converted as Redis converts Lua values: see ScriptResult.
+//
+// It is called with the store locked: store must only be used with ctx, by the calling
+// goroutine and before returning, and blocking operations return immediately as in Redis.
+type ScriptFunc func(ctx context.Context, store KVStore, keys []string, args []string) (interface{}, error)
+
+// ScriptStore is implemented by stores running scripts.
+type ScriptStore interface {
+	// RunScript runs script atomically with the given keys and arguments, and returns its result.
+	RunScript(ctx context.Context, script *Script, keys []string, args ...interface{}) *ScriptResult
+}
+
+// RunScript runs script on store, which must implement ScriptStore, and returns its result.
+// Wrapper stores use it to forward RunScript to the store they wrap.
+func RunScript(ctx context.Context, store KVStore, script *Script, keys []string, args ...interface{}) *ScriptResult {
+	sstore, ok := store.(ScriptStore)
+	if !ok {
+		return &ScriptResult{err: &OpError{
+			Op:      OpRunScript,
+			Key:     firstKey(keys),
+			Backend: BackendName(store),
+			Err:     fmt.Errorf("gokvstores: %T does not run scripts", store),
+		}}
+	}
+
+	return sstore.RunScript(ctx, script, keys, args...)
+}
+
+// Names of the ScriptStore operations.
+const (
+	OpRunScript      = "RunScript"
+	OpRegisterScript = "RegisterScript"
+)
+
+// ScriptResult is the result of a script, converted as Redis converts Lua values:
+// numbers are truncated to an int64, true is 1, false and nil are nil, and tables
+// are []interface{} of these values. Lua scripts should return floats as strings.
+type ScriptResult struct {
+	val interface{}
+	err error
+}
+
+// Result returns the value returned by the script and its error.
+func (r *ScriptResult) Result() (interface{}, error) {
+	return r.val, r.err
+}
+
+// Val returns the value returned by the script, nil if it failed.
+func (r *ScriptResult) Val() interface{} {
+	return r.val
+}
+
+// Err returns the error of the script.
+func (r *ScriptResult) Err() error {
+	return r.err
+}
+
+// Text returns the string returned by the script.
+// It returns ErrNotFound if the script returned nil.
+func (r *ScriptResult) Text() (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+
+	switch v := r.val.(type) {
+	case nil:
+		return "", errNilScriptResult
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	default:
+		return "", fmt.Errorf("gokvstores: unexpected script result type %T for Text", v)
+	}
+}
+
+// Int64 returns the integer returned by the script, or the integer string it returned.
+// It returns ErrNotFound if the script returned nil.
+func (r *ScriptResult) Int64() (int64, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	switch v := r.val.(type) {
+	case nil:
+		return 0, errNilScriptResult
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("gokvstores: unexpected script result type %T for Int64", v)
+	}
+}
+
+// Float64 returns the number returned by the script, floats being returned as strings.
+// It returns ErrNotFound if the script returned nil.
+func (r *ScriptResult) Float64() (float64, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	switch v := r.val.(type) {
+	case nil:
+		return 0, errNilScriptResult
+	case int64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("gokvstores: unexpected script result type %T for Float64", v)
+	}
+}
+
+// Bool returns whether the script returned a non-zero integer. Since Lua false is
+// converted to nil, it returns false without error if the script returned nil.
+func (r *ScriptResult) Bool() (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+
+	switch v := r.val.(type) {
+	case nil:
+		return false, nil
+	case int64:
+		return v != 0, nil
+	case string:
+		return strconv.ParseBool(v)
+	default:
+		return false, fmt.Errorf("gokvstores: unexpected script result type %T for Bool", v)
+	}
+}
+
+// Slice returns the table returned by the script.
+// It returns ErrNotFound if the script returned nil.
+func (r *ScriptResult) Slice() ([]interface{}, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	switch v := r.val.(type) {
+	case nil:
+		return nil, errNilScriptResult
+	case []interface{}:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("gokvstores: unexpected script result type %T for Slice", v)
+	}
+}
+
+// StringSlice returns the table of strings returned by the script, nil values being empty strings.
+// It returns ErrNotFound if the script returned nil.
+func (r *ScriptResult) StringSlice() ([]string, error) {
+	values, err := r.Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	strs := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			strs[i] = v
+		case int64:
+			strs[i] = strconv.FormatInt(v, 10)
+		default:
+			return nil, fmt.Errorf("gokvstores: unexpected script result type %T for StringSlice", v)
+		}
+	}
+
+	return strs, nil
+}
+
+// Int64Slice returns the table of integers returned by the script.
+// It returns ErrNotFound if the script returned nil.
+func (r *ScriptResult) Int64Slice() ([]int64, error) {
+	values, err := r.Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	ints := make([]int64, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case int64:
+			ints[i] = v
+		case string:
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			ints[i] = n
+		default:
+			return nil, fmt.Errorf("gokvstores: unexpected script result type %T for Int64Slice", v)
+		}
+	}
+
+	return ints, nil
+}
+
+// errNilScriptResult is returned by the ScriptResult methods when the script returned nil.
+var errNilScriptResult = fmt.Errorf("%w: script returned nil", ErrNotFound)
+
+// scriptArgs formats the given arguments as go-redis formats command arguments.
+func scriptArgs(args []interface{}) ([]string, error) {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case nil:
+		case string:
+			strs[i] = v
+		case []byte:
+			strs[i] = string(v)
+		case int:
+			strs[i] = strconv.FormatInt(int64(v), 10)
+		case int8:
+			strs[i] = strconv.FormatInt(int64(v), 10)
+		case int16:
+			strs[i] = strconv.FormatInt(int64(v), 10)
+		case int32:
+			strs[i] = strconv.FormatInt(int64(v), 10)
+		case int64:
+			strs[i] = strconv.FormatInt(v, 10)
+		case uint:
+			strs[i] = strconv.FormatUint(uint64(v), 10)
+		case uint8:
+			strs[i] = strconv.FormatUint(uint64(v), 10)
+		case uint16:
+			strs[i] = strconv.FormatUint(uint64(v), 10)
+		case uint32:
+			strs[i] = strconv.FormatUint(uint64(v), 10)
+		case uint64:
+			strs[i] = strconv.FormatUint(v, 10)
+		case float32:
+			strs[i] = strconv.FormatFloat(float64(v), 'f', -1, 64)
+		case float64:
+			strs[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			strs[i] = "0"
+			if v {
+				strs[i] = "1"
+			}
+		case time.Time:
+			strs[i] = v.Format(time.RFC3339Nano)
+		case time.Duration:
+			strs[i] = strconv.FormatInt(v.Nanoseconds(), 10)
+		case encoding.BinaryMarshaler:
+			b, err := v.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			strs[i] = string(b)
+		default:
+			return nil, fmt.Errorf("gokvstores: unsupported script argument type %T", v)
+		}
+	}
+
+	return strs, nil
+}
+
+// scriptValue converts the result of a ScriptFunc as Redis converts Lua values.
+func scriptValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, string, int64:
+		return v, nil
+	case bool:
+		if v {
+			return int64(1), nil
+		}
+		return nil, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case []byte:
+		return string(v), nil
+	case []string:
+		values := make([]interface{}, len(v))
+		for i, s := range v {
+			values[i] = s
+		}
+		return values, nil
+	case []int64:
+		values := make([]interface{}, len(v))
+		for i, n := range v {
+			values[i] = n
+		}
+		return values, nil
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			value, err := scriptValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("gokvstores: unsupported script result type %T", v)
+	}
+}
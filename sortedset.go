@@ -0,0 +1,76 @@
+package gokvstores
+
+import "context"
+
+// Z is a sorted set member with its score.
+type Z struct {
+	Member string
+	Score  float64
+}
+
+// SortedSetStore is implemented by stores supporting sorted sets, e.g. for leaderboards
+// or time-ordered feeds. Members are ordered by score, then lexicographically.
+type SortedSetStore interface {
+	// ZAdd adds the given members to the sorted set stored at key, or updates their score,
+	// and returns the number of added members.
+	ZAdd(ctx context.Context, key string, members ...Z) (int, error)
+
+	// ZIncrBy increments the score of member in the sorted set stored at key,
+	// adding it if needed, and returns its new score.
+	ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error)
+
+	// ZRangeByScore returns the members with a score between min and max, both included,
+	// by ascending score. It skips offset members and returns at most count members,
+	// or all of them if count <= 0.
+	ZRangeByScore(ctx context.Context, key string, min float64, max float64, offset int, count int) ([]Z, error)
+
+	// ZRevRange returns the members between the start and stop ranks, both included,
+	// by descending score. Negative ranks are offsets from the lowest score, -1 being the last member.
+	ZRevRange(ctx context.Context, key string, start int, stop int) ([]Z, error)
+
+	// ZRank returns the 0-based rank of member by ascending score, or -1 if it is not a member.
+	ZRank(ctx context.Context, key string, member string) (int, error)
+
+	// ZRem removes the given members and returns the number of removed members.
+	ZRem(ctx context.Context, key string, members ...string) (int, error)
+
+	// ZCard returns the number of members of the sorted set stored at key.
+	ZCard(ctx context.Context, key string) (int, error)
+}
+
+// Names of the SortedSetStore operations.
+const (
+	OpZAdd          = "ZAdd"
+	OpZIncrBy       = "ZIncrBy"
+	OpZRangeByScore = "ZRangeByScore"
+	OpZRevRange     = "ZRevRange"
+	OpZRank         = "ZRank"
+	OpZRem          = "ZRem"
+	OpZCard         = "ZCard"
+)
+
+// rangeIndexes converts start and stop ranks, possibly negative, into indexes within
+// a collection of the given length. It reports false if the range is empty.
+func rangeIndexes(start int, stop int, length int) (int, int, bool) {
+	if start < 0 {
+		start += length
+	}
+
+	if stop < 0 {
+		stop += length
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if stop >= length {
+		stop = length - 1
+	}
+
+	if start > stop || start >= length {
+		return 0, 0, false
+	}
+
+	return start, stop, true
+}
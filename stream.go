@@ -0,0 +1,89 @@
+package gokvstores
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errNoStreamValues is returned when appending a message without values, which Redis does not support.
+var errNoStreamValues = errors.New("gokvstores: stream message without values")
+
+// StreamMessage is a message of a stream.
+type StreamMessage struct {
+	// ID is the message ID, "<milliseconds>-<sequence>", assigned by Append.
+	ID string
+
+	// Values are the message fields.
+	Values map[string]string
+}
+
+// PendingMessage is a message delivered to a consumer of a group but not acknowledged yet.
+type PendingMessage struct {
+	// ID is the message ID.
+	ID string
+
+	// Consumer is the consumer the message was last delivered to.
+	Consumer string
+
+	// Idle is the time elapsed since the message was last delivered.
+	Idle time.Duration
+
+	// Deliveries is the number of times the message was delivered.
+	Deliveries int
+}
+
+// StreamStore is implemented by stores supporting append-only streams with consumer groups,
+// e.g. for event fan-out.
+//
+// Each consumer group has its own position in a stream: a message is delivered to a single
+// consumer of the group by ReadGroup, and stays pending until it is acknowledged with Ack.
+// Messages pending for too long, e.g. because their consumer crashed, can be claimed by
+// another consumer with Claim.
+type StreamStore interface {
+	// Append adds a message with the given values to the stream stored at key,
+	// creating the stream if needed, and returns its ID.
+	Append(ctx context.Context, key string, values map[string]string) (string, error)
+
+	// Read returns the messages with an ID greater than id, "0" reading the stream from its start.
+	// It returns at most count messages, or all of them if count <= 0.
+	Read(ctx context.Context, key string, id string, count int) ([]StreamMessage, error)
+
+	// CreateGroup creates a consumer group which delivers the messages with an ID greater than id,
+	// "0" delivering the whole stream and "$" only new messages. The stream is created if needed.
+	// It does nothing if the group already exists.
+	CreateGroup(ctx context.Context, key string, group string, id string) error
+
+	// ReadGroup delivers to consumer at most count messages, or all of them if count <= 0,
+	// never delivered to group. If there are none, it waits for new messages up to block,
+	// or returns immediately if block <= 0. It returns ErrNoGroup if the group does not exist.
+	ReadGroup(ctx context.Context, key string, group string, consumer string, count int, block time.Duration) ([]StreamMessage, error)
+
+	// Ack acknowledges the given pending messages of group and returns the number of acknowledged messages.
+	Ack(ctx context.Context, key string, group string, ids ...string) (int, error)
+
+	// Pending returns the pending messages of group by ascending ID.
+	// It returns at most count messages, or all of them if count <= 0.
+	Pending(ctx context.Context, key string, group string, count int) ([]PendingMessage, error)
+
+	// Claim transfers to consumer at most count messages, or all of them if count <= 0,
+	// pending in group for at least minIdle, and returns them. Pending messages which were
+	// removed from the stream are not returned.
+	Claim(ctx context.Context, key string, group string, consumer string, minIdle time.Duration, count int) ([]StreamMessage, error)
+
+	// Trim removes the oldest messages of the stream stored at key so that it holds
+	// at most maxLen messages, and returns the number of removed messages.
+	Trim(ctx context.Context, key string, maxLen int) (int, error)
+}
+
+// Names of the StreamStore operations.
+const (
+	OpAppend      = "Append"
+	OpRead        = "Read"
+	OpCreateGroup = "CreateGroup"
+	OpReadGroup   = "ReadGroup"
+	OpAck         = "Ack"
+	OpPending     = "Pending"
+	OpClaim       = "Claim"
+	OpTrim        = "Trim"
+)
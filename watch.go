@@ -0,0 +1,57 @@
+package gokvstores
+
+import "context"
+
+// EventType is the type of a key change Event.
+type EventType int
+
+// Key change event types.
+const (
+	// EventSet is sent when the value of a key is set or modified.
+	EventSet EventType = iota + 1
+
+	// EventDelete is sent when a key is deleted, including after the EventSet of the
+	// change which removed the last value of a collection.
+	EventDelete
+
+	// EventExpire is sent when a key expires.
+	EventExpire
+
+	// EventEvict is sent when a key is evicted because of the store memory limit.
+	EventEvict
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventSet:
+		return "set"
+	case EventDelete:
+		return "delete"
+	case EventExpire:
+		return "expire"
+	case EventEvict:
+		return "evict"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a key change notification.
+type Event struct {
+	Type EventType
+	Key  string
+}
+
+// WatchStore is implemented by stores which notify key changes, e.g. to evict local state.
+//
+// As with Pub/Sub, events are delivered at most once and are lost while the watcher
+// is disconnected or does not keep up.
+type WatchStore interface {
+	// Watch returns the channel receiving the events of the keys matching the given
+	// glob-style pattern, as KVStore.Keys, which is closed once ctx is done.
+	Watch(ctx context.Context, pattern string) (<-chan Event, error)
+}
+
+// OpWatch is the name of the WatchStore operation.
+const OpWatch = "Watch"